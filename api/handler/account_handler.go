@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"ps_backend/dto"
+	userService "ps_backend/internal/user"
+	"ps_backend/model"
+	"ps_backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// profileView is the public representation of a user's own profile.
+func profileView(user *model.User) gin.H {
+	return gin.H{
+		"id":                    user.ID,
+		"username":              user.Username,
+		"display_name":          user.DisplayName,
+		"phone_number":          user.PhoneNumber,
+		"verified":              user.Verified,
+		"persona":               user.Persona,
+		"timezone":              user.Timezone,
+		"locale":                user.Locale,
+		"plan":                  user.Plan,
+		"role":                  user.Role,
+		"created_at":            user.CreatedAt,
+		"deletion_scheduled_at": user.DeletionScheduledAt,
+	}
+}
+
+// personaUpdate converts the optional persona part of a request body.
+func personaUpdate(req *dto.PersonaRequest) *userService.PersonaUpdate {
+	if req == nil {
+		return nil
+	}
+	return &userService.PersonaUpdate{
+		Formality: req.Formality,
+		Warmth:    req.Warmth,
+		Humor:     req.Humor,
+		Verbosity: req.Verbosity,
+		Language:  req.Language,
+		Nickname:  req.Nickname,
+	}
+}
+
+// signUpPersona builds the persona of a new user from the request, falling back
+// to the deprecated free-text speaking style and tone.
+func signUpPersona(req *dto.PersonaRequest, speakingStyle, tone string) (model.Persona, error) {
+	base := model.PersonaFromLegacy(speakingStyle, tone)
+	update := personaUpdate(req)
+	if update == nil {
+		update = &userService.PersonaUpdate{}
+	}
+	return userService.MergePersona(base, *update)
+}
+
+// GetPersonaOptions lists the selectable chatbot persona settings with Korean and English labels.
+func GetPersonaOptions(c *gin.Context) {
+	languages := []model.PersonaOption{}
+	for _, locale := range utils.SupportedLocales() {
+		labels, ok := model.LanguageLabels[locale]
+		if !ok {
+			labels = map[string]string{"ko": locale, "en": locale}
+		}
+		languages = append(languages, model.PersonaOption{Value: locale, Labels: labels})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"formality": model.Personas.Formality,
+		"warmth":    model.Personas.Warmth,
+		"humor":     model.Personas.Humor,
+		"verbosity": model.Personas.Verbosity,
+		"language":  languages,
+		"default":   model.DefaultPersona(),
+	}})
+}
+
+// currentUser loads the authenticated user, writing a 404 response if it no longer exists.
+func currentUser(c *gin.Context) (*model.User, bool) {
+	user, err := userSvc.GetByID(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// GetMyProfile returns the profile of the authenticated user.
+func GetMyProfile(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profileView(user)})
+}
+
+// UpdateMyProfile partially updates the profile of the authenticated user.
+func UpdateMyProfile(c *gin.Context) {
+	var req dto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	err := userSvc.UpdateProfile(user, userService.ProfileUpdate{
+		Persona:     personaUpdate(req.Persona),
+		DisplayName: req.DisplayName,
+		Timezone:    req.Timezone,
+		Locale:      req.Locale,
+	})
+	switch {
+	case errors.Is(err, userService.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+	case errors.Is(err, userService.ErrUnsupportedLocale):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+	case errors.Is(err, userService.ErrInvalidPersona):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Profile updated", "data": profileView(user)})
+	}
+}
+
+// ChangeMyPassword replaces the password of the authenticated user and signs out every session.
+func ChangeMyPassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	err := userSvc.ChangePassword(user, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, userService.ErrInvalidPassword) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if errors.Is(err, utils.ErrWeakPassword) {
+		writeWeakPassword(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+	if _, err := tokenSvc.RevokeAll(user.ID); err != nil {
+		logrus.WithError(err).WithField("userID", user.ID).Error("Failed to revoke refresh tokens")
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+// ChangeMyPhone sends an OTP to a new phone number of the authenticated user.
+// The number is changed once the code is confirmed with VerifyMyPhone, so the
+// current number stays in use until then. The response is the same whether or
+// not the new number is registered to another account.
+func ChangeMyPhone(c *gin.Context) {
+	var req dto.ChangePhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok || !allowAttempt(c, otpTargetLimiter, fmt.Sprintf("user:%d", user.ID)) {
+		return
+	}
+	if req.PhoneNumber == user.PhoneNumber {
+		sendPhoneOTP(c, user)
+		return
+	}
+	sendPhoneChangeOTP(c, user, req.PhoneNumber)
+}
+
+// ResendMyPhoneOTP sends a new OTP for a pending phone change, or to the
+// unverified phone number of the authenticated user.
+func ResendMyPhoneOTP(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	phone, pending := authSvc.PendingPhoneChange(user.ID)
+	if !pending && user.Verified {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already verified"})
+		return
+	}
+	if !allowAttempt(c, otpTargetLimiter, fmt.Sprintf("user:%d", user.ID)) {
+		return
+	}
+	if pending {
+		sendPhoneChangeOTP(c, user, phone)
+		return
+	}
+	sendPhoneOTP(c, user)
+}
+
+func sendPhoneOTP(c *gin.Context, user *model.User) {
+	if user.Verified {
+		c.JSON(http.StatusOK, gin.H{"message": "Phone number unchanged", "data": profileView(user)})
+		return
+	}
+	if err := authSvc.GenerateAndSendOTP(user.ID, user.PhoneNumber); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send verification code", "data": profileView(user)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Verification code sent", "data": profileView(user)})
+}
+
+func sendPhoneChangeOTP(c *gin.Context, user *model.User, phone string) {
+	if err := authSvc.SendPhoneChangeOTP(user.ID, phone); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send verification code", "data": profileView(user)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Verification code sent", "data": profileView(user)})
+}
+
+// VerifyMyPhone confirms a pending phone change, or the phone number of the
+// authenticated user, with the OTP code.
+func VerifyMyPhone(c *gin.Context) {
+	var req dto.VerifyMyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	userID := c.GetUint("user_id")
+	if !allowAttempt(c, otpTargetLimiter, fmt.Sprintf("user:%d", userID)) {
+		return
+	}
+	if _, pending := authSvc.PendingPhoneChange(userID); pending {
+		verifyPhoneChange(c, userID, req.Code)
+		return
+	}
+	if !authSvc.ValidateOTP(userID, req.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
+		return
+	}
+	if err := authSvc.MarkPhoneVerified(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify phone"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Phone number verified"})
+}
+
+// verifyPhoneChange applies a phone change once its code is confirmed. Only the
+// holder of the new number gets this far, so a taken number can be reported.
+func verifyPhoneChange(c *gin.Context, userID uint, code string) {
+	phone, ok := authSvc.ValidatePhoneChangeOTP(userID, code)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	err := userSvc.ChangePhone(user, phone)
+	if errors.Is(err, userService.ErrPhoneTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already registered"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change phone number"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Phone number changed", "data": profileView(user)})
+}
+
+// ListMyLogins returns the most recent sign-in attempts on the authenticated user's account.
+func ListMyLogins(c *gin.Context) {
+	history, err := authSvc.LoginHistory(c.GetUint("user_id"), 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load login history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+// RequestDataExport starts building an archive of all personal data of the authenticated user.
+// The archive is downloaded through the export endpoints.
+func RequestDataExport(c *gin.Context) {
+	job, err := exportSvc.RequestArchive(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request data export"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Data export requested", "data": job})
+}
+
+// DeleteMyAccount schedules the deletion of the authenticated user's account after
+// the grace period and signs the user out of every session.
+func DeleteMyAccount(c *gin.Context) {
+	var req dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if err := userSvc.RequestDeletion(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule deletion"})
+		return
+	}
+	if _, err := tokenSvc.RevokeAll(user.ID); err != nil {
+		logrus.WithError(err).WithField("userID", user.ID).Error("Failed to revoke refresh tokens")
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Account deletion scheduled",
+		"data": gin.H{
+			"deletion_scheduled_at": user.DeletionScheduledAt,
+			"grace_period_hours":    int(userService.DeletionGracePeriod().Hours()),
+		},
+	})
+}
+
+// CancelAccountDeletion cancels a pending deletion of the authenticated user's account.
+func CancelAccountDeletion(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "No deletion is scheduled"})
+		return
+	}
+	if err := userSvc.CancelDeletion(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"ps_backend/dto"
+	promptService "ps_backend/internal/prompt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseIDParam reads the :id path parameter, writing a 400 response if it is invalid.
+func parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := dto.ParseUint(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListPrompts returns every chatbot prompt.
+func ListPrompts(c *gin.Context) {
+	prompts, err := promptSvc.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve prompts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": prompts})
+}
+
+// CreatePrompt adds a new, inactive chatbot prompt.
+func CreatePrompt(c *gin.Context) {
+	var req dto.PromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	p, err := promptSvc.Create(req.Name, req.Content, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to create prompt"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Prompt created", "data": p})
+}
+
+// UpdatePrompt changes the name and content of a chatbot prompt.
+func UpdatePrompt(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.PromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	p, err := promptSvc.Update(id, req.Name, req.Content, c.GetUint("user_id"))
+	if errors.Is(err, promptService.ErrPromptNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prompt"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt updated", "data": p})
+}
+
+// ActivatePrompt makes a prompt the one used by the chatbot.
+func ActivatePrompt(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	p, err := promptSvc.Activate(id, c.GetUint("user_id"))
+	if errors.Is(err, promptService.ErrPromptNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to activate prompt"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt activated", "data": p})
+}
+
+// DeletePrompt removes a chatbot prompt.
+func DeletePrompt(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := promptSvc.Delete(id)
+	if errors.Is(err, promptService.ErrPromptNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prompt"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt deleted"})
+}
+
+// UpdateUserRole changes the role of a user. It applies to the user's next request,
+// as the authentication middleware reads the stored role.
+func UpdateUserRole(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	user, err := userSvc.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.ID == c.GetUint("user_id") && req.Role != user.Role {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot change your own role"})
+		return
+	}
+	if err := userSvc.ChangeRole(user, req.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "data": gin.H{"user_id": user.ID, "role": user.Role}})
+}
@@ -1,68 +1,46 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"time"
+	"strings"
 
 	"ps_backend/db"
 	dto "ps_backend/dto"
 	authservice "ps_backend/internal/auth"
-	"ps_backend/model"
-	jwt "ps_backend/pkg/middleware"
+	deviceservice "ps_backend/internal/device"
+	tokenservice "ps_backend/internal/token"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/middleware"
 	"ps_backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 var authSvc = authservice.NewAuthService(db.GetDB())
+var tokenSvc = tokenservice.NewService(db.GetDB())
 
-// Register handles user registration requests
-func Register(c *gin.Context) {
-	var req dto.SignUpRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
-		return
-	}
-
-	// Check for existing username or phone
-	if exists, err := authSvc.UserExistsByUsername(req.Username); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
-		return
-	} else if exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
-		return
-	}
-
-	if exists, err := authSvc.UserExistsByPhone(req.PhoneNumber); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check phone number"})
-		return
-	} else if exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already registered"})
-		return
-	}
-
-	// Hash password
-	hashed, err := utils.HashPassword(req.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
-	}
+// Per-target limits complement the per-IP limits set up in the router: they stop
+// guessing against one account or phone number from many addresses.
+var (
+	loginUserLimiter = utils.NewRateLimiterFromConfig(config.Get().Login.UserRate)
+	otpTargetLimiter = utils.NewRateLimiterFromConfig(config.Get().OTP.TargetRate)
+)
 
-	user := &model.User{
-		Username:      req.Username,
-		PasswordHash:  hashed,
-		PhoneNumber:   req.PhoneNumber,
-		SpeakingStyle: req.SpeakingStyle,
-		Tone:          req.Tone,
-		CreatedAt:     time.Now(),
-	}
-
-	if err := authSvc.CreateUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Registration failed: " + err.Error()})
-		return
+// allowAttempt records an attempt against the key, writing a 429 response when over the limit.
+func allowAttempt(c *gin.Context, limiter *utils.RateLimiter, key string) bool {
+	if ok, retryAfter := limiter.Allow(key); !ok {
+		middleware.AbortTooManyRequests(c, retryAfter)
+		return false
 	}
+	return true
+}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "User registered", "user_id": user.ID})
+// writeWeakPassword answers a password that does not meet the policy.
+func writeWeakPassword(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "password_policy": utils.LoadPasswordPolicy()})
 }
 
 // SignIn handles authentication and JWT issuance
@@ -73,27 +51,47 @@ func SignIn(c *gin.Context) {
 		return
 	}
 
-	user, err := authSvc.GetUserByUsername(req.Username)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	key := strings.ToLower(req.Username)
+	if !allowAttempt(c, loginUserLimiter, key) {
 		return
 	}
-
-	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+	user, err := authSvc.Authenticate(req.Username, req.Password, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, authservice.ErrInvalidCredentials) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+	loginUserLimiter.Reset(key)
+
+	var deviceID uint
+	if req.Device != nil {
+		device, err := deviceSvc.Register(user.ID, deviceInput(req.Device), c.ClientIP())
+		if errors.Is(err, deviceservice.ErrTooManyDevices) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Too many devices; revoke one first"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register device"})
+			return
+		}
+		deviceID = device.ID
+	}
 
-	accessToken, refreshToken, err := jwt.GenerateToken(user.ID, user.Username)
+	pair, err := tokenSvc.Issue(user, deviceID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "Login successful",
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"message":               "Login successful",
+		"access_token":          pair.AccessToken,
+		"refresh_token":         pair.RefreshToken,
+		"device_id":             deviceID,
+		"deletion_scheduled_at": user.DeletionScheduledAt,
 	})
 }
 
@@ -105,6 +103,10 @@ func VerifyPhone(c *gin.Context) {
 		return
 	}
 
+	if !allowAttempt(c, otpTargetLimiter, fmt.Sprintf("user:%d", req.UserID)) {
+		return
+	}
+
 	// Validate OTP code
 	if !authSvc.ValidateOTP(req.UserID, req.Code) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
@@ -128,15 +130,99 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := jwt.GenerateTokenFromRefresh(req.RefreshToken)
-	if err != nil {
+	// Each refresh token can be used once; the user is reloaded so role changes take effect.
+	_, pair, err := tokenSvc.Rotate(req.RefreshToken)
+	if errors.Is(err, tokenservice.ErrInvalidRefreshToken) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	if err := deviceSvc.Touch(pair.DeviceID, c.ClientIP()); err != nil {
+		logrus.WithError(err).WithField("deviceID", pair.DeviceID).Warn("Failed to update device last seen")
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "Token refreshed",
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"access_token":  pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
 	})
 }
+
+// SignOut revokes the given refresh token. The access token stays valid until it expires.
+func SignOut(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := tokenSvc.Revoke(req.RefreshToken); err != nil && !errors.Is(err, tokenservice.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Signed out"})
+}
+
+// ForgotPassword sends a password reset code by SMS. The response is the same
+// whether or not the phone number is registered.
+func ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if !allowAttempt(c, otpTargetLimiter, "phone:"+req.PhoneNumber) {
+		return
+	}
+	if err := authSvc.RequestPasswordReset(req.PhoneNumber); err != nil {
+		logrus.WithError(err).Error("Failed to request password reset")
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the phone number is registered, a reset code has been sent"})
+}
+
+// VerifyPasswordReset exchanges a reset code for a short-lived reset token.
+func VerifyPasswordReset(c *gin.Context) {
+	var req dto.VerifyPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if !allowAttempt(c, otpTargetLimiter, "phone:"+req.PhoneNumber) {
+		return
+	}
+	token, expiresAt, err := authSvc.VerifyPasswordReset(req.PhoneNumber, req.Code)
+	if errors.Is(err, authservice.ErrInvalidResetCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"reset_token": token, "expires_at": expiresAt}})
+}
+
+// ResetPassword sets a new password with a reset token and signs out every session.
+func ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	_, err := authSvc.ResetPassword(req.ResetToken, req.NewPassword)
+	if errors.Is(err, authservice.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if errors.Is(err, utils.ErrWeakPassword) {
+		writeWeakPassword(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset; please sign in again"})
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/breathing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var breathingSvc = breathing.NewService(db.GetDB(), wsHub)
+
+// ListBreathingPatterns returns the built-in breathing patterns.
+func ListBreathingPatterns(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": breathing.Patterns})
+}
+
+// StartBreathingSession starts a server-paced breathing session for the authenticated user.
+// Cues are pushed over the user's WebSocket connection.
+func StartBreathingSession(c *gin.Context) {
+	var req dto.StartBreathingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	phases := make([]breathing.Phase, len(req.Phases))
+	for i, p := range req.Phases {
+		phases[i] = breathing.Phase{Cue: p.Cue, Seconds: p.Seconds}
+	}
+	session, err := breathingSvc.Start(breathing.StartInput{
+		UserID:       c.GetUint("user_id"),
+		PanicGuideID: req.PanicGuideID,
+		Pattern:      req.Pattern,
+		Phases:       phases,
+		Cycles:       req.Cycles,
+	})
+	switch {
+	case errors.Is(err, breathing.ErrInvalidSession):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, breathing.ErrGuideNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Panic guide not found"})
+		return
+	case errors.Is(err, breathing.ErrSessionActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Another breathing session is running"})
+		return
+	case err != nil:
+		logrus.WithError(err).Errorf("failed to start breathing session for user %d", c.GetUint("user_id"))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start breathing session"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Breathing session started", "data": session})
+}
+
+// StopBreathingSession aborts a running breathing session.
+func StopBreathingSession(c *gin.Context) {
+	id, err := dto.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+		return
+	}
+	session, err := breathingSvc.Stop(c.GetUint("user_id"), uint(id))
+	switch {
+	case errors.Is(err, breathing.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Breathing session not found"})
+		return
+	case errors.Is(err, breathing.ErrSessionNotRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": "Breathing session is not running"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop breathing session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Breathing session stopped", "data": session})
+}
+
+// GetBreathingSession returns a breathing session of the authenticated user.
+func GetBreathingSession(c *gin.Context) {
+	id, err := dto.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+		return
+	}
+	session, err := breathingSvc.Get(c.GetUint("user_id"), uint(id))
+	if errors.Is(err, breathing.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Breathing session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve breathing session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": session})
+}
+
+// ListBreathingSessions returns the breathing sessions of the authenticated user.
+func ListBreathingSessions(c *gin.Context) {
+	sessions, err := breathingSvc.List(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve breathing sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// GetBreathingEffectiveness returns the average heart rate drop per guide and pattern.
+// With scope=me only the authenticated user's sessions are aggregated.
+func GetBreathingEffectiveness(c *gin.Context) {
+	var userID uint
+	if c.Query("scope") == "me" {
+		userID = c.GetUint("user_id")
+	}
+	stats, err := breathingSvc.Effectiveness(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve effectiveness"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
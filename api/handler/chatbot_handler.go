@@ -3,16 +3,26 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"ps_backend/db"
+	"ps_backend/internal/crisis"
+	promptService "ps_backend/internal/prompt"
+	"ps_backend/internal/usage"
 	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/metrics"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 var chatdb = db.GetDB()
+var promptSvc = promptService.NewService(chatdb)
 
 type GeminiRequest struct {
 	Contents []struct {
@@ -21,6 +31,12 @@ type GeminiRequest struct {
 			Text string `json:"text"`
 		} `json:"parts"`
 	} `json:"contents"`
+	GenerationConfig GeminiGenerationConfig `json:"generationConfig"`
+}
+
+// GeminiGenerationConfig limits the reply Gemini generates.
+type GeminiGenerationConfig struct {
+	MaxOutputTokens int `json:"maxOutputTokens"`
 }
 
 type GeminiResponse struct {
@@ -31,15 +47,38 @@ type GeminiResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata GeminiUsage `json:"usageMetadata"`
+}
+
+// GeminiUsage is the token accounting returned by the Gemini API.
+type GeminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
 }
 
 type ChatRequest struct {
-	UserID  uint   `json:"user_id"`
 	Message string `json:"message"`
 }
 
-// 실제 Gemini API키로 대체해야 함!
-const geminiAPIKey = "YOUR_GEMINI_API_KEY"
+const geminiModel = "gemini-pro"
+
+// geminiMaxOutputTokens caps the reply, so the tokens of a call can be
+// estimated before it is made.
+const geminiMaxOutputTokens = 1024
+
+// estimateTokens bounds the tokens a call with prompt uses: Gemini spends at
+// most one token per character of the prompt, and the reply is capped at
+// geminiMaxOutputTokens.
+func estimateTokens(prompt string) int {
+	return utf8.RuneCountInString(prompt) + geminiMaxOutputTokens
+}
+
+var (
+	llmDuration = metrics.NewHistogramVec("llm_request_duration_seconds", "Latency of LLM API calls by model.",
+		[]float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60}, "model")
+	llmRequests = metrics.NewCounterVec("llm_requests_total", "LLM API calls by model and result (success or error).", "model", "result")
+)
 
 func ChatWithGemini(c *gin.Context) {
 	var req ChatRequest
@@ -48,43 +87,107 @@ func ChatWithGemini(c *gin.Context) {
 		return
 	}
 
-	// 유저 말투, 스타일 로드
+	// 유저 말투, 스타일 로드 (요청 본문이 아닌 인증된 사용자 기준)
+	userID := c.GetUint("user_id")
 	var user model.User
-	if err := chatdb.First(&user, req.UserID).Error; err != nil {
+	if err := chatdb.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "유저 없음"})
 		return
 	}
 
 	// 최근 대화 이력(최대 5개)
 	var logs []model.ChatbotLog
-	chatdb.Where("user_id = ?", req.UserID).Order("created_at desc").Limit(5).Find(&logs)
+	chatdb.Where("user_id = ?", user.ID).Order("created_at desc").Limit(5).Find(&logs)
 	var history string
 	for i := len(logs) - 1; i >= 0; i-- {
 		history += fmt.Sprintf("%s: %s\n", logs[i].Sender, logs[i].Message)
 	}
 
-	// Gemini 프롬프트 구성
+	// Gemini 프롬프트 구성 (관리자가 활성화한 시스템 프롬프트가 있으면 앞에 붙임)
 	prompt := fmt.Sprintf(
-		"사용자의 말투는 '%s', 스타일은 '%s'입니다.\n최근 대화 기록:\n%sUser: %s",
-		user.SpeakingStyle, user.Tone, history, req.Message,
+		"다음 말투 설정을 지켜 답하세요.\n%s최근 대화 기록:\n%sUser: %s",
+		user.Persona.Instructions(), history, req.Message,
 	)
+	if active, err := promptSvc.GetActive(); err != nil {
+		logrus.WithError(err).Error("failed to load active chatbot prompt")
+	} else if active != nil {
+		prompt = active.Content + "\n\n" + prompt
+	}
 
-	// Gemini API 호출
-	reply, err := CallGeminiAPI(prompt)
+	// 사용량 한도 확인 후 예상 토큰을 먼저 예약 (초과해도 위기 상담 자원은 함께 안내)
+	record, summary, err := usageSvc.Reserve(&user, geminiModel, estimateTokens(prompt))
+	if err != nil {
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":            "챗봇 사용 한도를 초과했습니다. 도움이 필요하면 아래 상담 창구에 연락하세요.",
+				"usage":            summary,
+				"crisis_resources": crisis.Resources(),
+			})
+			return
+		}
+		logrus.WithError(err).Errorf("failed to reserve llm usage for user %d", user.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "사용량 조회 실패"})
+		return
+	}
+
+	// Gemini API 호출 후 예약을 실제 사용량으로 교체
+	started := time.Now()
+	reply, tokens, err := CallGeminiAPI(prompt)
+	record.PromptTokens = tokens.PromptTokenCount
+	record.CompletionTokens = tokens.CandidatesTokenCount
+	record.TotalTokens = tokens.TotalTokenCount
+	record.LatencyMs = time.Since(started).Milliseconds()
+	record.Success = err == nil
+	llmDuration.Observe(time.Since(started).Seconds(), geminiModel)
+	if err != nil {
+		llmRequests.Inc(geminiModel, "error")
+	} else {
+		llmRequests.Inc(geminiModel, "success")
+	}
+	if recErr := usageSvc.Finish(record); recErr != nil {
+		logrus.WithError(recErr).Errorf("failed to record llm usage for user %d", user.ID)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gemini 호출 실패"})
+		var apiErr *GeminiAPIError
+		if errors.As(err, &apiErr) {
+			logrus.WithError(err).Errorf("Gemini rejected the chat request of user %d", user.ID)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Gemini 호출 실패", "crisis_resources": crisis.Resources()})
+			return
+		}
+		logrus.WithError(err).Errorf("Gemini call failed for user %d", user.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gemini 호출 실패", "crisis_resources": crisis.Resources()})
 		return
 	}
 
 	// 로그 저장
-	chatdb.Create(&model.ChatbotLog{UserID: req.UserID, Message: req.Message, Sender: "user"})
-	chatdb.Create(&model.ChatbotLog{UserID: req.UserID, Message: reply, Sender: "bot"})
+	chatdb.Create(&model.ChatbotLog{UserID: user.ID, Message: req.Message, Sender: "user"})
+	chatdb.Create(&model.ChatbotLog{UserID: user.ID, Message: reply, Sender: "bot"})
 
 	c.JSON(http.StatusOK, gin.H{"reply": reply})
 }
 
-func CallGeminiAPI(prompt string) (string, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-pro:generateContent?key=%s", geminiAPIKey)
+// GeminiAPIError is returned by CallGeminiAPI when Gemini answers with a
+// non-2xx status, e.g. 401 for a bad key or 429 when its quota is used up.
+type GeminiAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *GeminiAPIError) Error() string {
+	body := e.Body
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	return fmt.Sprintf("gemini api returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), body)
+}
+
+// CallGeminiAPI sends the prompt to Gemini and returns the reply together with its token usage.
+func CallGeminiAPI(prompt string) (string, GeminiUsage, error) {
+	cfg := config.Get()
+	if cfg == nil || cfg.Gemini.Key == "" {
+		return "", GeminiUsage{}, errors.New("GEMINI_API_KEY is not configured")
+	}
+	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent", cfg.GeminiURL(), geminiModel)
 	payload := GeminiRequest{
 		Contents: []struct {
 			Role  string `json:"role"`
@@ -101,23 +204,37 @@ func CallGeminiAPI(prompt string) (string, error) {
 				},
 			},
 		},
+		GenerationConfig: GeminiGenerationConfig{MaxOutputTokens: geminiMaxOutputTokens},
+	}
+	jsonBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", GeminiUsage{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(jsonBytes))
+	if err != nil {
+		return "", GeminiUsage{}, err
 	}
-	jsonBytes, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBytes))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", cfg.Gemini.Key)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", GeminiUsage{}, err
 	}
 	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-	// 응답에서 답변 텍스트만 추출
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", GeminiUsage{}, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", GeminiUsage{}, &GeminiAPIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	// 응답에서 답변 텍스트와 토큰 사용량 추출
 	var gemResp GeminiResponse
 	if err := json.Unmarshal(body, &gemResp); err != nil {
-		return "", err
+		return "", GeminiUsage{}, err
 	}
 	if len(gemResp.Candidates) == 0 || len(gemResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("Gemini 응답 파싱 실패")
+		return "", gemResp.UsageMetadata, fmt.Errorf("Gemini 응답 파싱 실패")
 	}
-	return gemResp.Candidates[0].Content.Parts[0].Text, nil
+	return gemResp.Candidates[0].Content.Parts[0].Text, gemResp.UsageMetadata, nil
 }
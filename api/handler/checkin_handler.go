@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/checkin"
+
+	"github.com/gin-gonic/gin"
+)
+
+var checkInSvc = checkin.NewService(db.GetDB(), wsHub, notifierSvc)
+
+// GetCheckInSchedule returns the daily check-in times of the authenticated user.
+func GetCheckInSchedule(c *gin.Context) {
+	slots, err := checkInSvc.GetSchedule(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load check-in schedule"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": slots})
+}
+
+// UpdateCheckInSchedule replaces the daily check-in times of the authenticated user.
+// An empty list turns scheduled check-ins off.
+func UpdateCheckInSchedule(c *gin.Context) {
+	var req dto.CheckInScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	slots, err := checkInSvc.SetSchedule(c.GetUint("user_id"), req.Times, enabled)
+	if errors.Is(err, checkin.ErrInvalidSchedule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Times must be distinct HH:MM values, at most 6"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update check-in schedule"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Check-in schedule updated", "data": slots})
+}
+
+// SubmitCheckIn records a mood and anxiety check-in of the authenticated user.
+func SubmitCheckIn(c *gin.Context) {
+	var req dto.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	entry, err := checkInSvc.Respond(c.GetUint("user_id"), checkin.ResponseInput{
+		ScheduleID:   req.ScheduleID,
+		Mood:         *req.Mood,
+		Nervousness:  *req.Nervousness,
+		Worry:        *req.Worry,
+		SleepQuality: req.SleepQuality,
+		Note:         strings.TrimSpace(req.Note),
+	})
+	switch {
+	case errors.Is(err, checkin.ErrInvalidCheckIn):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Scores are out of range"})
+		return
+	case errors.Is(err, checkin.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Check-in schedule not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record check-in"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Check-in recorded", "data": entry})
+}
+
+// ListMyCheckIns returns the check-ins of the authenticated user within the from/to range (default 30 days).
+func ListMyCheckIns(c *gin.Context) {
+	from, to, err := dto.ParseTimeRange(c.Query("from"), c.Query("to"), 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := checkInSvc.List(c.GetUint("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve check-ins"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// GetCheckInTrends returns daily mood and anxiety next to vitals and panic
+// episodes of the authenticated user, with their correlations.
+func GetCheckInTrends(c *gin.Context) {
+	from, to, err := dto.ParseTimeRange(c.Query("from"), c.Query("to"), 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	trend, err := checkInSvc.Trends(c.GetUint("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build check-in trends"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": trend})
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	deviceservice "ps_backend/internal/device"
+
+	"github.com/gin-gonic/gin"
+)
+
+var deviceSvc = deviceservice.NewService(db.GetDB())
+
+// deviceInput converts a device description from a request body.
+func deviceInput(req *dto.DeviceRequest) deviceservice.Input {
+	return deviceservice.Input{
+		InstallationID: req.InstallationID,
+		Type:           req.Type,
+		Name:           req.Name,
+		Model:          req.Model,
+		OS:             req.OS,
+		PushToken:      req.PushToken,
+		PushProvider:   req.PushProvider,
+	}
+}
+
+// ListMyDevices returns the devices of the authenticated user and marks the one making the request.
+func ListMyDevices(c *gin.Context) {
+	devices, err := deviceSvc.List(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load devices"})
+		return
+	}
+	current := c.GetUint("device_id")
+	items := make([]gin.H, 0, len(devices))
+	for _, d := range devices {
+		items = append(items, gin.H{
+			"id":              d.ID,
+			"installation_id": d.InstallationID,
+			"type":            d.Type,
+			"name":            d.Name,
+			"model":           d.Model,
+			"os":              d.OS,
+			"push_enabled":    d.PushToken != "",
+			"push_provider":   d.PushProvider,
+			"last_seen_at":    d.LastSeenAt,
+			"last_ip":         d.LastIP,
+			"revoked_at":      d.RevokedAt,
+			"created_at":      d.CreatedAt,
+			"current":         d.ID != 0 && d.ID == current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// RegisterMyDevice registers a device, such as a paired watch, or updates an
+// existing one, e.g. when its push token changes.
+func RegisterMyDevice(c *gin.Context) {
+	var req dto.DeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	device, err := deviceSvc.Register(c.GetUint("user_id"), deviceInput(&req), c.ClientIP())
+	if errors.Is(err, deviceservice.ErrTooManyDevices) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many devices; revoke one first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register device"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Device registered", "data": device})
+}
+
+// RevokeMyDevice signs a device out and stops push notifications to it.
+func RevokeMyDevice(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := deviceSvc.Revoke(c.GetUint("user_id"), id)
+	if errors.Is(err, deviceservice.ErrDeviceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke device"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Device revoked"})
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/crisis"
+	"ps_backend/internal/emergency"
+	"ps_backend/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var emergencySvc = emergency.NewService(db.GetDB())
+
+func contactInput(req dto.EmergencyContactRequest) emergency.ContactInput {
+	return emergency.ContactInput{
+		Name:             req.Name,
+		PhoneNumber:      req.PhoneNumber,
+		Relationship:     req.Relationship,
+		ConsentConfirmed: req.ConsentConfirmed,
+	}
+}
+
+// ListEmergencyContacts returns the emergency contacts of the authenticated user.
+func ListEmergencyContacts(c *gin.Context) {
+	contacts, err := emergencySvc.GetContacts(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve emergency contacts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": contacts})
+}
+
+// CreateEmergencyContact adds an emergency contact for the authenticated user.
+func CreateEmergencyContact(c *gin.Context) {
+	var req dto.EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	contact, err := emergencySvc.CreateContact(c.GetUint("user_id"), contactInput(req))
+	if errors.Is(err, emergency.ErrTooManyContacts) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Emergency contact limit reached"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create emergency contact"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Emergency contact created", "data": contact})
+}
+
+// UpdateEmergencyContact replaces an emergency contact of the authenticated user.
+// Changing the phone number resets the consent unless it is confirmed again.
+func UpdateEmergencyContact(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	contact, err := emergencySvc.UpdateContact(c.GetUint("user_id"), id, contactInput(req))
+	if errors.Is(err, emergency.ErrContactNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency contact not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update emergency contact"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Emergency contact updated", "data": contact})
+}
+
+// DeleteEmergencyContact removes an emergency contact of the authenticated user.
+func DeleteEmergencyContact(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := emergencySvc.DeleteContact(c.GetUint("user_id"), id)
+	if errors.Is(err, emergency.ErrContactNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency contact not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete emergency contact"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Emergency contact deleted"})
+}
+
+// SendSOS notifies the consented emergency contacts of the authenticated user by SMS.
+// Crisis resources are always included so the user has somewhere to turn.
+func SendSOS(c *gin.Context) {
+	var req dto.SOSRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	alert, err := emergencySvc.TriggerSOS(emergency.SOSInput{
+		UserID:    c.GetUint("user_id"),
+		Trigger:   model.SOSTriggerManual,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	})
+	resources := crisis.Resources()
+	switch {
+	case errors.Is(err, emergency.ErrRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "SOS alerts were sent recently", "data": alert, "crisis_resources": resources})
+	case errors.Is(err, emergency.ErrNoContacts):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No emergency contact has confirmed consent", "data": alert, "crisis_resources": resources})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send SOS", "crisis_resources": resources})
+	case alert.Status != model.SOSStatusSent:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No SOS message could be delivered", "data": alert, "crisis_resources": resources})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"message": "SOS sent", "data": alert, "crisis_resources": resources})
+	}
+}
+
+// ListSOSAlerts returns the SOS audit trail of the authenticated user.
+func ListSOSAlerts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	alerts, err := emergencySvc.GetAlerts(c.GetUint("user_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve SOS alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alerts})
+}
+
+// alertContactsOnPanic queues an automatic SOS for a measurement that indicates a panic attack.
+func alertContactsOnPanic(userID uint) {
+	if err := emergencySvc.QueuePanicSOS(userID); err != nil {
+		logrus.WithError(err).WithField("userID", userID).Error("Failed to queue automatic SOS")
+	}
+}
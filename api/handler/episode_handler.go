@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/episode"
+
+	"github.com/gin-gonic/gin"
+)
+
+var episodeSvc = episode.NewService(db.GetDB())
+
+// ListMyEpisodes returns the panic episodes of the authenticated user within the from/to range (default 30 days).
+func ListMyEpisodes(c *gin.Context) {
+	from, to, err := dto.ParseTimeRange(c.Query("from"), c.Query("to"), 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	episodes, err := episodeSvc.List(c.GetUint("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve episodes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": episodes})
+}
+
+// ReportEpisode records a self-reported panic episode of the authenticated user.
+func ReportEpisode(c *gin.Context) {
+	var req dto.EpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	in := episode.ReportInput{
+		EndedAt:   req.EndedAt,
+		Intensity: req.Intensity,
+		Notes:     strings.TrimSpace(req.Notes),
+		Location:  req.Location,
+		Triggers:  req.Triggers,
+	}
+	if req.StartedAt != nil {
+		in.StartedAt = *req.StartedAt
+	}
+	ep, err := episodeSvc.Report(c.GetUint("user_id"), in)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Episode recorded", "data": ep})
+}
+
+// UpdateEpisode changes the self-reported details of a panic episode of the authenticated user.
+func UpdateEpisode(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.EpisodeUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if req.Notes != nil {
+		notes := strings.TrimSpace(*req.Notes)
+		req.Notes = &notes
+	}
+	ep, err := episodeSvc.Annotate(c.GetUint("user_id"), id, episode.AnnotateInput{
+		Intensity: req.Intensity,
+		Notes:     req.Notes,
+		Location:  req.Location,
+		Triggers:  req.Triggers,
+	})
+	switch {
+	case errors.Is(err, episode.ErrEpisodeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	case errors.Is(err, episode.ErrInvalidContext):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown location or trigger"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update episode"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Episode updated", "data": ep})
+}
+
+// DeleteEpisode removes a panic episode of the authenticated user.
+func DeleteEpisode(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := episodeSvc.Delete(c.GetUint("user_id"), id)
+	if errors.Is(err, episode.ErrEpisodeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete episode"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Episode deleted"})
+}
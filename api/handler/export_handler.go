@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/export"
+	"ps_backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportSvc = export.NewService(db.GetDB())
+
+// exportView adds a signed download link to completed jobs.
+func exportView(job *model.ExportJob) gin.H {
+	view := gin.H{"job": job}
+	if link, expires, err := exportSvc.DownloadURL(job); err == nil {
+		view["download_url"] = link
+		view["download_url_expires_at"] = expires
+	}
+	return view
+}
+
+// RequestExport starts generating a health report of the authenticated user.
+func RequestExport(c *gin.Context) {
+	var req dto.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	from, to, err := dto.ParseTimeRange(req.From, req.To, 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := exportSvc.Request(c.GetUint("user_id"), req.Format, from, to)
+	if errors.Is(err, export.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format or period (max 366 days)"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request export"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Export requested", "data": job})
+}
+
+// ListExports returns the export jobs of the authenticated user.
+func ListExports(c *gin.Context) {
+	jobs, err := exportSvc.List(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve exports"})
+		return
+	}
+	views := make([]gin.H, len(jobs))
+	for i := range jobs {
+		views[i] = exportView(&jobs[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"data": views})
+}
+
+// GetExport returns an export job of the authenticated user with a fresh download link once it completed.
+func GetExport(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	job, err := exportSvc.Get(c.GetUint("user_id"), id)
+	if errors.Is(err, export.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve export"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": exportView(job)})
+}
+
+// DownloadExport serves an export file. The signed link is the only credential,
+// so it can be handed to a doctor.
+func DownloadExport(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+	job, err := exportSvc.OpenSigned(id, expires, c.Query("signature"))
+	switch {
+	case errors.Is(err, export.ErrInvalidLink):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired download link"})
+		return
+	case errors.Is(err, export.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open export"})
+		return
+	}
+	c.Header("Content-Type", job.ContentType)
+	c.Header("Cache-Control", "private, no-store")
+	c.FileAttachment(job.FilePath, job.FileName)
+}
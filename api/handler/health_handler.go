@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+
+	"ps_backend/db"
+	"ps_backend/internal/health"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var healthSvc = health.NewService(db.GetDB())
+
+func init() {
+	metrics.NewGaugeFunc("websocket_connected_clients", "Open WebSocket connections.", func() float64 {
+		return float64(wsHub.ClientCount())
+	})
+
+	pool := func() sql.DBStats {
+		sqlDB, err := db.GetDB().DB()
+		if err != nil {
+			return sql.DBStats{}
+		}
+		return sqlDB.Stats()
+	}
+	metrics.NewGaugeFunc("db_pool_max_open_connections", "Maximum number of open database connections.", func() float64 {
+		return float64(pool().MaxOpenConnections)
+	})
+	metrics.NewGaugeFunc("db_pool_open_connections", "Open database connections, in use or idle.", func() float64 {
+		return float64(pool().OpenConnections)
+	})
+	metrics.NewGaugeFunc("db_pool_in_use_connections", "Database connections in use.", func() float64 {
+		return float64(pool().InUse)
+	})
+	metrics.NewGaugeFunc("db_pool_idle_connections", "Idle database connections.", func() float64 {
+		return float64(pool().Idle)
+	})
+	metrics.NewCounterFunc("db_pool_wait_count_total", "Times a query waited for a free database connection.", func() float64 {
+		return float64(pool().WaitCount)
+	})
+	metrics.NewCounterFunc("db_pool_wait_duration_seconds_total", "Time spent waiting for a free database connection.", func() float64 {
+		return pool().WaitDuration.Seconds()
+	})
+}
+
+// Healthz reports that the process is up and serving requests. It checks
+// nothing else, so a restart is only triggered when the process is stuck.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the server can handle traffic: 200 when every check
+// passed or is only degraded, 503 otherwise.
+func Readyz(c *gin.Context) {
+	report := healthSvc.Ready(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+var metricsHandler = gin.WrapH(metrics.Handler())
+
+// Metrics serves the Prometheus metrics. When METRICS_TOKEN is set, the scraper
+// must send it as a bearer token.
+func Metrics(c *gin.Context) {
+	if token := config.Get().Server.MetricsToken; token != "" {
+		got := c.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+			return
+		}
+	}
+	metricsHandler(c)
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"ps_backend/db"
+	"ps_backend/internal/insight"
+
+	"github.com/gin-gonic/gin"
+)
+
+var insightSvc = insight.NewService(db.GetDB())
+
+// GetMyInsights returns the episode patterns of the authenticated user as of the
+// last nightly batch.
+func GetMyInsights(c *gin.Context) {
+	ui, err := insightSvc.Get(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load insights"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ui})
+}
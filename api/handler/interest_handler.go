@@ -3,18 +3,22 @@ package handler
 import (
 	"net/http"
 	"ps_backend/db"
+	"ps_backend/dto"
+	interestService "ps_backend/internal/interest"
 	"ps_backend/model"
 
 	"github.com/gin-gonic/gin"
 )
 
 var databaseI = db.GetDB()
+var interestSvc = interestService.NewService(databaseI)
 
 type AddInterestRequest struct {
-	UserID   uint   `json:"user_id"`
-	Interest string `json:"interest"`
+	Interest string `json:"interest" binding:"required"`
 }
 
+// AddInterest links an existing interest to the authenticated user.
+// Creating new interests is reserved for admins (AdminCreateInterest).
 func AddInterest(c *gin.Context) {
 	var req AddInterestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -22,24 +26,43 @@ func AddInterest(c *gin.Context) {
 		return
 	}
 
-	// 관심사 없으면 생성, 있으면 그대로 사용
-	var interest model.Interest
-	if err := databaseI.Where("name = ?", req.Interest).First(&interest).Error; err != nil {
-		interest = model.Interest{Name: req.Interest}
-		databaseI.Create(&interest)
+	interest, err := interestSvc.GetByName(req.Interest)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "등록되지 않은 관심사"})
+		return
 	}
 
-	userInterest := model.UserInterest{
-		UserID:     req.UserID,
-		InterestID: interest.ID,
-	}
-	if err := databaseI.Create(&userInterest).Error; err != nil {
+	if err := interestSvc.AssignToUser(c.GetUint("user_id"), interest.ID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "관심사 추가 실패"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "관심사 등록 성공"})
 }
 
+// RemoveMyInterest unlinks an interest from the authenticated user.
+func RemoveMyInterest(c *gin.Context) {
+	interestID, err := dto.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 관심사 ID"})
+		return
+	}
+	if err := interestSvc.RemoveFromUser(c.GetUint("user_id"), uint(interestID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "관심사 해제 실패"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "관심사 해제 성공"})
+}
+
+// ListMyInterests returns the interests of the authenticated user.
+func ListMyInterests(c *gin.Context) {
+	interests, err := interestSvc.GetUserInterests(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "관심사 조회 실패"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"interests": interests})
+}
+
 func ListInterests(c *gin.Context) {
 	var interests []model.Interest
 	if err := databaseI.Find(&interests).Error; err != nil {
@@ -48,3 +71,67 @@ func ListInterests(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"interests": interests})
 }
+
+// ListSubInterests returns the sub-interests of an interest.
+func ListSubInterests(c *gin.Context) {
+	interestID, err := dto.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 관심사 ID"})
+		return
+	}
+	subs, err := interestSvc.GetSub(uint(interestID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "세부 관심사 조회 실패"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sub_interests": subs})
+}
+
+// AdminCreateInterest creates a new interest.
+func AdminCreateInterest(c *gin.Context) {
+	var req dto.InterestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "데이터 형식 오류"})
+		return
+	}
+	interest, err := interestSvc.Create(req.Name)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "관심사 생성 실패(중복?)"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "관심사 생성 성공", "interest": interest})
+}
+
+// AdminCreateSubInterest creates a new sub-interest under an interest.
+func AdminCreateSubInterest(c *gin.Context) {
+	interestID, err := dto.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 관심사 ID"})
+		return
+	}
+	var req dto.InterestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "데이터 형식 오류"})
+		return
+	}
+	sub, err := interestSvc.CreateSub(uint(interestID), req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "세부 관심사 생성 실패: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "세부 관심사 생성 성공", "sub_interest": sub})
+}
+
+// AdminDeleteInterest deletes an interest with its sub-interests and assignments.
+func AdminDeleteInterest(c *gin.Context) {
+	interestID, err := dto.ParseUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 관심사 ID"})
+		return
+	}
+	if err := interestSvc.Delete(uint(interestID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "관심사 삭제 실패"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "관심사 삭제 성공"})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	jobQueue  = jobs.NewQueue(db.GetDB())
+	jobRunner = jobs.NewRunner(db.GetDB())
+)
+
+// init registers the background work of every service. The workers start with StartJobs.
+func init() {
+	authSvc.RegisterJobs(jobRunner)
+	tokenSvc.RegisterJobs(jobRunner)
+	userSvc.RegisterJobs(jobRunner)
+	exportSvc.RegisterJobs(jobRunner)
+	notifierSvc.RegisterJobs(jobRunner)
+	emergencySvc.RegisterJobs(jobRunner)
+	checkInSvc.RegisterJobs(jobRunner)
+	insightSvc.RegisterJobs(jobRunner)
+	breathingSvc.RegisterJobs(jobRunner)
+}
+
+// StartJobs starts the job workers and the recurring schedules. It is called by
+// the server once the schema is known to be current.
+func StartJobs() {
+	// Breathing sessions the previous process left past their planned end are
+	// interrupted before serving; the recurring job catches the rest.
+	if _, err := breathingSvc.InterruptStale(time.Now()); err != nil {
+		logrus.WithError(err).Error("Interrupting stale breathing sessions failed")
+	}
+	jobRunner.Start()
+}
+
+// StopJobs stops the job workers and waits for running jobs to finish.
+func StopJobs() {
+	jobRunner.Stop()
+}
+
+// GetJobStats returns the job counts per type and status and the recurring schedules.
+func GetJobStats(c *gin.Context) {
+	stats, err := jobQueue.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load job stats"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// ListJobs returns jobs newest first, filtered by ?status= and ?type=.
+// Older pages are fetched with ?before=<id of the last item>.
+func ListJobs(c *gin.Context) {
+	var before uint
+	if v := c.Query("before"); v != "" {
+		id, err := dto.ParseUint(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before"})
+			return
+		}
+		before = uint(id)
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	items, err := jobQueue.List(c.Query("status"), c.Query("type"), before, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// RetryJob moves a dead job back into the queue.
+func RetryJob(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := jobQueue.Retry(id)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Job queued"})
+}
+
+// DiscardJob deletes a dead job.
+func DiscardJob(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := jobQueue.Discard(id)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discard job"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Job discarded"})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/notifier"
+	"ps_backend/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var notifierSvc = notifier.NewService(db.GetDB())
+
+// notifyUser sends a notification, logging instead of failing the request on error.
+func notifyUser(in notifier.Input) {
+	if _, err := notifierSvc.Notify(in); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"userID": in.UserID, "category": in.Category}).
+			Error("Failed to create notification")
+	}
+}
+
+// ListNotifications returns the notification inbox of the authenticated user.
+// Older pages are fetched with ?before=<id of the last item>.
+func ListNotifications(c *gin.Context) {
+	var before uint
+	if v := c.Query("before"); v != "" {
+		id, err := dto.ParseUint(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before"})
+			return
+		}
+		before = uint(id)
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	items, unread, err := notifierSvc.Inbox(c.GetUint("user_id"), before, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "unread": unread})
+}
+
+// MarkNotificationRead marks one notification of the authenticated user read.
+func MarkNotificationRead(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := notifierSvc.MarkRead(c.GetUint("user_id"), id)
+	if errors.Is(err, notifier.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked read"})
+}
+
+// MarkAllNotificationsRead marks every notification of the authenticated user read.
+func MarkAllNotificationsRead(c *gin.Context) {
+	count, err := notifierSvc.MarkAllRead(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked read", "updated": count})
+}
+
+// GetNotificationPreferences returns the notification preferences of the authenticated user.
+func GetNotificationPreferences(c *gin.Context) {
+	pref, err := notifierSvc.GetPreferences(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pref, "categories": model.NotificationCategories})
+}
+
+// UpdateNotificationPreferences partially updates the notification preferences of the authenticated user.
+func UpdateNotificationPreferences(c *gin.Context) {
+	var req dto.NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	pref, err := notifierSvc.UpdatePreferences(c.GetUint("user_id"), notifier.PreferenceUpdate{
+		DisabledCategories: req.DisabledCategories,
+		QuietHoursEnabled:  req.QuietHoursEnabled,
+		QuietHoursStart:    req.QuietHoursStart,
+		QuietHoursEnd:      req.QuietHoursEnd,
+	})
+	if errors.Is(err, notifier.ErrInvalidPreference) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification preferences updated", "data": pref})
+}
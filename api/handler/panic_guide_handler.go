@@ -1,16 +1,62 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"ps_backend/db"
 	dto "ps_backend/dto"
 	panicService "ps_backend/internal/panic_guide"
+	"ps_backend/model"
+	"ps_backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+var panicGuideSvc = panicService.NewService(db.GetDB())
+var guideRecommender = panicService.NewRecommender(db.GetDB())
+
+// guideFromRequest maps a panic guide request onto the model, keeping step order.
+func guideFromRequest(req dto.PanicGuideRequest) *model.PanicGuide {
+	guide := &model.PanicGuide{
+		Title:       req.Title,
+		Description: req.Description,
+		Locale:      req.Locale,
+		Difficulty:  req.Difficulty,
+	}
+	for _, step := range req.Steps {
+		guide.Steps = append(guide.Steps, model.PanicGuideStep{
+			Type:            step.Type,
+			Instruction:     step.Instruction,
+			DurationSeconds: step.DurationSeconds,
+			AudioURL:        step.AudioURL,
+			ImageURL:        step.ImageURL,
+		})
+	}
+	for _, interestID := range req.InterestIDs {
+		guide.Interests = append(guide.Interests, model.Interest{ID: interestID})
+	}
+	return guide
+}
+
+// parseGuideID reads the :id path parameter, writing a 400 response if it is invalid.
+func parseGuideID(c *gin.Context) (uint, bool) {
+	id, err := dto.ParseUint(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid panic guide id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// negotiateLocale picks the response locale from Accept-Language and announces it in Content-Language.
+func negotiateLocale(c *gin.Context) string {
+	locale := utils.NegotiateLocale(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", locale)
+	return locale
+}
+
 func ListPanicGuides(c *gin.Context) {
-	guides, err := panicService.NewService(db.GetDB()).GetAll()
+	guides, err := panicGuideSvc.GetAllForUser(c.GetUint("user_id"), negotiateLocale(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve panic guides"})
 		return
@@ -18,6 +64,46 @@ func ListPanicGuides(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": guides})
 }
 
+// GetPanicGuide returns a single panic guide with its ordered steps.
+func GetPanicGuide(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	guide, err := panicGuideSvc.GetByIDForUser(id, c.GetUint("user_id"), negotiateLocale(c))
+	if errors.Is(err, panicService.ErrGuideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Panic guide not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve panic guide"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": guide})
+}
+
+// RecommendPanicGuides ranks panic guides for the authenticated user with a score breakdown.
+func RecommendPanicGuides(c *gin.Context) {
+	limit, err := dto.ParseUint(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid limit"})
+		return
+	}
+	result, err := guideRecommender.Recommend(c.GetUint("user_id"), int(limit))
+	if err == nil {
+		refs := make([]*model.PanicGuide, len(result.Items))
+		for i := range result.Items {
+			refs[i] = &result.Items[i].Guide
+		}
+		err = panicGuideSvc.Localize(refs, negotiateLocale(c))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to recommend panic guides"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
 func AddPanicGuide(c *gin.Context) {
 	var req dto.PanicGuideRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -25,58 +111,185 @@ func AddPanicGuide(c *gin.Context) {
 		return
 	}
 
-	_, err := panicService.NewService(db.GetDB()).Create(req.Title, req.Description)
+	guide, err := panicGuideSvc.Create(guideFromRequest(req))
+	if errors.Is(err, panicService.ErrUnsupportedLocale) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unsupported locale"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to add panic guide"})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Panic guide added successfully"})
+	c.JSON(http.StatusCreated, gin.H{"message": "Panic guide added successfully", "data": guide})
 }
 
-func BookmarkPanicGuide(c *gin.Context) {
-	var req dto.BookmarkRequest
+// UpdatePanicGuide replaces the content and steps of a panic guide.
+func UpdatePanicGuide(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	var req dto.PanicGuideRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
 		return
 	}
 
-	err := panicService.NewService(db.GetDB()).Bookmark(req.UserID, req.PanicGuideID)
+	guide, err := panicGuideSvc.Update(id, guideFromRequest(req))
+	if errors.Is(err, panicService.ErrGuideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Panic guide not found"})
+		return
+	}
+	if errors.Is(err, panicService.ErrUnsupportedLocale) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unsupported locale"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to bookmark panic guide"})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update panic guide"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Panic guide bookmarked successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Panic guide updated successfully", "data": guide})
 }
 
-func ListUserBookmarks(c *gin.Context) {
-	var req dto.BookmarkRequest
+// DeletePanicGuide removes a panic guide with its steps and bookmarks.
+func DeletePanicGuide(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	err := panicGuideSvc.Delete(id)
+	if errors.Is(err, panicService.ErrGuideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Panic guide not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete panic guide"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Panic guide deleted successfully"})
+}
 
-	// Try to bind JSON first
-	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == 0 {
-		// If JSON bind fails or userID is zero, try to get user_id from query param
-		userIDQuery := c.Query("user_id")
-		if userIDQuery == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "user_id is required"})
-			return
-		}
-		var err error
-		var parsed uint64
-		parsed, err = dto.ParseUint(userIDQuery)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user_id"})
-			return
-		}
-		req.UserID = uint(parsed)
-		if req.UserID == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user_id"})
+// BookmarkPanicGuide bookmarks a guide for the authenticated user. Repeated calls are no-ops.
+func BookmarkPanicGuide(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	var req dto.BookmarkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
 			return
 		}
 	}
 
-	bookmarkedGuides, err := panicService.NewService(db.GetDB()).GetBookmarks(req.UserID)
+	created, err := panicGuideSvc.Bookmark(c.GetUint("user_id"), id, req.Folder, req.Pinned)
+	if errors.Is(err, panicService.ErrGuideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Panic guide not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to bookmark panic guide"})
+		return
+	}
+	if created {
+		c.JSON(http.StatusCreated, gin.H{"message": "Panic guide bookmarked successfully"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Panic guide already bookmarked"})
+}
+
+// UnbookmarkPanicGuide removes a guide from the authenticated user's bookmarks.
+func UnbookmarkPanicGuide(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	err := panicGuideSvc.Unbookmark(c.GetUint("user_id"), id)
+	if errors.Is(err, panicService.ErrBookmarkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Bookmark not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to remove bookmark"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed successfully"})
+}
+
+// UpdateBookmark moves a bookmark to another folder, changes its position or pins it.
+func UpdateBookmark(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	var req dto.UpdateBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
+		return
+	}
+	err := panicGuideSvc.UpdateBookmark(c.GetUint("user_id"), id, panicService.BookmarkUpdate{
+		Folder:   req.Folder,
+		Position: req.Position,
+		Pinned:   req.Pinned,
+	})
+	if errors.Is(err, panicService.ErrBookmarkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Bookmark not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update bookmark"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark updated successfully"})
+}
+
+// ReorderBookmarks sets the order of the bookmarks in a folder.
+func ReorderBookmarks(c *gin.Context) {
+	var req dto.ReorderBookmarksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
+		return
+	}
+	err := panicGuideSvc.ReorderBookmarks(c.GetUint("user_id"), req.Folder, req.PanicGuideIDs)
+	if errors.Is(err, panicService.ErrBookmarkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Bookmark not found in folder"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to reorder bookmarks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmarks reordered successfully"})
+}
+
+// ListUserBookmarks returns the authenticated user's bookmarks, optionally filtered by ?folder=.
+func ListUserBookmarks(c *gin.Context) {
+	var folder *string
+	if f, ok := c.GetQuery("folder"); ok {
+		folder = &f
+	}
+	bookmarkedGuides, err := panicGuideSvc.GetBookmarks(c.GetUint("user_id"), folder)
+	if err == nil {
+		refs := make([]*model.PanicGuide, len(bookmarkedGuides))
+		for i := range bookmarkedGuides {
+			refs[i] = &bookmarkedGuides[i].PanicGuide
+		}
+		err = panicGuideSvc.Localize(refs, negotiateLocale(c))
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve bookmarked panic guides"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": bookmarkedGuides})
 }
+
+// ListBookmarkFolders returns the authenticated user's bookmark folders.
+func ListBookmarkFolders(c *gin.Context) {
+	folders, err := panicGuideSvc.GetBookmarkFolders(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve bookmark folders"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": folders})
+}
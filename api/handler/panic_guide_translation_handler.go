@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"ps_backend/dto"
+	panicService "ps_backend/internal/panic_guide"
+	"ps_backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPanicGuideTranslations returns every translation of a panic guide.
+func ListPanicGuideTranslations(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	translations, err := panicGuideSvc.GetTranslations(id)
+	if errors.Is(err, panicService.ErrGuideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Panic guide not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve translations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": translations})
+}
+
+// SavePanicGuideTranslation adds or replaces the translation of a guide in the :locale path parameter.
+func SavePanicGuideTranslation(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	var req dto.PanicGuideTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
+		return
+	}
+
+	translation := &panicService.GuideTranslation{
+		PanicGuideTranslation: model.PanicGuideTranslation{
+			PanicGuideID: id,
+			Locale:       c.Param("locale"),
+			Title:        req.Title,
+			Description:  req.Description,
+		},
+	}
+	for _, step := range req.Steps {
+		translation.Steps = append(translation.Steps, model.PanicGuideStepTranslation{
+			Position:    step.Position,
+			Instruction: step.Instruction,
+			AudioURL:    step.AudioURL,
+		})
+	}
+
+	err := panicGuideSvc.SaveTranslation(translation)
+	switch {
+	case errors.Is(err, panicService.ErrGuideNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Panic guide not found"})
+		return
+	case errors.Is(err, panicService.ErrUnsupportedLocale):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unsupported locale"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Translation saved successfully", "data": translation})
+}
+
+// DeletePanicGuideTranslation removes the translation of a guide in the :locale path parameter.
+func DeletePanicGuideTranslation(c *gin.Context) {
+	id, ok := parseGuideID(c)
+	if !ok {
+		return
+	}
+	err := panicGuideSvc.DeleteTranslation(id, c.Param("locale"))
+	if errors.Is(err, panicService.ErrTranslationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Translation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete translation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted successfully"})
+}
+
+// ListMissingTranslations reports guides lacking translations for the ?locale= query parameter.
+func ListMissingTranslations(c *gin.Context) {
+	report, err := panicGuideSvc.MissingTranslations(c.Query("locale"))
+	if errors.Is(err, panicService.ErrUnsupportedLocale) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unsupported locale"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to build translation report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/crisis"
+	"ps_backend/internal/questionnaire"
+	"ps_backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+var questionnaireSvc = questionnaire.NewService(db.GetDB())
+
+// ListInstruments returns the newest version of every questionnaire.
+func ListInstruments(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": questionnaire.Latest()})
+}
+
+// GetInstrument returns a questionnaire definition; ?version= selects an older version.
+func GetInstrument(c *gin.Context) {
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+	inst, ok := questionnaire.Find(c.Param("code"), version)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Questionnaire not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": inst})
+}
+
+// SubmitQuestionnaire scores and stores a questionnaire completed by the
+// authenticated user. A positive answer to a safety item, such as PHQ-9 item 9,
+// adds the crisis resources to the response.
+func SubmitQuestionnaire(c *gin.Context) {
+	var req dto.QuestionnaireSubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	resp, err := questionnaireSvc.Submit(c.GetUint("user_id"), c.Param("code"), req.Version, req.Answers)
+	switch {
+	case errors.Is(err, questionnaire.ErrInstrumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Questionnaire not found"})
+		return
+	case errors.Is(err, questionnaire.ErrInvalidAnswers):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answers do not match the questionnaire"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record questionnaire"})
+		return
+	}
+	body := gin.H{"message": "Questionnaire recorded", "data": resp}
+	if resp.SafetyFlag {
+		body["safety"] = gin.H{
+			"message": "혼자 견디지 마세요. 지금 바로 아래 상담 창구에 연락하면 도움을 받을 수 있습니다.",
+		}
+		body["crisis_resources"] = crisis.Resources()
+	}
+	c.JSON(http.StatusCreated, body)
+}
+
+// ListMyQuestionnaires returns the questionnaire history of the authenticated user
+// within the from/to range (default 365 days); ?instrument= filters by questionnaire.
+func ListMyQuestionnaires(c *gin.Context) {
+	listQuestionnaires(c, c.GetUint("user_id"))
+}
+
+// GetMyQuestionnaire returns one questionnaire response of the authenticated user.
+func GetMyQuestionnaire(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	resp, err := questionnaireSvc.Get(c.GetUint("user_id"), id)
+	if errors.Is(err, questionnaire.ErrResponseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Questionnaire response not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve questionnaire response"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// GetSharedQuestionnaires returns the questionnaire history of a patient to the clinician.
+func GetSharedQuestionnaires(c *gin.Context) {
+	patientID, ok := authorizeShared(c, model.ShareScopeQuestionnaires)
+	if !ok {
+		return
+	}
+	listQuestionnaires(c, patientID)
+}
+
+func listQuestionnaires(c *gin.Context, userID uint) {
+	from, to, err := dto.ParseTimeRange(c.Query("from"), c.Query("to"), 365)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := questionnaireSvc.History(userID, c.Query("instrument"), from, to)
+	if errors.Is(err, questionnaire.ErrInstrumentNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown instrument"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve questionnaires"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ps_backend/db"
+	"ps_backend/dto"
+	"ps_backend/internal/notifier"
+	"ps_backend/internal/sharing"
+	"ps_backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+var sharingSvc = sharing.NewService(db.GetDB())
+
+// CreateShareInvite issues an invitation code the authenticated user can hand to a clinician.
+func CreateShareInvite(c *gin.Context) {
+	var req dto.CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	share, err := sharingSvc.CreateInvite(c.GetUint("user_id"), req.Scopes)
+	if errors.Is(err, sharing.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Invitation created", "data": share})
+}
+
+// ListMyShares returns every share issued by the authenticated user.
+func ListMyShares(c *gin.Context) {
+	shares, err := sharingSvc.ListForUser(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shares"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shares})
+}
+
+// RevokeShare revokes a pending invitation or an active share of the authenticated user.
+func RevokeShare(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	share, err := sharingSvc.Revoke(c.GetUint("user_id"), id)
+	if errors.Is(err, sharing.ErrShareNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Share revoked", "data": share})
+}
+
+// ListShareAccessLogs returns who read which data through a share of the authenticated user.
+func ListShareAccessLogs(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	logs, err := sharingSvc.AccessLogs(c.GetUint("user_id"), id, limit)
+	if errors.Is(err, sharing.ErrShareNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve access logs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": logs})
+}
+
+// AcceptShareInvite lets the authenticated clinician accept an invitation code.
+func AcceptShareInvite(c *gin.Context) {
+	var req dto.AcceptShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	share, err := sharingSvc.Accept(c.GetUint("user_id"), strings.ToUpper(strings.TrimSpace(req.Code)))
+	switch {
+	case errors.Is(err, sharing.ErrInviteInvalid):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation is invalid or expired"})
+	case errors.Is(err, sharing.ErrAlreadyShared):
+		c.JSON(http.StatusConflict, gin.H{"error": "Already sharing with this user"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+	default:
+		notifyUser(notifier.Input{
+			UserID:   share.UserID,
+			Category: model.NotificationCareShare,
+			Data:     map[string]string{"type": model.NotificationCareShare, "share_id": strconv.FormatUint(uint64(share.ID), 10)},
+		})
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted", "data": share})
+	}
+}
+
+// ListPatients returns the users sharing data with the authenticated clinician.
+func ListPatients(c *gin.Context) {
+	shares, err := sharingSvc.ListPatients(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve patients"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shares})
+}
+
+// authorizeShared checks the clinician's access to a scope of the patient in
+// the :id path parameter and records it. It writes the error response itself.
+func authorizeShared(c *gin.Context, scope string) (uint, bool) {
+	patientID, ok := parseIDParam(c)
+	if !ok {
+		return 0, false
+	}
+	_, err := sharingSvc.Authorize(c.GetUint("user_id"), patientID, scope, sharing.AccessInfo{
+		Path:     c.Request.URL.Path,
+		ClientIP: c.ClientIP(),
+	})
+	switch {
+	case errors.Is(err, sharing.ErrShareNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active share with this user"})
+		return 0, false
+	case errors.Is(err, sharing.ErrScopeNotGranted):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Scope not shared: " + scope})
+		return 0, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authorize access"})
+		return 0, false
+	}
+	return patientID, true
+}
+
+// GetSharedVitals returns the vital records of a patient to the clinician.
+func GetSharedVitals(c *gin.Context) {
+	patientID, ok := authorizeShared(c, model.ShareScopeVitals)
+	if !ok {
+		return
+	}
+	vitals, err := vitalSvc.GetVitalsByUser(patientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vital records"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": vitals})
+}
+
+// GetSharedEpisodes returns the panic episodes of a patient to the clinician.
+func GetSharedEpisodes(c *gin.Context) {
+	from, to, err := dto.ParseTimeRange(c.Query("from"), c.Query("to"), 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	patientID, ok := authorizeShared(c, model.ShareScopeEpisodes)
+	if !ok {
+		return
+	}
+	episodes, err := episodeSvc.List(patientID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve episodes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": episodes})
+}
+
+// GetSharedChatSummaries returns daily chatbot activity of a patient to the clinician.
+// Message content is never shared.
+func GetSharedChatSummaries(c *gin.Context) {
+	from, to, err := dto.ParseTimeRange(c.Query("from"), c.Query("to"), 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	patientID, ok := authorizeShared(c, model.ShareScopeChatSummaries)
+	if !ok {
+		return
+	}
+	summaries, err := sharingSvc.ChatSummaries(patientID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve chat summaries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summaries})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ps_backend/db"
+	"ps_backend/internal/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+var usageSvc = usage.NewService(db.GetDB())
+
+// GetMyUsage returns the LLM usage summary and recent calls of the authenticated user.
+func GetMyUsage(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	user, err := userSvc.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	summary, err := usageSvc.GetSummary(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve usage"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	recent, err := usageSvc.GetRecent(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"summary": summary, "recent": recent}})
+}
@@ -1,22 +1,30 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"ps_backend/db"
+	"ps_backend/dto"
+	authservice "ps_backend/internal/auth"
+	userService "ps_backend/internal/user"
 	"ps_backend/model"
+	"ps_backend/pkg/utils"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var database = db.GetDB()
+var userSvc = userService.NewService(database)
 
 type SignUpRequest struct {
-	Username      string `json:"username"`
-	Password      string `json:"password"`
-	PhoneNumber   string `json:"phone_number"`
-	SpeakingStyle string `json:"speaking_style"` // "반말" 등
-	Tone          string `json:"tone"`           // "유머" 등
+	Username      string              `json:"username"`
+	Password      string              `json:"password"`
+	PhoneNumber   string              `json:"phone_number"`
+	SpeakingStyle string              `json:"speaking_style"` // "반말" 등, persona가 없을 때만 사용
+	Tone          string              `json:"tone"`           // "유머" 등, persona가 없을 때만 사용
+	Persona       *dto.PersonaRequest `json:"persona"`
 }
 
 func SignUp(c *gin.Context) {
@@ -26,6 +34,16 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	if err := utils.ValidatePassword(req.Password, req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "비밀번호 규칙 위반: " + err.Error(), "password_policy": utils.LoadPasswordPolicy()})
+		return
+	}
+	persona, err := signUpPersona(req.Persona, req.SpeakingStyle, req.Tone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "말투 설정 오류"})
+		return
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "비밀번호 해시 실패"})
@@ -33,11 +51,10 @@ func SignUp(c *gin.Context) {
 	}
 
 	user := model.User{
-		Username:      req.Username,
-		PasswordHash:  string(hash),
-		PhoneNumber:   req.PhoneNumber,
-		SpeakingStyle: req.SpeakingStyle,
-		Tone:          req.Tone,
+		Username:     req.Username,
+		PasswordHash: string(hash),
+		PhoneNumber:  req.PhoneNumber,
+		Persona:      persona,
 	}
 	if err := database.Create(&user).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "유저 생성 실패(중복?)"})
@@ -57,14 +74,20 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "데이터 형식 오류"})
 		return
 	}
-	var user model.User
-	if err := database.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "존재하지 않는 유저"})
+	// 유저 존재 여부가 드러나지 않도록 실패 응답은 하나로 통일
+	key := strings.ToLower(req.Username)
+	if !allowAttempt(c, loginUserLimiter, key) {
+		return
+	}
+	user, err := authSvc.Authenticate(req.Username, req.Password, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, authservice.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "아이디 또는 비밀번호가 올바르지 않습니다"})
 		return
 	}
-	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "비밀번호 불일치"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "로그인 실패"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "로그인 성공", "user_id": user.ID, "speaking_style": user.SpeakingStyle, "tone": user.Tone})
+	loginUserLimiter.Reset(key)
+	c.JSON(http.StatusOK, gin.H{"message": "로그인 성공", "user_id": user.ID, "persona": user.Persona})
 }
@@ -1,15 +1,21 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"ps_backend/db"
 	"ps_backend/dto"
+	"ps_backend/internal/crisis"
+	deviceservice "ps_backend/internal/device"
+	"ps_backend/internal/notifier"
 	vitalService "ps_backend/internal/vital"
 	"ps_backend/model"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 var vitalSvc = vitalService.NewService(db.GetDB())
@@ -21,8 +27,35 @@ func RegisterVital(c *gin.Context) {
 		return
 	}
 
+	// The authenticated user always wins over the body.
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		userID = req.UserID
+	}
+	// Samples are attributed to the given device, or to the device the token was issued to.
+	deviceID := c.GetUint("device_id")
+	if req.DeviceID != nil {
+		deviceID = *req.DeviceID
+	}
+	var source *uint
+	if deviceID != 0 {
+		device, err := deviceSvc.Resolve(userID, deviceID)
+		if errors.Is(err, deviceservice.ErrDeviceNotFound) || errors.Is(err, deviceservice.ErrDeviceRevoked) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or revoked device"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load device"})
+			return
+		}
+		source = &device.ID
+		if err := deviceSvc.Touch(device.ID, c.ClientIP()); err != nil {
+			logrus.WithError(err).WithField("deviceID", device.ID).Warn("Failed to update device last seen")
+		}
+	}
 	entry := &model.VitalSign{
-		UserID:      req.UserID,
+		UserID:      userID,
+		DeviceID:    source,
 		HeartRate:   req.HeartRate,
 		BreathRate:  req.BreathRate,
 		StressLevel: req.StressLevel,
@@ -32,31 +65,53 @@ func RegisterVital(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vital record"})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Vital record created successfully"})
+	if vitalSvc.DetectPanic(entry) {
+		episode, err := episodeSvc.RecordDetected(entry)
+		if err != nil {
+			logrus.WithError(err).WithField("userID", entry.UserID).Error("Failed to record panic episode")
+		} else if episode.EndedAt == nil {
+			// Push once per episode so the other devices of the user can offer help.
+			notifyUser(notifier.Input{
+				UserID:   entry.UserID,
+				Category: model.NotificationPanicDetected,
+				Data:     map[string]string{"type": model.NotificationPanicDetected, "episode_id": strconv.FormatUint(uint64(episode.ID), 10)},
+			})
+		}
+		alertContactsOnPanic(entry.UserID)
+		c.JSON(http.StatusCreated, gin.H{
+			"message":          "Vital record created successfully",
+			"panic_detected":   true,
+			"crisis_resources": crisis.Resources(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Vital record created successfully", "panic_detected": false})
 }
 
+// ListVitals returns the vital records of the authenticated user. Without a
+// token the user is taken from the user_id query parameter or JSON body.
 func ListVitals(c *gin.Context) {
-	var userID uint
-	var err error
-
-	userIDStr := c.Query("user_id")
-	if userIDStr == "" {
-		var req struct {
-			UserID string `json:"user_id" binding:"required"`
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		userIDStr := c.Query("user_id")
+		if userIDStr == "" {
+			var req struct {
+				UserID string `json:"user_id" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+				return
+			}
+			userIDStr = req.UserID
 		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+
+		parsed, err := dto.ParseUint(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
 			return
 		}
-		userIDStr = req.UserID
-	}
-
-	parsed, err := dto.ParseUint(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
-		return
+		userID = uint(parsed)
 	}
-	userID = uint(parsed)
 
 	vitals, err := vitalSvc.GetVitalsByUser(userID)
 	if err != nil {
@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"ps_backend/pkg/websocket"
+)
+
+var wsHub = websocket.NewHub()
+
+func init() {
+	go wsHub.Run()
+}
+
+// ServeWebSocket upgrades the request and registers the client with the shared hub.
+var ServeWebSocket = websocket.ServeWS(wsHub)
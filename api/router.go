@@ -2,18 +2,199 @@ package api
 
 import (
 	"ps_backend/api/handler"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+	jwt "ps_backend/pkg/middleware"
+	"ps_backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter() *gin.Engine {
-	r := gin.Default()
+// SetupRouter registers every route on a new engine. The middleware, such as
+// logging, recovery and CORS, runs before all routes.
+func SetupRouter(middleware ...gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware...)
+
+	// Per-IP limits on credential and OTP endpoints; per-account limits are applied in the handlers.
+	cfg := config.Get()
+	loginLimit := jwt.RateLimitByIP(utils.NewRateLimiterFromConfig(cfg.Login.IPRate))
+	otpLimit := jwt.RateLimitByIP(utils.NewRateLimiterFromConfig(cfg.OTP.IPRate))
+
+	// Probes and the metrics scrape live outside /api and are not rate limited.
+	r.GET("/healthz", handler.Healthz)
+	r.GET("/readyz", handler.Readyz)
+	r.GET("/metrics", handler.Metrics)
 
 	api := r.Group("/api")
 	{
 		api.POST("/signup", handler.SignUp)
-		api.POST("/login", handler.Login)
-		api.POST("/chat", handler.ChatWithGemini)
+		api.POST("/login", loginLimit, handler.Login)
+
+		api.POST("/auth/signin", loginLimit, handler.SignIn)
+		api.POST("/auth/refresh", handler.RefreshToken)
+		api.POST("/auth/signout", handler.SignOut)
+		api.POST("/auth/verify-phone", otpLimit, handler.VerifyPhone)
+		api.POST("/auth/password/forgot", otpLimit, handler.ForgotPassword)
+		api.POST("/auth/password/verify", otpLimit, handler.VerifyPasswordReset)
+		api.POST("/auth/password/reset", otpLimit, handler.ResetPassword)
+
+		api.GET("/exports/:id/download", handler.DownloadExport)
+
+		api.GET("/interests", handler.ListInterests)
+		api.GET("/interests/:id/subs", handler.ListSubInterests)
+		api.GET("/persona-options", handler.GetPersonaOptions)
+		api.GET("/questionnaires", handler.ListInstruments)
+		api.GET("/questionnaires/:code", handler.GetInstrument)
+	}
+
+	// Guide listings flag bookmarks when the caller is signed in.
+	guides := api.Group("")
+	guides.Use(jwt.OptionalJWTAuthMiddleware())
+	{
+		guides.GET("/panic-guides", handler.ListPanicGuides)
+		guides.GET("/panic-guides/:id", handler.GetPanicGuide)
+	}
+
+	protected := api.Group("")
+	protected.Use(jwt.JWTAuthMiddleware())
+	{
+		protected.POST("/chat", handler.ChatWithGemini)
+
+		protected.GET("/users/me", handler.GetMyProfile)
+		protected.PUT("/users/me", handler.UpdateMyProfile)
+		protected.PUT("/users/me/password", handler.ChangeMyPassword)
+		protected.GET("/users/me/logins", handler.ListMyLogins)
+		protected.GET("/users/me/devices", handler.ListMyDevices)
+		protected.POST("/users/me/devices", handler.RegisterMyDevice)
+		protected.DELETE("/users/me/devices/:id", handler.RevokeMyDevice)
+
+		protected.GET("/users/me/notifications", handler.ListNotifications)
+		protected.POST("/users/me/notifications/read-all", handler.MarkAllNotificationsRead)
+		protected.POST("/users/me/notifications/:id/read", handler.MarkNotificationRead)
+		protected.GET("/users/me/notification-preferences", handler.GetNotificationPreferences)
+		protected.PUT("/users/me/notification-preferences", handler.UpdateNotificationPreferences)
+
+		protected.GET("/users/me/checkin-schedule", handler.GetCheckInSchedule)
+		protected.PUT("/users/me/checkin-schedule", handler.UpdateCheckInSchedule)
+		protected.GET("/users/me/checkins", handler.ListMyCheckIns)
+		protected.POST("/users/me/checkins", handler.SubmitCheckIn)
+		protected.GET("/users/me/checkins/trends", handler.GetCheckInTrends)
+		protected.GET("/users/me/insights", handler.GetMyInsights)
+
+		protected.POST("/questionnaires/:code/responses", handler.SubmitQuestionnaire)
+		protected.GET("/users/me/questionnaire-responses", handler.ListMyQuestionnaires)
+		protected.GET("/users/me/questionnaire-responses/:id", handler.GetMyQuestionnaire)
+
+		protected.PUT("/users/me/phone", otpLimit, handler.ChangeMyPhone)
+		protected.POST("/users/me/phone/otp", otpLimit, handler.ResendMyPhoneOTP)
+		protected.POST("/users/me/phone/verify", otpLimit, handler.VerifyMyPhone)
+		protected.GET("/users/me/usage", handler.GetMyUsage)
+		protected.POST("/users/me/export", handler.RequestDataExport)
+		protected.DELETE("/users/me", handler.DeleteMyAccount)
+		protected.POST("/users/me/deletion/cancel", handler.CancelAccountDeletion)
+		protected.GET("/users/me/interests", handler.ListMyInterests)
+		protected.POST("/users/me/interests", handler.AddInterest)
+		protected.DELETE("/users/me/interests/:id", handler.RemoveMyInterest)
+
+		protected.GET("/panic-guides/recommended", handler.RecommendPanicGuides)
+		protected.POST("/panic-guides", jwt.RequirePermission(model.PermManageGuides), handler.AddPanicGuide)
+		protected.PUT("/panic-guides/:id", jwt.RequirePermission(model.PermManageGuides), handler.UpdatePanicGuide)
+		protected.DELETE("/panic-guides/:id", jwt.RequirePermission(model.PermManageGuides), handler.DeletePanicGuide)
+
+		protected.GET("/panic-guides/bookmarks", handler.ListUserBookmarks)
+		protected.GET("/panic-guides/bookmarks/folders", handler.ListBookmarkFolders)
+		protected.PUT("/panic-guides/bookmarks/order", handler.ReorderBookmarks)
+		protected.POST("/panic-guides/:id/bookmark", handler.BookmarkPanicGuide)
+		protected.PUT("/panic-guides/:id/bookmark", handler.UpdateBookmark)
+		protected.DELETE("/panic-guides/:id/bookmark", handler.UnbookmarkPanicGuide)
+
+		protected.GET("/ws", handler.ServeWebSocket)
+
+		protected.POST("/vitals", handler.RegisterVital)
+		protected.GET("/vitals", handler.ListVitals)
+
+		protected.GET("/users/me/emergency-contacts", handler.ListEmergencyContacts)
+		protected.POST("/users/me/emergency-contacts", handler.CreateEmergencyContact)
+		protected.PUT("/users/me/emergency-contacts/:id", handler.UpdateEmergencyContact)
+		protected.DELETE("/users/me/emergency-contacts/:id", handler.DeleteEmergencyContact)
+		protected.POST("/sos", handler.SendSOS)
+		protected.GET("/sos/alerts", handler.ListSOSAlerts)
+
+		protected.GET("/users/me/episodes", handler.ListMyEpisodes)
+		protected.POST("/users/me/episodes", handler.ReportEpisode)
+		protected.PATCH("/users/me/episodes/:id", handler.UpdateEpisode)
+		protected.DELETE("/users/me/episodes/:id", handler.DeleteEpisode)
+
+		protected.POST("/users/me/exports", handler.RequestExport)
+		protected.GET("/users/me/exports", handler.ListExports)
+		protected.GET("/users/me/exports/:id", handler.GetExport)
+
+		protected.GET("/users/me/shares", handler.ListMyShares)
+		protected.POST("/users/me/shares", handler.CreateShareInvite)
+		protected.DELETE("/users/me/shares/:id", handler.RevokeShare)
+		protected.GET("/users/me/shares/:id/access-logs", handler.ListShareAccessLogs)
+
+		protected.GET("/breathing-patterns", handler.ListBreathingPatterns)
+		protected.POST("/breathing-sessions", handler.StartBreathingSession)
+		protected.GET("/breathing-sessions", handler.ListBreathingSessions)
+		protected.GET("/breathing-sessions/effectiveness", handler.GetBreathingEffectiveness)
+		protected.GET("/breathing-sessions/:id", handler.GetBreathingSession)
+		protected.POST("/breathing-sessions/:id/stop", handler.StopBreathingSession)
+	}
+
+	clinician := protected.Group("/clinician")
+	clinician.Use(jwt.RequirePermission(model.PermViewSharedData))
+	{
+		clinician.POST("/shares/accept", handler.AcceptShareInvite)
+		clinician.GET("/patients", handler.ListPatients)
+		clinician.GET("/patients/:id/vitals", handler.GetSharedVitals)
+		clinician.GET("/patients/:id/episodes", handler.GetSharedEpisodes)
+		clinician.GET("/patients/:id/chat-summaries", handler.GetSharedChatSummaries)
+		clinician.GET("/patients/:id/questionnaires", handler.GetSharedQuestionnaires)
+	}
+
+	// Only staff roles reach /admin; each section also requires its permission.
+	admin := protected.Group("/admin")
+	admin.Use(jwt.RequireRole(model.RoleAdmin, model.RoleContentEditor))
+	{
+		guideAdmin := admin.Group("/panic-guides")
+		guideAdmin.Use(jwt.RequirePermission(model.PermManageGuides))
+		{
+			guideAdmin.GET("/translations/missing", handler.ListMissingTranslations)
+			guideAdmin.GET("/:id/translations", handler.ListPanicGuideTranslations)
+			guideAdmin.PUT("/:id/translations/:locale", handler.SavePanicGuideTranslation)
+			guideAdmin.DELETE("/:id/translations/:locale", handler.DeletePanicGuideTranslation)
+		}
+
+		interestAdmin := admin.Group("/interests")
+		interestAdmin.Use(jwt.RequirePermission(model.PermManageInterests))
+		{
+			interestAdmin.POST("", handler.AdminCreateInterest)
+			interestAdmin.POST("/:id/subs", handler.AdminCreateSubInterest)
+			interestAdmin.DELETE("/:id", handler.AdminDeleteInterest)
+		}
+
+		promptAdmin := admin.Group("/prompts")
+		promptAdmin.Use(jwt.RequirePermission(model.PermManagePrompts))
+		{
+			promptAdmin.GET("", handler.ListPrompts)
+			promptAdmin.POST("", handler.CreatePrompt)
+			promptAdmin.PUT("/:id", handler.UpdatePrompt)
+			promptAdmin.POST("/:id/activate", handler.ActivatePrompt)
+			promptAdmin.DELETE("/:id", handler.DeletePrompt)
+		}
+
+		admin.PUT("/users/:id/role", jwt.RequirePermission(model.PermManageUsers), handler.UpdateUserRole)
+
+		jobAdmin := admin.Group("/jobs")
+		jobAdmin.Use(jwt.RequirePermission(model.PermManageJobs))
+		{
+			jobAdmin.GET("/stats", handler.GetJobStats)
+			jobAdmin.GET("", handler.ListJobs)
+			jobAdmin.POST("/:id/retry", handler.RetryJob)
+			jobAdmin.DELETE("/:id", handler.DiscardJob)
+		}
 	}
 
 	return r
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"ps_backend/db"
+	authservice "ps_backend/internal/auth"
+	userservice "ps_backend/internal/user"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// runSeed inserts the fixtures of -env (default APP_ENV) and prints the
+// generated passwords of the accounts it created.
+func runSeed(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	env := fs.String("env", cfg.Env, "fixture set: "+strings.Join(db.SeedEnvironments, ", "))
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	created, err := db.Seed(db.GetDB(), *env)
+	for _, account := range created {
+		printCredentials(account.Role+" account created", account.Username, account.Password)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed: %v\n", err)
+		return 1
+	}
+	if len(created) == 0 {
+		fmt.Println("no accounts created; existing passwords are unchanged")
+	}
+	return 0
+}
+
+// runCreateAdmin creates a verified admin account, or with -promote grants the
+// admin role to an existing user.
+func runCreateAdmin(_ *config.Config, args []string) int {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the account (required)")
+	phone := fs.String("phone", "", "phone number of a new account (required unless -promote)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	promote := fs.Bool("promote", false, "grant the admin role to an existing user")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *username == "" || (!*promote && *phone == "") {
+		fmt.Fprintln(os.Stderr, "create-admin: -username and -phone are required")
+		fs.Usage()
+		return 2
+	}
+
+	users := userservice.NewService(db.GetDB())
+	existing, err := users.GetByUsername(*username)
+	switch {
+	case err == nil && *promote:
+		if err := users.ChangeRole(existing, model.RoleAdmin); err != nil {
+			fmt.Fprintf(os.Stderr, "create-admin: %v\n", err)
+			return 1
+		}
+		fmt.Printf("user %s (id %d) is now an admin\n", existing.Username, existing.ID)
+		return 0
+	case err == nil:
+		fmt.Fprintf(os.Stderr, "create-admin: user %s already exists; use -promote to make it an admin\n", *username)
+		return 1
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		fmt.Fprintf(os.Stderr, "create-admin: %v\n", err)
+		return 1
+	case *promote:
+		fmt.Fprintf(os.Stderr, "create-admin: user %s not found\n", *username)
+		return 1
+	}
+
+	password, generated, err := newPassword(*passwordStdin, *username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: %v\n", err)
+		return 1
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: %v\n", err)
+		return 1
+	}
+	user := &model.User{
+		Username:     *username,
+		PasswordHash: hash,
+		PhoneNumber:  *phone,
+		Verified:     true,
+		Role:         model.RoleAdmin,
+		Persona:      model.DefaultPersona(),
+		CreatedAt:    time.Now(),
+	}
+	if err := users.Create(user); err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: %v\n", err)
+		return 1
+	}
+	if generated {
+		printCredentials("admin account created", user.Username, password)
+	} else {
+		fmt.Printf("admin account %s created (id %d)\n", user.Username, user.ID)
+	}
+	return 0
+}
+
+// runResetPassword sets a new password for a user, lifting a sign-in lockout and
+// signing the user out of every device.
+func runResetPassword(_ *config.Config, args []string) int {
+	fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the account")
+	userID := fs.Uint("user-id", 0, "ID of the account, instead of -username")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	user, err := lookupUser(*username, *userID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reset-password: %v\n", err)
+		return 1
+	}
+	password, generated, err := newPassword(*passwordStdin, user.Username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reset-password: %v\n", err)
+		return 1
+	}
+	if err := authservice.NewAuthService(db.GetDB()).SetPassword(user.ID, password); err != nil {
+		fmt.Fprintf(os.Stderr, "reset-password: %v\n", err)
+		return 1
+	}
+	if generated {
+		printCredentials("password reset", user.Username, password)
+	} else {
+		fmt.Printf("password of %s reset; all sessions signed out\n", user.Username)
+	}
+	return 0
+}
+
+// lookupUser finds a user by username or ID; exactly one must be given.
+func lookupUser(username string, id uint) (*model.User, error) {
+	if (username == "") == (id == 0) {
+		return nil, errors.New("exactly one of -username and -user-id is required")
+	}
+	users := userservice.NewService(db.GetDB())
+	var user *model.User
+	var err error
+	if id != 0 {
+		user, err = users.GetByID(id)
+	} else {
+		user, err = users.GetByUsername(username)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("user not found")
+	}
+	return user, err
+}
+
+// newPassword reads a password from stdin or generates one. Passwords are never
+// taken from flags so they do not end up in shell history or process listings.
+func newPassword(fromStdin bool, username string) (password string, generated bool, err error) {
+	if !fromStdin {
+		password, err = utils.GeneratePassword()
+		return password, true, err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false, fmt.Errorf("read password: %w", err)
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if err := utils.ValidatePassword(password, username); err != nil {
+		return "", false, err
+	}
+	return password, false, nil
+}
+
+// printCredentials shows a generated password. It is not stored anywhere else.
+func printCredentials(what, username, password string) {
+	fmt.Printf("%s\n  username: %s\n  password: %s\n  (shown only once; store it in a password manager)\n", what, username, password)
+}
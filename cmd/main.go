@@ -1,86 +1,101 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
 	"os"
-	"os/signal"
+	"sort"
 	"strings"
-	"syscall"
-	"time"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
-
-	"ps_backend/api"
-	"ps_backend/db"
+	"ps_backend/pkg/config"
 )
 
+// command is a subcommand of the server binary.
+type command struct {
+	summary string
+	// validate checks the configuration before run; nil skips validation.
+	validate func(cfg *config.Config) error
+	run      func(cfg *config.Config, args []string) int
+}
+
+var commands = map[string]command{
+	"serve":              {"run the API server (default)", (*config.Config).ValidateServer, runServe},
+	"migrate":            {"apply, roll back or list schema migrations", (*config.Config).Validate, runMigrate},
+	"seed":               {"insert the fixtures of an environment", (*config.Config).Validate, runSeed},
+	"create-admin":       {"create an admin account or promote a user", (*config.Config).Validate, runCreateAdmin},
+	"reset-password":     {"set a new password for a user", (*config.Config).Validate, runResetPassword},
+	"purge-expired-data": {"delete expired accounts, tokens, exports and job records", (*config.Config).Validate, runPurgeExpiredData},
+	"export-user":        {"write the data archive of a user to a file", (*config.Config).Validate, runExportUser},
+	"config":             {"print the configuration with secrets redacted", nil, runConfig},
+}
+
 func main() {
-	// Load config
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8100"
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		dsn = "host=localhost user=psdb password=jmung002 dbname=ps_db port=5432 sslmode=disable"
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
 	}
-	corsOrigins := os.Getenv("CORS_ORIGINS") // comma-separated
-
-	// Initialize logger
-	log := logrus.New()
-	log.SetFormatter(&logrus.JSONFormatter{})
-
-	// Connect database and run migrations
-	if _, err := db.ConnectAndMigrateWithDSN(dsn); err != nil {
-		log.Fatalf("Database connection failed: %v", err)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
 	}
 
-	// Setup Gin
-	gin.SetMode(gin.ReleaseMode)
-	router := gin.New()
-	router.Use(gin.LoggerWithWriter(log.Writer()), gin.Recovery())
-
-	// CORS
-	origins := strings.Split(corsOrigins, ",")
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     origins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
-	// Setup API routes
-	router = api.SetupRouter()
+	cfg, err := config.Load()
+	if cmd.validate != nil {
+		if err = errors.Join(err, cmd.validate(cfg)); err != nil {
+			printConfigErrors(err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(cmd.run(cfg, args))
+}
 
-	// HTTP server
-	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%s", port),
-		Handler: router,
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].summary)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
 
-	// Graceful shutdown
-	go func() {
-		log.Infof("Server running on port %s", port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
+// runConfig prints the effective configuration and any problems with it. It
+// runs even when the configuration is invalid, which is when it is most useful.
+func runConfig(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	server := fs.Bool("server", true, "also check what serve needs, such as the JWT secrets")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Info("Shutdown signal received")
+	fmt.Print(cfg.Redacted())
+	validate := cfg.Validate
+	if *server {
+		validate = cfg.ValidateServer
+	}
+	_, err := config.Load()
+	if err = errors.Join(err, validate()); err != nil {
+		fmt.Fprintln(os.Stderr)
+		printConfigErrors(err)
+		return 1
+	}
+	return 0
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+// printConfigErrors lists configuration problems, one per line.
+func printConfigErrors(err error) {
+	fmt.Fprintln(os.Stderr, "invalid configuration:")
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(os.Stderr, "  - %s\n", line)
 	}
-	log.Info("Server exiting")
 }
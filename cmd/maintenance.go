@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"ps_backend/db"
+	authservice "ps_backend/internal/auth"
+	"ps_backend/internal/export"
+	"ps_backend/internal/jobs"
+	tokenservice "ps_backend/internal/token"
+	userservice "ps_backend/internal/user"
+	"ps_backend/pkg/config"
+)
+
+// runPurgeExpiredData runs the scheduled clean-up jobs once, right away.
+func runPurgeExpiredData(_ *config.Config, args []string) int {
+	fs := flag.NewFlagSet("purge-expired-data", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	database := db.GetDB()
+	failed := false
+	report := func(what string, n int64, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", what, err)
+			failed = true
+		}
+		fmt.Printf("%-32s %d\n", what, n)
+	}
+
+	users, err := userservice.NewService(database).PurgeDue()
+	report("accounts past deletion grace", int64(users), err)
+	tokens, err := tokenservice.NewService(database).PurgeExpired()
+	report("expired refresh tokens", tokens, err)
+	resets, err := authservice.NewAuthService(database).PurgeExpiredResetTokens()
+	report("expired password reset tokens", resets, err)
+	exports, err := export.NewService(database).PurgeExpired()
+	report("expired exports", int64(exports), err)
+	succeeded, dead, err := jobs.NewRunner(database).Cleanup()
+	report("old succeeded and dead jobs", succeeded+dead, err)
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// runExportUser writes the same data archive a user can request in the app.
+func runExportUser(_ *config.Config, args []string) int {
+	fs := flag.NewFlagSet("export-user", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the account")
+	userID := fs.Uint("user-id", 0, "ID of the account, instead of -username")
+	output := fs.String("o", "", "output file (default panicshield-data-<user id>-<date>.zip)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	user, err := lookupUser(*username, *userID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-user: %v\n", err)
+		return 1
+	}
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("panicshield-data-%d-%s.zip", user.ID, time.Now().UTC().Format("20060102"))
+	}
+	// O_EXCL keeps an earlier export from being overwritten by accident.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-user: %v\n", err)
+		return 1
+	}
+	if err := export.NewService(db.GetDB()).WriteUserArchive(file, user.ID); err != nil {
+		file.Close()
+		os.Remove(path)
+		fmt.Fprintf(os.Stderr, "export-user: %v\n", err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "export-user: %v\n", err)
+		return 1
+	}
+	fmt.Printf("data of user %s (id %d) written to %s\n", user.Username, user.ID, path)
+	return 0
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"ps_backend/db"
+	"ps_backend/pkg/config"
+)
+
+const migrateUsage = `Usage: %s migrate <command>
+
+Commands:
+  up              apply all pending migrations
+  down [-steps N] roll back the latest N migrations (default 1)
+  status          list migrations and whether they are applied
+  verify          compare the migrated schema with the models, exit 1 on drift
+`
+
+// runMigrate implements the migrate subcommand and returns the process exit code.
+func runMigrate(_ *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return 2
+	}
+
+	migrator, err := db.NewMigrator(db.GetDB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load migrations: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate up: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *steps < 1 {
+			fmt.Fprintln(os.Stderr, "-steps must be at least 1")
+			return 2
+		}
+		rolledBack, err := migrator.Down(*steps)
+		for _, m := range rolledBack {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate down: %v\n", err)
+			return 1
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate status: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", "-"
+			if s.AppliedAt != nil {
+				state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			if s.Modified {
+				state = "modified"
+			}
+			if s.Missing {
+				state = "unknown"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		w.Flush()
+	case "verify":
+		drift, err := migrator.Drift()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate verify: %v\n", err)
+			return 1
+		}
+		for _, d := range drift {
+			fmt.Println(d)
+		}
+		if len(drift) > 0 {
+			return 1
+		}
+		fmt.Println("schema matches the models")
+	default:
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"ps_backend/api"
+	"ps_backend/api/handler"
+	"ps_backend/db"
+	"ps_backend/pkg/config"
+	jwt "ps_backend/pkg/middleware"
+)
+
+// runServe runs the API server and the background jobs until SIGINT or SIGTERM.
+func runServe(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	log := logrus.New()
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	// Refuse to serve against a schema this build does not expect
+	migrator, err := db.NewMigrator(db.GetDB())
+	if err != nil {
+		log.Errorf("Loading migrations failed: %v", err)
+		return 1
+	}
+	if err := migrator.Check(); errors.Is(err, db.ErrPendingMigrations) {
+		log.Errorf("%v; run `%s migrate up` first", err, os.Args[0])
+		return 1
+	} else if err != nil {
+		log.Errorf("Checking the schema failed: %v", err)
+		return 1
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	middleware := []gin.HandlerFunc{gin.LoggerWithWriter(log.Writer()), gin.Recovery(), jwt.Metrics()}
+	if len(cfg.Server.CORSOrigins) > 0 {
+		middleware = append(middleware, cors.New(cors.Config{
+			AllowOrigins:     cfg.Server.CORSOrigins,
+			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
+			ExposeHeaders:    []string{"Content-Length"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		}))
+	}
+	router := api.SetupRouter(middleware...)
+	// Without trusted proxies gin would take the client IP from X-Forwarded-For
+	// of any peer, letting clients dodge the per-IP limits and forge logged IPs.
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Errorf("Invalid trusted proxies: %v", err)
+		return 1
+	}
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler: router,
+	}
+
+	handler.StartJobs()
+
+	// Graceful shutdown
+	errCh := make(chan error, 1)
+	go func() {
+		log.Infof("Server running on port %d", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-quit:
+		log.Info("Shutdown signal received")
+	case err := <-errCh:
+		log.Errorf("listen: %v", err)
+		handler.StopJobs()
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	code := 0
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Errorf("Server forced to shutdown: %v", err)
+		code = 1
+	}
+	handler.StopJobs()
+	log.Info("Server exiting")
+	return code
+}
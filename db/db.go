@@ -0,0 +1,35 @@
+package db
+
+import (
+	"sync"
+
+	"ps_backend/pkg/config"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var (
+	conn     *gorm.DB
+	connOnce sync.Once
+)
+
+// Connect opens a connection pool. It does not touch the schema; see Migrator.
+// Connections are made on first use, so commands that never query the database
+// do not need one.
+func Connect(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+}
+
+// GetDB returns the shared connection pool to the configured database. The
+// commands validate the configuration before they use it.
+func GetDB() *gorm.DB {
+	connOnce.Do(func() {
+		db, err := Connect(config.Get().Database.DSN)
+		if err != nil {
+			panic(err)
+		}
+		conn = db
+	})
+	return conn
+}
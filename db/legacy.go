@@ -0,0 +1,104 @@
+package db
+
+import (
+	"ps_backend/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// upgradeLegacySchema brings a database that was managed by AutoMigrate up to the
+// baseline schema, including the data conversions that used to run on every start.
+// It only runs once, when such a database is adopted by the versioned migrations.
+func upgradeLegacySchema(db *gorm.DB) error {
+	if err := dedupeBookmarks(db); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(models()...); err != nil {
+		return err
+	}
+
+	if err := convertGuidesToSteps(db); err != nil {
+		return err
+	}
+	return convertLegacyPersonas(db)
+}
+
+// dedupeBookmarks removes duplicate bookmarks so the unique (user_id, panic_guide_id) index can be created.
+func dedupeBookmarks(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.UserPanicGuide{}) {
+		return nil
+	}
+	return db.Exec(`DELETE FROM user_panic_guides a
+		USING user_panic_guides b
+		WHERE a.user_id = b.user_id AND a.panic_guide_id = b.panic_guide_id AND a.id > b.id`).Error
+}
+
+// convertGuidesToSteps turns guides created before steps existed into single-step guides.
+func convertGuidesToSteps(db *gorm.DB) error {
+	var guides []model.PanicGuide
+	if err := db.Where("NOT EXISTS (SELECT 1 FROM panic_guide_steps WHERE panic_guide_steps.panic_guide_id = panic_guides.id)").
+		Find(&guides).Error; err != nil {
+		return err
+	}
+	for _, guide := range guides {
+		step := model.PanicGuideStep{
+			PanicGuideID:    guide.ID,
+			Position:        1,
+			Type:            model.StepTypeGeneral,
+			Instruction:     guide.Description,
+			DurationSeconds: 60,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&step).Error; err != nil {
+				return err
+			}
+			return tx.Model(&model.PanicGuide{ID: guide.ID}).Update("estimated_seconds", step.DurationSeconds).Error
+		})
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Converted panic guide %d into a single-step guide", guide.ID)
+	}
+	return nil
+}
+
+// convertLegacyPersonas maps the free-text speaking_style and tone columns onto
+// the persona settings and drops them afterwards.
+func convertLegacyPersonas(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&model.User{}, "speaking_style") || !migrator.HasColumn(&model.User{}, "tone") {
+		return nil
+	}
+	var rows []struct {
+		ID            uint
+		SpeakingStyle string
+		Tone          string
+	}
+	if err := db.Table("users").Select("id, COALESCE(speaking_style, '') AS speaking_style, COALESCE(tone, '') AS tone").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			p := model.PersonaFromLegacy(row.SpeakingStyle, row.Tone)
+			if err := tx.Model(&model.User{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"persona_formality": p.Formality,
+				"persona_warmth":    p.Warmth,
+				"persona_humor":     p.Humor,
+				"persona_verbosity": p.Verbosity,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Migrator().DropColumn(&model.User{}, "speaking_style"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn(&model.User{}, "tone"); err != nil {
+			return err
+		}
+		logrus.Infof("Converted speaking style and tone of %d users into personas", len(rows))
+		return nil
+	})
+}
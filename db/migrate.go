@@ -1,50 +1,361 @@
 package db
 
 import (
-	"os"
-	"ps_backend/model"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func ConnectAndMigrateWithDSN(dsn string) (*gorm.DB, error) {
-	logrus.Infof("Starting database migration")
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var (
+	ErrPendingMigrations = errors.New("database has pending migrations")
+	ErrMigrationModified = errors.New("an applied migration was modified")
+	ErrMigrationMissing  = errors.New("an applied migration is not known to this build")
+	ErrNoDownMigration   = errors.New("migration has no down script")
+)
+
+// Migration is one numbered schema change. The files live in db/migrations as
+// NNNN_name.up.sql and NNNN_name.down.sql and are embedded into the binary.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum identifies the up script so edits to applied migrations are detected.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// SchemaMigration is a row of schema_migrations, one per applied migration.
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:128;not null"`
+	Checksum  string `gorm:"size:64;not null"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus describes one migration for `migrate status`.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the applied up script differs from the embedded one.
+	Modified bool
+	// Missing is set when the database has the migration but this build does not.
+	Missing bool
+}
+
+// LoadMigrations reads the embedded migrations ordered by version.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
 	if err != nil {
-		logrus.Fatalf("DB connection failed: %v", err)
 		return nil, err
 	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("unexpected migration file %s", name)
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %s must be named NNNN_name.%s.sql", name, direction)
+		}
+		body, err := fs.ReadFile(migrationFiles, path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d has files named %s and %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back the embedded migrations.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Status lists every known and applied migration ordered by version.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var statuses []MigrationStatus
+	for _, mig := range m.migrations {
+		status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = row.Checksum != mig.Checksum()
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Check returns ErrPendingMigrations when the schema is behind this build and
+// ErrMigrationModified when an applied migration was edited afterwards.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.Modified {
+			return fmt.Errorf("%w: %04d_%s", ErrMigrationModified, s.Version, s.Name)
+		}
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+	return nil
+}
 
-	err = db.AutoMigrate(
-		&model.User{},
-		&model.ChatbotLog{},
-		&model.Interest{},
-		&model.SubInterest{},
-		&model.UserInterest{},
-		&model.UserSubInterest{},
-		&model.VitalSign{},
-		&model.PanicGuide{},
-		&model.UserPanicGuide{},
-	)
+// Up applies all pending migrations in order, each in its own transaction.
+// A database that was created by AutoMigrate is upgraded and recorded at the
+// baseline first instead of running the baseline script against it.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.adoptLegacy(); err != nil {
+		return nil, err
+	}
+	if err := m.verifyApplied(); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending()
 	if err != nil {
-		logrus.Fatalf("Migration failed: %v", err)
 		return nil, err
 	}
+	for i, mig := range pending {
+		logrus.Infof("Applying migration %04d_%s", mig.Version, mig.Name)
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Checksum:  mig.Checksum(),
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+	return pending, nil
+}
 
-	logrus.Infof("Database migration completed")
-	return db, nil
+// Down rolls back the latest steps applied migrations, newest first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := ensureTable(m.db); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := m.db.Order("version DESC").Limit(steps).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	var rolledBack []Migration
+	for _, row := range rows {
+		mig, ok := known[row.Version]
+		if !ok {
+			return rolledBack, fmt.Errorf("%w: %04d_%s", ErrMigrationMissing, row.Version, row.Name)
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			return rolledBack, fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, mig.Version, mig.Name)
+		}
+		logrus.Infof("Rolling back migration %04d_%s", mig.Version, mig.Name)
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, mig.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		rolledBack = append(rolledBack, mig)
+	}
+	return rolledBack, nil
 }
 
-func GetDB() *gorm.DB {
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		panic("DATABASE_DSN environment variable not set")
+// applied returns the recorded migrations keyed by version. A database without
+// schema_migrations has none applied.
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	applied := make(map[int]SchemaMigration)
+	exists, err := m.hasTable("schema_migrations")
+	if err != nil || !exists {
+		return applied, err
 	}
-	db, err := ConnectAndMigrateWithDSN(dsn)
+	var rows []SchemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// verifyApplied refuses to continue when history and the embedded files disagree.
+func (m *Migrator) verifyApplied() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if s.Modified {
+			return fmt.Errorf("%w: %04d_%s", ErrMigrationModified, s.Version, s.Name)
+		}
+		if s.Missing {
+			return fmt.Errorf("%w: %04d_%s", ErrMigrationMissing, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// hasTable reports whether the table exists. Unlike gorm's HasTable it returns
+// connection errors instead of reporting the table as missing.
+func (m *Migrator) hasTable(name string) (bool, error) {
+	var exists bool
+	err := m.db.Raw("SELECT to_regclass(?) IS NOT NULL", name).Scan(&exists).Error
+	return exists, err
+}
+
+func ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name varchar(128) NOT NULL,
+		checksum varchar(64) NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error
+}
+
+// adoptLegacy records the baseline as applied on databases that predate
+// schema_migrations, after bringing them up to the baseline schema. The upgrade,
+// the tracking table and the baseline row are committed together, so a failed
+// upgrade leaves the database untracked and the next run retries it. An empty
+// schema_migrations next to a users table is treated as untracked as well.
+func (m *Migrator) adoptLegacy() error {
+	tracked, err := m.hasTable("schema_migrations")
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if tracked {
+		var count int64
+		if err := m.db.Model(&SchemaMigration{}).Count(&count).Error; err != nil {
+			return err
+		}
+		tracked = count > 0
+	}
+	legacy, err := m.hasTable("users")
+	if err != nil {
+		return err
+	}
+	if tracked || !legacy || len(m.migrations) == 0 {
+		return ensureTable(m.db)
 	}
-	return db
+	baseline := m.migrations[0]
+	logrus.Infof("Adopting existing database at migration %04d_%s", baseline.Version, baseline.Name)
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureTable(tx); err != nil {
+			return err
+		}
+		if err := upgradeLegacySchema(tx); err != nil {
+			return fmt.Errorf("upgrade legacy schema: %w", err)
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   baseline.Version,
+			Name:      baseline.Name,
+			Checksum:  baseline.Checksum(),
+			AppliedAt: time.Now(),
+		}).Error
+	})
 }
@@ -0,0 +1,36 @@
+DROP TABLE IF EXISTS "job_schedules";
+DROP TABLE IF EXISTS "jobs";
+DROP TABLE IF EXISTS "user_insights";
+DROP TABLE IF EXISTS "questionnaire_responses";
+DROP TABLE IF EXISTS "check_ins";
+DROP TABLE IF EXISTS "check_in_schedules";
+DROP TABLE IF EXISTS "notification_preferences";
+DROP TABLE IF EXISTS "notification_deliveries";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "devices";
+DROP TABLE IF EXISTS "login_histories";
+DROP TABLE IF EXISTS "password_reset_tokens";
+DROP TABLE IF EXISTS "refresh_tokens";
+DROP TABLE IF EXISTS "export_jobs";
+DROP TABLE IF EXISTS "care_share_access_logs";
+DROP TABLE IF EXISTS "care_shares";
+DROP TABLE IF EXISTS "panic_episodes";
+DROP TABLE IF EXISTS "sos_alert_deliveries";
+DROP TABLE IF EXISTS "sos_alerts";
+DROP TABLE IF EXISTS "emergency_contacts";
+DROP TABLE IF EXISTS "chatbot_prompts";
+DROP TABLE IF EXISTS "breathing_sessions";
+DROP TABLE IF EXISTS "llm_usages";
+DROP TABLE IF EXISTS "user_panic_guides";
+DROP TABLE IF EXISTS "panic_guide_interests";
+DROP TABLE IF EXISTS "panic_guide_step_translations";
+DROP TABLE IF EXISTS "panic_guide_translations";
+DROP TABLE IF EXISTS "panic_guide_steps";
+DROP TABLE IF EXISTS "panic_guides";
+DROP TABLE IF EXISTS "vital_signs";
+DROP TABLE IF EXISTS "user_sub_interests";
+DROP TABLE IF EXISTS "user_interests";
+DROP TABLE IF EXISTS "sub_interests";
+DROP TABLE IF EXISTS "interests";
+DROP TABLE IF EXISTS "chatbot_logs";
+DROP TABLE IF EXISTS "users";
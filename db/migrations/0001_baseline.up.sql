@@ -0,0 +1,508 @@
+-- Baseline schema: every table the application used when schema changes moved
+-- from AutoMigrate to versioned migrations.
+
+CREATE TABLE "users" (
+    "id" bigserial,
+    "username" text NOT NULL,
+    "password_hash" text NOT NULL,
+    "phone_number" text NOT NULL,
+    "verified" boolean DEFAULT false,
+    "display_name" varchar(64),
+    "timezone" varchar(64) NOT NULL DEFAULT 'Asia/Seoul',
+    "locale" varchar(16),
+    "plan" varchar(32) NOT NULL DEFAULT 'free',
+    "role" varchar(32) NOT NULL DEFAULT 'user',
+    "created_at" timestamptz,
+    "persona_formality" varchar(16) NOT NULL DEFAULT 'polite',
+    "persona_warmth" varchar(16) NOT NULL DEFAULT 'balanced',
+    "persona_humor" varchar(16) NOT NULL DEFAULT 'light',
+    "persona_verbosity" varchar(16) NOT NULL DEFAULT 'balanced',
+    "persona_language" varchar(16),
+    "persona_nickname" varchar(32),
+    "failed_login_count" bigint NOT NULL DEFAULT 0,
+    "locked_until" timestamptz,
+    "deletion_requested_at" timestamptz,
+    "deletion_scheduled_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_username" UNIQUE ("username"),
+    CONSTRAINT "uni_users_phone_number" UNIQUE ("phone_number")
+);
+CREATE INDEX "idx_users_deletion_scheduled_at" ON "users" ("deletion_scheduled_at");
+
+CREATE TABLE "chatbot_logs" (
+    "id" bigserial,
+    "user_id" bigint,
+    "message" text,
+    "sender" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "interests" (
+    "id" bigserial,
+    "name" varchar(255) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_interests_name" UNIQUE ("name")
+);
+
+CREATE TABLE "sub_interests" (
+    "id" bigserial,
+    "interest_id" bigint NOT NULL,
+    "name" varchar(255) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_sub_interests_interest_id" ON "sub_interests" ("interest_id");
+
+CREATE TABLE "user_interests" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "interest_id" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_user_interests_interest_id" ON "user_interests" ("interest_id");
+CREATE INDEX "idx_user_interests_user_id" ON "user_interests" ("user_id");
+
+CREATE TABLE "user_sub_interests" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "sub_interest_id" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_user_sub_interests_sub_interest_id" ON "user_sub_interests" ("sub_interest_id");
+CREATE INDEX "idx_user_sub_interests_user_id" ON "user_sub_interests" ("user_id");
+
+CREATE TABLE "vital_signs" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "device_id" bigint,
+    "heart_rate" bigint,
+    "breath_rate" bigint,
+    "stress_level" bigint,
+    "measured_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_vital_signs_device_id" ON "vital_signs" ("device_id");
+CREATE INDEX "idx_vital_signs_user_id" ON "vital_signs" ("user_id");
+
+CREATE TABLE "panic_guides" (
+    "id" bigserial,
+    "title" varchar(128) NOT NULL,
+    "description" text NOT NULL,
+    "locale" varchar(16) NOT NULL DEFAULT 'ko',
+    "difficulty" varchar(16) NOT NULL DEFAULT 'easy',
+    "estimated_seconds" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "panic_guide_steps" (
+    "id" bigserial,
+    "panic_guide_id" bigint NOT NULL,
+    "position" bigint NOT NULL,
+    "type" varchar(32) NOT NULL,
+    "instruction" text NOT NULL,
+    "duration_seconds" bigint NOT NULL DEFAULT 0,
+    "audio_url" varchar(512),
+    "image_url" varchar(512),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_panic_guides_steps" FOREIGN KEY ("panic_guide_id") REFERENCES "panic_guides"("id")
+);
+CREATE INDEX "idx_panic_guide_steps_panic_guide_id" ON "panic_guide_steps" ("panic_guide_id");
+
+CREATE TABLE "panic_guide_translations" (
+    "id" bigserial,
+    "panic_guide_id" bigint NOT NULL,
+    "locale" varchar(16) NOT NULL,
+    "title" varchar(128) NOT NULL,
+    "description" text NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_guide_translation" ON "panic_guide_translations" ("panic_guide_id","locale");
+
+CREATE TABLE "panic_guide_step_translations" (
+    "id" bigserial,
+    "panic_guide_id" bigint NOT NULL,
+    "position" bigint NOT NULL,
+    "locale" varchar(16) NOT NULL,
+    "instruction" text NOT NULL,
+    "audio_url" varchar(512),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_step_translation" ON "panic_guide_step_translations" ("panic_guide_id","position","locale");
+
+CREATE TABLE "panic_guide_interests" (
+    "panic_guide_id" bigint NOT NULL,
+    "interest_id" bigint NOT NULL,
+    PRIMARY KEY ("panic_guide_id","interest_id"),
+    CONSTRAINT "fk_panic_guide_interests_panic_guide" FOREIGN KEY ("panic_guide_id") REFERENCES "panic_guides"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_panic_guide_interests_interest" FOREIGN KEY ("interest_id") REFERENCES "interests"("id") ON DELETE CASCADE
+);
+
+CREATE TABLE "user_panic_guides" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "panic_guide_id" bigint NOT NULL,
+    "folder" varchar(64) NOT NULL DEFAULT '',
+    "position" bigint NOT NULL DEFAULT 0,
+    "pinned" boolean NOT NULL DEFAULT false,
+    "bookmarked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_user_panic_guides_panic_guide_id" ON "user_panic_guides" ("panic_guide_id");
+CREATE UNIQUE INDEX "idx_user_panic_guide" ON "user_panic_guides" ("user_id","panic_guide_id");
+
+CREATE TABLE "llm_usages" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "model" varchar(64) NOT NULL,
+    "prompt_tokens" bigint,
+    "completion_tokens" bigint,
+    "total_tokens" bigint,
+    "latency_ms" bigint,
+    "success" boolean,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_llm_usages_created_at" ON "llm_usages" ("created_at");
+CREATE INDEX "idx_llm_usages_user_id" ON "llm_usages" ("user_id");
+
+CREATE TABLE "breathing_sessions" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "panic_guide_id" bigint NOT NULL,
+    "pattern" varchar(32) NOT NULL,
+    "phases" varchar(255) NOT NULL,
+    "cycles" bigint NOT NULL,
+    "cycles_completed" bigint NOT NULL DEFAULT 0,
+    "status" varchar(16) NOT NULL,
+    "heart_rate_before" bigint,
+    "heart_rate_after" bigint,
+    "started_at" timestamptz NOT NULL,
+    "ended_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_breathing_sessions_status" ON "breathing_sessions" ("status");
+CREATE INDEX "idx_breathing_sessions_panic_guide_id" ON "breathing_sessions" ("panic_guide_id");
+CREATE INDEX "idx_breathing_sessions_user_id" ON "breathing_sessions" ("user_id");
+
+CREATE TABLE "chatbot_prompts" (
+    "id" bigserial,
+    "name" varchar(64) NOT NULL,
+    "content" text NOT NULL,
+    "active" boolean NOT NULL DEFAULT false,
+    "updated_by" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_chatbot_prompts_name" UNIQUE ("name")
+);
+CREATE INDEX "idx_chatbot_prompts_active" ON "chatbot_prompts" ("active");
+
+CREATE TABLE "emergency_contacts" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "phone_number" varchar(32) NOT NULL,
+    "relationship" varchar(50),
+    "consent_confirmed" boolean NOT NULL DEFAULT false,
+    "consent_confirmed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_emergency_contacts_user_id" ON "emergency_contacts" ("user_id");
+
+CREATE TABLE "sos_alerts" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "trigger" varchar(32) NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "latitude" decimal,
+    "longitude" decimal,
+    "message" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_sos_alerts_created_at" ON "sos_alerts" ("created_at");
+CREATE INDEX "idx_sos_alerts_status" ON "sos_alerts" ("status");
+CREATE INDEX "idx_sos_alerts_user_id" ON "sos_alerts" ("user_id");
+
+CREATE TABLE "sos_alert_deliveries" (
+    "id" bigserial,
+    "sos_alert_id" bigint NOT NULL,
+    "emergency_contact_id" bigint NOT NULL,
+    "phone_number" varchar(32) NOT NULL,
+    "success" boolean NOT NULL,
+    "error" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sos_alerts_deliveries" FOREIGN KEY ("sos_alert_id") REFERENCES "sos_alerts"("id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_sos_alert_deliveries_emergency_contact_id" ON "sos_alert_deliveries" ("emergency_contact_id");
+CREATE INDEX "idx_sos_alert_deliveries_sos_alert_id" ON "sos_alert_deliveries" ("sos_alert_id");
+
+CREATE TABLE "panic_episodes" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "source" varchar(16) NOT NULL,
+    "started_at" timestamptz NOT NULL,
+    "ended_at" timestamptz,
+    "intensity" bigint,
+    "peak_heart_rate" bigint,
+    "notes" text,
+    "location" varchar(32),
+    "triggers" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_panic_episodes_started_at" ON "panic_episodes" ("started_at");
+CREATE INDEX "idx_panic_episodes_user_id" ON "panic_episodes" ("user_id");
+
+CREATE TABLE "care_shares" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "clinician_id" bigint,
+    "invite_code" varchar(16) NOT NULL,
+    "scopes" text NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "invite_expires_at" timestamptz NOT NULL,
+    "accepted_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_care_shares_status" ON "care_shares" ("status");
+CREATE UNIQUE INDEX "idx_care_shares_invite_code" ON "care_shares" ("invite_code");
+CREATE INDEX "idx_care_shares_clinician_id" ON "care_shares" ("clinician_id");
+CREATE INDEX "idx_care_shares_user_id" ON "care_shares" ("user_id");
+
+CREATE TABLE "care_share_access_logs" (
+    "id" bigserial,
+    "care_share_id" bigint NOT NULL,
+    "clinician_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "scope" varchar(32) NOT NULL,
+    "path" varchar(255),
+    "client_ip" varchar(64),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_care_share_access_logs_created_at" ON "care_share_access_logs" ("created_at");
+CREATE INDEX "idx_care_share_access_logs_user_id" ON "care_share_access_logs" ("user_id");
+CREATE INDEX "idx_care_share_access_logs_clinician_id" ON "care_share_access_logs" ("clinician_id");
+CREATE INDEX "idx_care_share_access_logs_care_share_id" ON "care_share_access_logs" ("care_share_id");
+
+CREATE TABLE "export_jobs" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "format" varchar(16) NOT NULL,
+    "from" timestamptz NOT NULL,
+    "to" timestamptz NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "file_path" varchar(255),
+    "file_name" varchar(128),
+    "content_type" varchar(64),
+    "size_bytes" bigint,
+    "error" varchar(255),
+    "completed_at" timestamptz,
+    "expires_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_export_jobs_expires_at" ON "export_jobs" ("expires_at");
+CREATE INDEX "idx_export_jobs_status" ON "export_jobs" ("status");
+CREATE INDEX "idx_export_jobs_user_id" ON "export_jobs" ("user_id");
+
+CREATE TABLE "refresh_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "device_id" bigint,
+    "token_id" varchar(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_refresh_tokens_expires_at" ON "refresh_tokens" ("expires_at");
+CREATE UNIQUE INDEX "idx_refresh_tokens_token_id" ON "refresh_tokens" ("token_id");
+CREATE INDEX "idx_refresh_tokens_device_id" ON "refresh_tokens" ("device_id");
+CREATE INDEX "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE "password_reset_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_password_reset_tokens_expires_at" ON "password_reset_tokens" ("expires_at");
+CREATE UNIQUE INDEX "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+CREATE INDEX "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
+
+CREATE TABLE "login_histories" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "result" varchar(16) NOT NULL,
+    "ip" varchar(64),
+    "user_agent" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_login_history_user_created" ON "login_histories" ("user_id","created_at");
+
+CREATE TABLE "devices" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "installation_id" varchar(64) NOT NULL,
+    "type" varchar(16) NOT NULL,
+    "name" varchar(64),
+    "model" varchar(64),
+    "os" varchar(64),
+    "push_token" varchar(512),
+    "push_provider" varchar(16),
+    "last_seen_at" timestamptz,
+    "last_ip" varchar(64),
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_device_user_installation" ON "devices" ("user_id","installation_id");
+
+CREATE TABLE "notifications" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "category" varchar(32) NOT NULL,
+    "title" varchar(200) NOT NULL,
+    "body" text,
+    "data" text,
+    "status" varchar(16) NOT NULL,
+    "read_at" timestamptz,
+    "sent_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_notifications_status" ON "notifications" ("status");
+CREATE INDEX "idx_notification_user_created" ON "notifications" ("user_id","created_at");
+
+CREATE TABLE "notification_deliveries" (
+    "id" bigserial,
+    "notification_id" bigint NOT NULL,
+    "device_id" bigint NOT NULL,
+    "provider" varchar(16) NOT NULL,
+    "success" boolean NOT NULL,
+    "attempts" bigint NOT NULL,
+    "error" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notifications_deliveries" FOREIGN KEY ("notification_id") REFERENCES "notifications"("id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_notification_deliveries_device_id" ON "notification_deliveries" ("device_id");
+CREATE INDEX "idx_notification_deliveries_notification_id" ON "notification_deliveries" ("notification_id");
+
+CREATE TABLE "notification_preferences" (
+    "user_id" bigint NOT NULL,
+    "disabled_categories" text,
+    "quiet_hours_enabled" boolean NOT NULL DEFAULT false,
+    "quiet_hours_start" varchar(5) NOT NULL DEFAULT '22:00',
+    "quiet_hours_end" varchar(5) NOT NULL DEFAULT '07:00',
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id")
+);
+
+CREATE TABLE "check_in_schedules" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "time" varchar(5) NOT NULL,
+    "enabled" boolean NOT NULL DEFAULT true,
+    "last_prompt_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_checkin_schedule_user_time" ON "check_in_schedules" ("user_id","time");
+
+CREATE TABLE "check_ins" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "schedule_id" bigint,
+    "mood" bigint NOT NULL,
+    "nervousness" bigint NOT NULL,
+    "worry" bigint NOT NULL,
+    "anxiety_score" bigint NOT NULL,
+    "sleep_quality" bigint,
+    "note" text,
+    "prompted_at" timestamptz,
+    "responded_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_check_ins_schedule_id" ON "check_ins" ("schedule_id");
+CREATE INDEX "idx_checkin_user_responded" ON "check_ins" ("user_id","responded_at");
+
+CREATE TABLE "questionnaire_responses" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "instrument" varchar(16) NOT NULL,
+    "version" bigint NOT NULL,
+    "answers" text NOT NULL,
+    "total_score" bigint NOT NULL,
+    "severity" varchar(32) NOT NULL,
+    "safety_flag" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_questionnaire_user_instrument" ON "questionnaire_responses" ("user_id","instrument","created_at");
+
+CREATE TABLE "user_insights" (
+    "user_id" bigint NOT NULL,
+    "from" timestamptz,
+    "to" timestamptz,
+    "stats" text,
+    "insights" text,
+    "computed_at" timestamptz,
+    PRIMARY KEY ("user_id")
+);
+CREATE INDEX "idx_user_insights_computed_at" ON "user_insights" ("computed_at");
+
+CREATE TABLE "jobs" (
+    "id" bigserial,
+    "type" varchar(64) NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "payload" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "run_at" timestamptz NOT NULL,
+    "locked_by" varchar(64),
+    "locked_at" timestamptz,
+    "last_error" text,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_jobs_claim" ON "jobs" ("status","type","run_at");
+
+CREATE TABLE "job_schedules" (
+    "name" varchar(64),
+    "spec" varchar(64) NOT NULL,
+    "job_type" varchar(64) NOT NULL,
+    "next_run_at" timestamptz NOT NULL,
+    "last_run_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("name")
+);
@@ -0,0 +1,5 @@
+-- Access tokens of a user issued before this time are rejected. It is set when
+-- every session of the user is revoked, e.g. on a password change.
+-- IF NOT EXISTS: adopting a pre-migration database runs AutoMigrate with the
+-- current models, which already adds the column.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "tokens_valid_after" timestamptz;
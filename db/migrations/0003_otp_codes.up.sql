@@ -0,0 +1,16 @@
+-- One-time SMS codes, previously kept in the memory of each server process.
+-- IF NOT EXISTS: adopting a pre-migration database runs AutoMigrate with the
+-- current models, which already creates the table.
+CREATE TABLE IF NOT EXISTS "otp_codes" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "purpose" varchar(32) NOT NULL,
+    "code_hash" varchar(64) NOT NULL,
+    "phone" varchar(32) NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_otp_codes_user_purpose" ON "otp_codes" ("user_id","purpose");
+CREATE INDEX IF NOT EXISTS "idx_otp_codes_expires_at" ON "otp_codes" ("expires_at");
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// models returns every model stored in the database. The migrations must create
+// the tables and columns these declare; Drift reports where they do not.
+func models() []interface{} {
+	return []interface{}{
+		&model.User{},
+		&model.ChatbotLog{},
+		&model.Interest{},
+		&model.SubInterest{},
+		&model.UserInterest{},
+		&model.UserSubInterest{},
+		&model.VitalSign{},
+		&model.PanicGuide{},
+		&model.PanicGuideStep{},
+		&model.PanicGuideTranslation{},
+		&model.PanicGuideStepTranslation{},
+		&model.UserPanicGuide{},
+		&model.LLMUsage{},
+		&model.BreathingSession{},
+		&model.ChatbotPrompt{},
+		&model.EmergencyContact{},
+		&model.SOSAlert{},
+		&model.SOSAlertDelivery{},
+		&model.PanicEpisode{},
+		&model.CareShare{},
+		&model.CareShareAccessLog{},
+		&model.ExportJob{},
+		&model.RefreshToken{},
+		&model.PasswordResetToken{},
+		&model.OTPCode{},
+		&model.LoginHistory{},
+		&model.Device{},
+		&model.Notification{},
+		&model.NotificationDelivery{},
+		&model.NotificationPreference{},
+		&model.CheckInSchedule{},
+		&model.CheckIn{},
+		&model.QuestionnaireResponse{},
+		&model.UserInsight{},
+		&model.Job{},
+		&model.JobSchedule{},
+	}
+}
+
+// Drift compares the models, including their many2many join tables, with the
+// tables in the database and describes every table or column that one side has
+// and the other lacks. An empty result means the migrations match the models.
+func (m *Migrator) Drift() ([]string, error) {
+	tables := make(map[string][]string)
+	for _, value := range models() {
+		stmt := &gorm.Statement{DB: m.db}
+		if err := stmt.Parse(value); err != nil {
+			return nil, err
+		}
+		tables[stmt.Schema.Table] = stmt.Schema.DBNames
+		for _, rel := range stmt.Schema.Relationships.Many2Many {
+			tables[rel.JoinTable.Table] = rel.JoinTable.DBNames
+		}
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var drift []string
+	for _, table := range names {
+		var columns []string
+		if err := m.db.Raw(`SELECT column_name FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ?`, table).Scan(&columns).Error; err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			drift = append(drift, fmt.Sprintf("table %s is missing", table))
+			continue
+		}
+		existing := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			existing[column] = true
+		}
+		for _, column := range tables[table] {
+			if !existing[column] {
+				drift = append(drift, fmt.Sprintf("column %s.%s is missing", table, column))
+			}
+			delete(existing, column)
+		}
+		extra := make([]string, 0, len(existing))
+		for column := range existing {
+			extra = append(extra, column)
+		}
+		sort.Strings(extra)
+		for _, column := range extra {
+			drift = append(drift, fmt.Sprintf("column %s.%s is not declared by any model", table, column))
+		}
+	}
+	return drift, nil
+}
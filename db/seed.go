@@ -1,42 +1,141 @@
 package db
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"ps_backend/model"
 	"ps_backend/pkg/utils"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-func Seed(db *gorm.DB) {
-	logrus.Info("Starting database seeding...")
+// Seed environments. Each one includes the fixtures of the ones before it.
+const (
+	SeedProduction  = "production"  // reference data and the admin account
+	SeedStaging     = "staging"     // plus a demo account
+	SeedDevelopment = "development" // plus two weeks of sample data for the demo account
+)
+
+// SeedEnvironments lists the environments Seed accepts.
+var SeedEnvironments = []string{SeedProduction, SeedStaging, SeedDevelopment}
+
+// ErrUnknownSeedEnvironment is returned for an environment not in SeedEnvironments.
+var ErrUnknownSeedEnvironment = errors.New("unknown seed environment")
+
+const (
+	seedAdminUsername = "admin"
+	seedAdminPhone    = "0000000000"
+	seedDemoUsername  = "demo"
+	seedDemoPhone     = "01000000000"
+)
+
+// SeededAccount is an account created by Seed. The password is generated and
+// only available here, so it must be shown to the operator right away.
+type SeededAccount struct {
+	Username string
+	Password string
+	Role     string
+}
+
+// Seed inserts the fixtures of the environment. It is idempotent: existing rows
+// are left alone and accounts that already exist keep their password, so only
+// newly created accounts are returned.
+func Seed(db *gorm.DB, env string) ([]SeededAccount, error) {
+	var levels int
+	switch env {
+	case SeedProduction:
+		levels = 1
+	case SeedStaging:
+		levels = 2
+	case SeedDevelopment:
+		levels = 3
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSeedEnvironment, env)
+	}
+	logrus.Infof("Seeding %s fixtures", env)
+
+	if err := seedReferenceData(db); err != nil {
+		return nil, err
+	}
+	var created []SeededAccount
+	admin, account, err := seedAccount(db, seedAdminUsername, seedAdminPhone, model.RoleAdmin, model.PersonaFromLegacy("존댓말", "진지함"))
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		created = append(created, *account)
+	}
+	if admin.Role != model.RoleAdmin {
+		return created, fmt.Errorf("user %q exists but is not an admin", seedAdminUsername)
+	}
+
+	if levels >= 2 {
+		demo, account, err := seedAccount(db, seedDemoUsername, seedDemoPhone, model.RoleUser, model.DefaultPersona())
+		if err != nil {
+			return created, err
+		}
+		if account != nil {
+			created = append(created, *account)
+		}
+		if levels >= 3 {
+			if err := seedSampleData(db, demo.ID, time.Now()); err != nil {
+				return created, err
+			}
+		}
+	}
+
+	logrus.Info("Database seeding completed.")
+	return created, nil
+}
+
+// seedAccount returns the user with the username, creating a verified account
+// with a generated password if it does not exist yet. The account is only
+// returned when it was created.
+func seedAccount(db *gorm.DB, username, phone, role string, persona model.Persona) (*model.User, *SeededAccount, error) {
+	var user model.User
+	err := db.Where("username = ?", username).First(&user).Error
+	if err == nil {
+		return &user, nil, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
 
-	// Seed default admin user
-	hashedPassword, _ := utils.HashPassword("admin123") // default admin password
-	adminUser := model.User{
-		Username:      "admin",
-		PasswordHash:  hashedPassword,
-		PhoneNumber:   "0000000000",
-		Verified:      true,
-		SpeakingStyle: "존댓말",
-		Tone:          "진지함",
-		CreatedAt:     time.Now(),
+	password, err := utils.GeneratePassword()
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, nil, err
 	}
-	if err := db.FirstOrCreate(&adminUser, model.User{Username: "admin"}).Error; err != nil {
-		logrus.Errorf("Failed to seed admin user: %v", err)
-	} else {
-		logrus.Info("Seeded admin user")
+	user = model.User{
+		Username:     username,
+		PasswordHash: hash,
+		PhoneNumber:  phone,
+		Verified:     true,
+		Role:         role,
+		Persona:      persona,
+		CreatedAt:    time.Now(),
 	}
+	if err := db.Create(&user).Error; err != nil {
+		return nil, nil, fmt.Errorf("seed user %s: %w", username, err)
+	}
+	logrus.Infof("Seeded %s user %s", role, username)
+	return &user, &SeededAccount{Username: username, Password: password, Role: role}, nil
+}
 
+// seedReferenceData inserts the interests and panic guides every environment needs.
+func seedReferenceData(db *gorm.DB) error {
 	// Seed interests
 	interests := []string{"운동", "음악", "독서"}
 	for _, interestName := range interests {
 		interest := model.Interest{Name: interestName}
 		if err := db.FirstOrCreate(&interest, model.Interest{Name: interestName}).Error; err != nil {
-			logrus.Errorf("Failed to seed interest %s: %v", interestName, err)
-		} else {
-			logrus.Infof("Seeded interest %s", interestName)
+			return fmt.Errorf("seed interest %s: %w", interestName, err)
 		}
 	}
 
@@ -50,8 +149,7 @@ func Seed(db *gorm.DB) {
 	for parentName, subNames := range subInterestsMap {
 		var parentInterest model.Interest
 		if err := db.Where("name = ?", parentName).First(&parentInterest).Error; err != nil {
-			logrus.Errorf("Failed to find parent interest %s: %v", parentName, err)
-			continue
+			return fmt.Errorf("find parent interest %s: %w", parentName, err)
 		}
 		for _, subName := range subNames {
 			subInterest := model.SubInterest{
@@ -59,27 +157,155 @@ func Seed(db *gorm.DB) {
 				InterestID: parentInterest.ID,
 			}
 			if err := db.FirstOrCreate(&subInterest, model.SubInterest{Name: subName, InterestID: parentInterest.ID}).Error; err != nil {
-				logrus.Errorf("Failed to seed sub-interest %s under %s: %v", subName, parentName, err)
-			} else {
-				logrus.Infof("Seeded sub-interest %s under %s", subName, parentName)
+				return fmt.Errorf("seed sub-interest %s under %s: %w", subName, parentName, err)
 			}
 		}
 	}
 
 	// Seed panic guides
 	panicGuides := []model.PanicGuide{
-		{Title: "심호흡", Description: "천천히 깊게 숨을 쉬세요."},
-		{Title: "자리 이동", Description: "안전한 곳으로 이동하세요."},
-		{Title: "도움 요청", Description: "주변 사람에게 도움을 요청하세요."},
+		{
+			Title: "심호흡", Description: "천천히 깊게 숨을 쉬세요.", EstimatedSeconds: 60,
+			Steps: []model.PanicGuideStep{
+				{Position: 1, Type: model.StepTypeBreathing, Instruction: "천천히 깊게 숨을 쉬세요.", DurationSeconds: 60},
+			},
+		},
+		{
+			Title: "자리 이동", Description: "안전한 곳으로 이동하세요.", EstimatedSeconds: 60,
+			Steps: []model.PanicGuideStep{
+				{Position: 1, Type: model.StepTypeGeneral, Instruction: "안전한 곳으로 이동하세요.", DurationSeconds: 60},
+			},
+		},
+		{
+			Title: "도움 요청", Description: "주변 사람에게 도움을 요청하세요.", EstimatedSeconds: 60,
+			Steps: []model.PanicGuideStep{
+				{Position: 1, Type: model.StepTypeGeneral, Instruction: "주변 사람에게 도움을 요청하세요.", DurationSeconds: 60},
+			},
+		},
+		{
+			Title: "5-4-3-2-1 그라운딩", Description: "감각에 집중해 지금 이 순간으로 돌아오세요.", Difficulty: model.DifficultyEasy, EstimatedSeconds: 150,
+			Steps: []model.PanicGuideStep{
+				{Position: 1, Type: model.StepTypeGrounding, Instruction: "눈에 보이는 것 5가지를 말해보세요.", DurationSeconds: 30},
+				{Position: 2, Type: model.StepTypeGrounding, Instruction: "만질 수 있는 것 4가지를 느껴보세요.", DurationSeconds: 30},
+				{Position: 3, Type: model.StepTypeGrounding, Instruction: "들리는 소리 3가지에 귀 기울이세요.", DurationSeconds: 30},
+				{Position: 4, Type: model.StepTypeGrounding, Instruction: "맡을 수 있는 냄새 2가지를 찾아보세요.", DurationSeconds: 30},
+				{Position: 5, Type: model.StepTypeGrounding, Instruction: "느낄 수 있는 맛 1가지를 떠올려보세요.", DurationSeconds: 30},
+			},
+		},
 	}
 
 	for _, guide := range panicGuides {
 		if err := db.FirstOrCreate(&guide, model.PanicGuide{Title: guide.Title}).Error; err != nil {
-			logrus.Errorf("Failed to seed panic guide %s: %v", guide.Title, err)
-		} else {
-			logrus.Infof("Seeded panic guide %s", guide.Title)
+			return fmt.Errorf("seed panic guide %s: %w", guide.Title, err)
 		}
 	}
 
-	logrus.Info("Database seeding completed.")
+	// Seed English translations of the panic guides
+	englishGuides := map[string]struct {
+		title, description string
+		steps              []string
+	}{
+		"심호흡":   {"Deep breathing", "Breathe slowly and deeply.", []string{"Breathe slowly and deeply."}},
+		"자리 이동": {"Move to a safe place", "Move somewhere you feel safe.", []string{"Move somewhere you feel safe."}},
+		"도움 요청": {"Ask for help", "Ask someone nearby for help.", []string{"Ask someone nearby for help."}},
+		"5-4-3-2-1 그라운딩": {"5-4-3-2-1 grounding", "Focus on your senses to return to the present moment.", []string{
+			"Name 5 things you can see.",
+			"Feel 4 things you can touch.",
+			"Listen for 3 sounds you can hear.",
+			"Find 2 things you can smell.",
+			"Notice 1 thing you can taste.",
+		}},
+	}
+	for koTitle, en := range englishGuides {
+		var guide model.PanicGuide
+		if err := db.Where("title = ?", koTitle).First(&guide).Error; err != nil {
+			return fmt.Errorf("find panic guide %s: %w", koTitle, err)
+		}
+		translation := model.PanicGuideTranslation{PanicGuideID: guide.ID, Locale: "en", Title: en.title, Description: en.description}
+		if err := db.FirstOrCreate(&translation, model.PanicGuideTranslation{PanicGuideID: guide.ID, Locale: "en"}).Error; err != nil {
+			return fmt.Errorf("seed translation of panic guide %s: %w", koTitle, err)
+		}
+		for i, instruction := range en.steps {
+			step := model.PanicGuideStepTranslation{PanicGuideID: guide.ID, Position: i + 1, Locale: "en", Instruction: instruction}
+			if err := db.FirstOrCreate(&step, model.PanicGuideStepTranslation{PanicGuideID: guide.ID, Position: i + 1, Locale: "en"}).Error; err != nil {
+				return fmt.Errorf("seed step translation of panic guide %s: %w", koTitle, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// seedSampleData gives the user two weeks of vital signs, episodes and check-ins
+// ending at now, unless the user already has vital signs.
+func seedSampleData(db *gorm.DB, userID uint, now time.Time) error {
+	var count int64
+	if err := db.Model(&model.VitalSign{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	const days = 14
+	start := now.Truncate(time.Hour).AddDate(0, 0, -days)
+	var vitals []model.VitalSign
+	for t := start; t.Before(now); t = t.Add(time.Hour) {
+		// A gentle daily curve peaking in the late afternoon; offset is the
+		// distance in hours from 17:00, wrapping around midnight.
+		h := t.Hour()
+		offset := 17 - h
+		if offset < 0 {
+			offset = -offset
+		}
+		if offset > 12 {
+			offset = 24 - offset
+		}
+		vitals = append(vitals, model.VitalSign{
+			UserID:      userID,
+			HeartRate:   86 - offset*2 + h%3,
+			BreathRate:  14 + h%4,
+			StressLevel: 60 - offset*3 + h%5,
+			MeasuredAt:  t,
+		})
+	}
+	if err := db.CreateInBatches(vitals, 200).Error; err != nil {
+		return fmt.Errorf("seed vital signs: %w", err)
+	}
+
+	intensity := func(v int) *int { return &v }
+	episodes := []model.PanicEpisode{
+		{Source: model.EpisodeSourceSelfReported, StartedAt: start.AddDate(0, 0, 2).Add(8 * time.Hour), Intensity: intensity(6), Location: "commute", Triggers: []string{"crowds"}},
+		{Source: model.EpisodeSourceDetected, StartedAt: start.AddDate(0, 0, 5).Add(22 * time.Hour), Intensity: intensity(7), PeakHeartRate: intensity(132), Location: "home", Triggers: []string{"lack_of_sleep"}},
+		{Source: model.EpisodeSourceSelfReported, StartedAt: start.AddDate(0, 0, 9).Add(14 * time.Hour), Intensity: intensity(5), Location: "work", Triggers: []string{"work_stress", "caffeine"}},
+		{Source: model.EpisodeSourceSelfReported, StartedAt: start.AddDate(0, 0, 12).Add(8 * time.Hour), Intensity: intensity(4), Location: "commute", Triggers: []string{"crowds"}},
+	}
+	for i := range episodes {
+		ended := episodes[i].StartedAt.Add(15 * time.Minute)
+		episodes[i].UserID = userID
+		episodes[i].EndedAt = &ended
+	}
+	if err := db.Create(&episodes).Error; err != nil {
+		return fmt.Errorf("seed episodes: %w", err)
+	}
+
+	var checkIns []model.CheckIn
+	for d := 0; d < days; d++ {
+		nervousness, worry := d%3, (d+1)%3
+		sleep := 2 + d%4
+		checkIns = append(checkIns, model.CheckIn{
+			UserID:       userID,
+			Mood:         3 + d%3 - 1,
+			Nervousness:  nervousness,
+			Worry:        worry,
+			AnxietyScore: nervousness + worry,
+			SleepQuality: &sleep,
+			RespondedAt:  start.AddDate(0, 0, d).Add(21 * time.Hour),
+		})
+	}
+	if err := db.Create(&checkIns).Error; err != nil {
+		return fmt.Errorf("seed check-ins: %w", err)
+	}
+	logrus.Infof("Seeded %d vital signs, %d episodes and %d check-ins for user %d", len(vitals), len(episodes), len(checkIns), userID)
+	return nil
 }
@@ -0,0 +1,17 @@
+package dto
+
+// InterestRequest represents the JSON body for creating an interest or sub-interest.
+type InterestRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// PromptRequest represents the JSON body for creating or updating a chatbot prompt.
+type PromptRequest struct {
+	Name    string `json:"name" binding:"required,max=64"`
+	Content string `json:"content" binding:"required"`
+}
+
+// UpdateRoleRequest represents the JSON body for changing a user's role.
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user clinician content-editor admin"`
+}
@@ -1,18 +1,20 @@
 package dto
 
-// SignUpRequest represents the JSON body for user registration.
-type SignUpRequest struct {
-	Username      string `json:"username" binding:"required,min=2,max=32"`
-	Password      string `json:"password" binding:"required,min=6"`
-	PhoneNumber   string `json:"phone_number" binding:"required"`
-	SpeakingStyle string `json:"speaking_style" binding:"required"`
-	Tone          string `json:"tone" binding:"required"`
+// PersonaRequest represents the chatbot persona settings; omitted fields are left unchanged.
+type PersonaRequest struct {
+	Formality *string `json:"formality" binding:"omitempty,oneof=casual polite formal"`
+	Warmth    *string `json:"warmth" binding:"omitempty,oneof=reserved balanced warm"`
+	Humor     *string `json:"humor" binding:"omitempty,oneof=none light playful"`
+	Verbosity *string `json:"verbosity" binding:"omitempty,oneof=brief balanced detailed"`
+	Language  *string `json:"language" binding:"omitempty,max=16"`
+	Nickname  *string `json:"nickname" binding:"omitempty,max=32"`
 }
 
 // LoginRequest represents the JSON body for user login.
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username string         `json:"username" binding:"required"`
+	Password string         `json:"password" binding:"required"`
+	Device   *DeviceRequest `json:"device"` // registers the device and binds the tokens to it
 }
 
 // VerifyPhoneRequest represents the JSON body for phone verification.
@@ -25,3 +27,50 @@ type VerifyPhoneRequest struct {
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+// ForgotPasswordRequest represents the JSON body for requesting a password reset code.
+type ForgotPasswordRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required,max=32"`
+}
+
+// VerifyPasswordResetRequest represents the JSON body for exchanging a reset code for a reset token.
+type VerifyPasswordResetRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required,max=32"`
+	Code        string `json:"code" binding:"required,len=6"`
+}
+
+// ResetPasswordRequest represents the JSON body for setting a new password with a reset token.
+type ResetPasswordRequest struct {
+	ResetToken  string `json:"reset_token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest represents the JSON body for requesting account deletion.
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateProfileRequest represents the JSON body for a partial profile update.
+// Omitted fields are left unchanged.
+type UpdateProfileRequest struct {
+	Persona     *PersonaRequest `json:"persona"`
+	DisplayName *string         `json:"display_name" binding:"omitempty,max=64"`
+	Timezone    *string         `json:"timezone" binding:"omitempty,max=64"`
+	Locale      *string         `json:"locale" binding:"omitempty,max=16"`
+}
+
+// ChangePasswordRequest represents the JSON body for changing the password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePhoneRequest represents the JSON body for changing the phone number.
+type ChangePhoneRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required,max=32"`
+}
+
+// VerifyMyPhoneRequest represents the JSON body for confirming the phone number of the signed-in user.
+type VerifyMyPhoneRequest struct {
+	Code string `json:"code" binding:"required,len=6"`
+}
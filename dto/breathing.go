@@ -0,0 +1,15 @@
+package dto
+
+// BreathingPhaseRequest represents a single phase of a custom breathing pattern.
+type BreathingPhaseRequest struct {
+	Cue     string `json:"cue" binding:"required,oneof=inhale hold exhale"`
+	Seconds int    `json:"seconds" binding:"required,min=1,max=30"`
+}
+
+// StartBreathingRequest represents the JSON body for starting a guided breathing session.
+type StartBreathingRequest struct {
+	PanicGuideID uint                    `json:"panic_guide_id" binding:"required"`
+	Pattern      string                  `json:"pattern" binding:"required,oneof=box 4-7-8 custom"`
+	Cycles       int                     `json:"cycles" binding:"required,min=1,max=20"`
+	Phases       []BreathingPhaseRequest `json:"phases" binding:"omitempty,dive"`
+}
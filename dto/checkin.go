@@ -0,0 +1,18 @@
+package dto
+
+// CheckInScheduleRequest represents the JSON body replacing the daily check-in times.
+type CheckInScheduleRequest struct {
+	Times   []string `json:"times" binding:"max=6,dive,len=5"` // HH:MM in the user's time zone
+	Enabled *bool    `json:"enabled"`                          // defaults to true
+}
+
+// CheckInRequest represents the JSON body of a mood and anxiety check-in.
+// Nervousness and worry are the two GAD-2 items, scored 0-3.
+type CheckInRequest struct {
+	ScheduleID   *uint  `json:"schedule_id"` // the prompt being answered, if any
+	Mood         *int   `json:"mood" binding:"required,min=1,max=5"`
+	Nervousness  *int   `json:"nervousness" binding:"required,min=0,max=3"`
+	Worry        *int   `json:"worry" binding:"required,min=0,max=3"`
+	SleepQuality *int   `json:"sleep_quality" binding:"omitempty,min=1,max=5"`
+	Note         string `json:"note" binding:"max=2000"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// DeviceRequest represents the JSON description of a client device, sent on sign-in or registration.
+type DeviceRequest struct {
+	InstallationID string  `json:"installation_id" binding:"required,max=64"`
+	Type           string  `json:"type" binding:"required,oneof=phone tablet watch web other"`
+	Name           string  `json:"name" binding:"max=64"`
+	Model          string  `json:"model" binding:"max=64"`
+	OS             string  `json:"os" binding:"max=64"`
+	PushToken      *string `json:"push_token" binding:"omitempty,max=512"`
+	PushProvider   string  `json:"push_provider" binding:"omitempty,oneof=fcm apns"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+// EmergencyContactRequest represents the JSON body for creating or updating an emergency contact.
+type EmergencyContactRequest struct {
+	Name             string `json:"name" binding:"required,max=100"`
+	PhoneNumber      string `json:"phone_number" binding:"required,max=32"`
+	Relationship     string `json:"relationship" binding:"max=50"`
+	ConsentConfirmed bool   `json:"consent_confirmed"`
+}
+
+// SOSRequest represents the JSON body of an SOS request. The location is optional.
+type SOSRequest struct {
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
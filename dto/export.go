@@ -0,0 +1,9 @@
+package dto
+
+// ExportRequest represents the JSON body for requesting a health report export.
+// From and To accept RFC 3339 timestamps or YYYY-MM-DD dates; the default period is the last 30 days.
+type ExportRequest struct {
+	Format string `json:"format" binding:"required,oneof=csv fhir pdf"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
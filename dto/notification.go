@@ -0,0 +1,9 @@
+package dto
+
+// NotificationPreferenceRequest represents the JSON body for a partial update of notification preferences.
+type NotificationPreferenceRequest struct {
+	DisabledCategories *[]string `json:"disabled_categories" binding:"omitempty,max=10"`
+	QuietHoursEnabled  *bool     `json:"quiet_hours_enabled"`
+	QuietHoursStart    *string   `json:"quiet_hours_start" binding:"omitempty,len=5"`
+	QuietHoursEnd      *string   `json:"quiet_hours_end" binding:"omitempty,len=5"`
+}
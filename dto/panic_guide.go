@@ -1,18 +1,54 @@
 package dto
 
+// PanicGuideStepRequest represents a single step of a panic guide in create or update requests.
+type PanicGuideStepRequest struct {
+	Type            string `json:"type" binding:"required,oneof=breathing grounding_54321 muscle_relaxation cognitive_reframing general"`
+	Instruction     string `json:"instruction" binding:"required"`
+	DurationSeconds int    `json:"duration_seconds" binding:"min=0,max=3600"`
+	AudioURL        string `json:"audio_url" binding:"omitempty,url,max=512"`
+	ImageURL        string `json:"image_url" binding:"omitempty,url,max=512"`
+}
+
 // PanicGuideRequest represents the JSON body for creating or updating a panic guide.
+// Steps are stored in the given order.
 type PanicGuideRequest struct {
-	Title       string `json:"title" binding:"required,min=1,max=128"`
-	Description string `json:"description" binding:"required"`
+	Title       string                  `json:"title" binding:"required,min=1,max=128"`
+	Description string                  `json:"description" binding:"required"`
+	Locale      string                  `json:"locale" binding:"omitempty,max=16"`
+	Difficulty  string                  `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	Steps       []PanicGuideStepRequest `json:"steps" binding:"omitempty,dive"`
+	InterestIDs []uint                  `json:"interest_ids"`
 }
 
-// BookmarkRequest represents the JSON body or query parameters for bookmarking a panic guide.
+// BookmarkRequest represents the optional JSON body for bookmarking a panic guide.
 type BookmarkRequest struct {
-	UserID       uint `json:"user_id" binding:"required"`
-	PanicGuideID uint `json:"panic_guide_id" binding:"required"`
+	Folder string `json:"folder" binding:"max=64"`
+	Pinned bool   `json:"pinned"`
+}
+
+// UpdateBookmarkRequest represents the JSON body for moving, reordering or pinning a bookmark.
+type UpdateBookmarkRequest struct {
+	Folder   *string `json:"folder" binding:"omitempty,max=64"`
+	Position *int    `json:"position" binding:"omitempty,min=1"`
+	Pinned   *bool   `json:"pinned"`
+}
+
+// ReorderBookmarksRequest represents the JSON body for ordering the bookmarks of a folder.
+type ReorderBookmarksRequest struct {
+	Folder        string `json:"folder" binding:"max=64"`
+	PanicGuideIDs []uint `json:"panic_guide_ids" binding:"required,min=1"`
+}
+
+// PanicGuideStepTranslationRequest represents the translation of a single step, matched by position.
+type PanicGuideStepTranslationRequest struct {
+	Position    int    `json:"position" binding:"required,min=1"`
+	Instruction string `json:"instruction" binding:"required"`
+	AudioURL    string `json:"audio_url" binding:"omitempty,url,max=512"`
 }
 
-// ListBookmarksRequest represents the JSON body or query parameters for listing a user's bookmarks.
-type ListBookmarksRequest struct {
-	UserID uint `json:"user_id" binding:"required"`
+// PanicGuideTranslationRequest represents the JSON body for adding or replacing a guide translation.
+type PanicGuideTranslationRequest struct {
+	Title       string                             `json:"title" binding:"required,min=1,max=128"`
+	Description string                             `json:"description" binding:"required"`
+	Steps       []PanicGuideStepTranslationRequest `json:"steps" binding:"omitempty,dive"`
 }
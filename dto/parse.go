@@ -1,8 +1,48 @@
 package dto
 
-import "strconv"
+import (
+	"errors"
+	"strconv"
+	"time"
+)
 
 // ParseUint converts a string to uint64, returning an error if invalid.
 func ParseUint(s string) (uint64, error) {
 	return strconv.ParseUint(s, 10, 64)
 }
+
+// ParseTime parses an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC).
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// ParseTimeRange parses optional from/to values. Missing bounds default to now
+// and defaultDays before to. A date-only to includes that whole day.
+func ParseTimeRange(from, to string, defaultDays int) (time.Time, time.Time, error) {
+	end := time.Now()
+	if to != "" {
+		t, err := ParseTime(to)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to")
+		}
+		if len(to) == len("2006-01-02") {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -defaultDays)
+	if from != "" {
+		t, err := ParseTime(from)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from")
+		}
+		start = t
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,7 @@
+package dto
+
+// QuestionnaireSubmitRequest represents the JSON body of a completed questionnaire.
+type QuestionnaireSubmitRequest struct {
+	Version int   `json:"version" binding:"min=0"` // 0 or omitted for the newest version
+	Answers []int `json:"answers" binding:"required,min=1,max=50"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// CreateShareRequest represents the JSON body for issuing a clinician invitation.
+type CreateShareRequest struct {
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=vitals episodes chat_summaries questionnaires"`
+}
+
+// AcceptShareRequest represents the JSON body for accepting an invitation code.
+type AcceptShareRequest struct {
+	Code string `json:"code" binding:"required,max=16"`
+}
+
+// EpisodeRequest represents the JSON body for self-reporting a panic episode.
+type EpisodeRequest struct {
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Intensity *int       `json:"intensity" binding:"omitempty,min=1,max=10"`
+	Notes     string     `json:"notes" binding:"max=2000"`
+	Location  string     `json:"location" binding:"max=32"`
+	Triggers  []string   `json:"triggers" binding:"max=10"`
+}
+
+// EpisodeUpdateRequest represents the JSON body for a partial update of a panic episode.
+type EpisodeUpdateRequest struct {
+	Intensity *int      `json:"intensity" binding:"omitempty,min=1,max=10"`
+	Notes     *string   `json:"notes" binding:"omitempty,max=2000"`
+	Location  *string   `json:"location" binding:"omitempty,max=32"`
+	Triggers  *[]string `json:"triggers" binding:"omitempty,max=10"`
+}
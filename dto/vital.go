@@ -2,8 +2,9 @@ package dto
 
 // VitalRequest represents the JSON body for registering a vital sign.
 type VitalRequest struct {
-	UserID      uint `json:"user_id" binding:"required"`
-	HeartRate   int  `json:"heart_rate" binding:"required"`
-	BreathRate  int  `json:"breath_rate" binding:"required"`
-	StressLevel int  `json:"stress_level" binding:"required"`
+	UserID      uint  `json:"user_id"`   // ignored for authenticated requests
+	DeviceID    *uint `json:"device_id"` // source device, e.g. a watch relayed through the phone; defaults to the token's device
+	HeartRate   int   `json:"heart_rate" binding:"required"`
+	BreathRate  int   `json:"breath_rate" binding:"required"`
+	StressLevel int   `json:"stress_level" binding:"required"`
 }
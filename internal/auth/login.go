@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCredentials is returned for an unknown username, a wrong password
+// or a locked account alike, so callers cannot tell which one applied.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// dummyHash is compared against when the username does not exist so that
+// unknown and existing usernames take about the same time to reject.
+var dummyHash, _ = utils.HashPassword("not-a-real-password")
+
+// LockoutPolicy controls how failed sign-ins lock an account.
+type LockoutPolicy struct {
+	Threshold int           // failures before the first lock
+	Base      time.Duration // first lock; doubled for every further failure
+	Max       time.Duration
+}
+
+// LoadLockoutPolicy reads LOGIN_LOCKOUT_THRESHOLD (default 5), LOGIN_LOCKOUT_BASE
+// (default 1m) and LOGIN_LOCKOUT_MAX (default 1h).
+func LoadLockoutPolicy() LockoutPolicy {
+	c := config.Get().Login
+	return LockoutPolicy{Threshold: c.LockoutThreshold, Base: c.LockoutBase, Max: c.LockoutMax}
+}
+
+// LockDuration returns how long the account is locked after the given number of
+// consecutive failures, or zero if it stays unlocked.
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	d := float64(p.Base) * math.Pow(2, float64(failures-p.Threshold))
+	if d > float64(p.Max) {
+		return p.Max
+	}
+	return time.Duration(d)
+}
+
+// Authenticate checks the username and password, applying the lockout policy and
+// recording the attempt in the login history of existing accounts.
+func (s *AuthService) Authenticate(username, password, ip, userAgent string) (*model.User, error) {
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		utils.CheckPasswordHash(password, dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		s.recordLogin(user.ID, model.LoginResultLocked, ip, userAgent)
+		return nil, ErrInvalidCredentials
+	}
+
+	if !utils.CheckPasswordHash(password, user.PasswordHash) {
+		if err := s.registerFailure(user.ID); err != nil {
+			return nil, err
+		}
+		s.recordLogin(user.ID, model.LoginResultBadPassword, ip, userAgent)
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		if err := s.db.Model(&model.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"failed_login_count": 0, "locked_until": nil}).Error; err != nil {
+			return nil, err
+		}
+		user.FailedLoginCount, user.LockedUntil = 0, nil
+	}
+	s.recordLogin(user.ID, model.LoginResultSuccess, ip, userAgent)
+	return user, nil
+}
+
+// registerFailure increments the failure counter and locks the account once the
+// lockout threshold is reached.
+func (s *AuthService) registerFailure(userID uint) error {
+	policy := LoadLockoutPolicy()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_login_count").First(&user, userID).Error; err != nil {
+			return err
+		}
+		failures := user.FailedLoginCount + 1
+		updates := map[string]interface{}{"failed_login_count": failures}
+		if d := policy.LockDuration(failures); d > 0 {
+			updates["locked_until"] = time.Now().Add(d)
+			logrus.WithFields(logrus.Fields{"userID": userID, "failures": failures, "lock": d}).
+				Warn("Account locked after failed sign-ins")
+		}
+		return tx.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
+	})
+}
+
+func (s *AuthService) recordLogin(userID uint, result, ip, userAgent string) {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	entry := &model.LoginHistory{UserID: userID, Result: result, IP: ip, UserAgent: userAgent}
+	if err := s.db.Create(entry).Error; err != nil {
+		logrus.WithError(err).WithField("userID", userID).Error("Failed to record login history")
+	}
+}
+
+// LoginHistory returns the most recent sign-in attempts on the user's account.
+func (s *AuthService) LoginHistory(userID uint, limit int) ([]model.LoginHistory, error) {
+	var history []model.LoginHistory
+	err := s.db.Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&history).Error
+	return history, err
+}
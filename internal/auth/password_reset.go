@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidResetCode is returned when the phone number or reset code does not match.
+	ErrInvalidResetCode = errors.New("invalid or expired code")
+	// ErrInvalidResetToken is returned for an unknown, used or expired reset token.
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+// PasswordResetTokenTTL returns how long a reset token stays valid after the OTP
+// was verified, configured with PASSWORD_RESET_TOKEN_TTL (default 15m).
+func PasswordResetTokenTTL() time.Duration {
+	return config.Get().Password.ResetTokenTTL
+}
+
+func (s *AuthService) getUserByPhone(phone string) (*model.User, error) {
+	var user model.User
+	if err := s.db.Where("phone_number = ?", phone).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RequestPasswordReset sends a reset code to the phone number if it belongs to a
+// user. Unknown numbers are silently ignored and the SMS is sent by a background
+// job, so callers cannot tell whether a number is registered.
+func (s *AuthService) RequestPasswordReset(phone string) error {
+	user, err := s.getUserByPhone(phone)
+	if err != nil || user == nil {
+		return err
+	}
+	return s.SendOTP(OTPPurposePasswordReset, user.ID, user.PhoneNumber, "Your password reset code is %s")
+}
+
+// VerifyPasswordReset checks the reset code sent to the phone number and returns
+// a single-use reset token. Earlier reset tokens of the user are discarded.
+func (s *AuthService) VerifyPasswordReset(phone, code string) (string, time.Time, error) {
+	user, err := s.getUserByPhone(phone)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if user == nil || !s.ValidateOTPFor(OTPPurposePasswordReset, user.ID, code) {
+		return "", time.Time{}, ErrInvalidResetCode
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(b)
+	expiresAt := time.Now().Add(PasswordResetTokenTTL())
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashResetToken(token),
+			ExpiresAt: expiresAt,
+		}).Error
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ResetPassword consumes the reset token, sets the new password and revokes
+// every refresh token of the user. It returns the user's ID. A password that
+// fails the password policy leaves the token unused.
+func (s *AuthService) ResetPassword(token, newPassword string) (uint, error) {
+	var userID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var reset model.PasswordResetToken
+		if err := tx.Where("token_hash = ? AND expires_at > ?", hashResetToken(token), time.Now()).
+			First(&reset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		// Deleting first makes a concurrent reset with the same token fail.
+		res := tx.Delete(&model.PasswordResetToken{}, reset.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		if err := setPassword(tx, reset.UserID, newPassword); err != nil {
+			return err
+		}
+		userID = reset.UserID
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	logrus.WithField("userID", userID).Info("Password reset; all refresh tokens revoked")
+	return userID, nil
+}
+
+// SetPassword replaces the password of a user without a reset token, as done by
+// an administrator. Like ResetPassword it lifts a sign-in lockout and revokes
+// every refresh token of the user.
+func (s *AuthService) SetPassword(userID uint, newPassword string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, userID, newPassword)
+	})
+	if err != nil {
+		return err
+	}
+	logrus.WithField("userID", userID).Info("Password set by an administrator; all refresh tokens revoked")
+	return nil
+}
+
+func setPassword(tx *gorm.DB, userID uint, newPassword string) error {
+	var user model.User
+	if err := tx.Select("id", "username").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if err := utils.ValidatePassword(newPassword, user.Username); err != nil {
+		return err
+	}
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	// A new password also lifts a sign-in lockout and signs out every session.
+	now := time.Now()
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password_hash":      hash,
+		"failed_login_count": 0,
+		"locked_until":       nil,
+		"tokens_valid_after": now,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// JobPurgeResetTokens is the background job type deleting expired reset tokens.
+const JobPurgeResetTokens = "auth.purge_reset_tokens"
+
+// PurgeExpiredResetTokens deletes reset tokens that can no longer be used.
+func (s *AuthService) PurgeExpiredResetTokens() (int64, error) {
+	res := s.db.Where("expires_at <= ?", time.Now()).Delete(&model.PasswordResetToken{})
+	return res.RowsAffected, res.Error
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
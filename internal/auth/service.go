@@ -2,24 +2,20 @@ package auth
 
 import (
 	"errors"
-	"os"
-	"time"
 
+	"ps_backend/internal/jobs"
 	"ps_backend/model"
 
-	jwtmw "ps_backend/pkg/middleware"
-
-	jwt "github.com/golang-jwt/jwt/v5"
-
 	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	queue *jobs.Queue
 }
 
 func NewAuthService(db *gorm.DB) *AuthService {
-	return &AuthService{db: db}
+	return &AuthService{db: db, queue: jobs.NewQueue(db)}
 }
 
 func (s *AuthService) UserExistsByUsername(username string) (bool, error) {
@@ -53,56 +49,11 @@ func (s *AuthService) GetUserByUsername(username string) (*model.User, error) {
 	return &user, nil
 }
 
+// ValidateOTP checks the OTP code last sent to the user and consumes it on success.
 func (s *AuthService) ValidateOTP(userID uint, code string) bool {
-	// TODO: Implement OTP validation logic or import the correct package
-	return false
+	return s.ValidateOTPFor(OTPPurposeVerifyPhone, userID, code)
 }
-func (s *AuthService) MarkPhoneVerified(userID uint) error {
-	return s.db.Model(&model.User{}).Where("id = ?", userID).Update("phone_verified", true).Error
-}
-
-func (s *AuthService) RefreshJWT(refreshToken string) (string, error) {
-	refreshSecret := os.Getenv("REFRESH_SECRET")
-	if refreshSecret == "" {
-		return "", errors.New("refresh secret not configured")
-	}
-
-	token, err := jwt.ParseWithClaims(refreshToken, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(refreshSecret), nil
-	})
-	if err != nil || !token.Valid {
-		return "", errors.New("invalid refresh token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", errors.New("invalid token claims")
-	}
 
-	// Check expiration
-	if exp, ok := claims["exp"].(float64); ok {
-		if int64(exp) < time.Now().Unix() {
-			return "", errors.New("refresh token expired")
-		}
-	} else {
-		return "", errors.New("invalid exp claim")
-	}
-
-	// Extract user ID from claims
-	userIDFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		return "", errors.New("invalid user_id claim")
-	}
-	userID := uint(userIDFloat)
-
-	username, ok := claims["username"].(string)
-	if !ok {
-		return "", errors.New("invalid username claim")
-	}
-
-	accessToken, _, err := jwtmw.GenerateToken(userID, username)
-	if err != nil {
-		return "", err
-	}
-	return accessToken, nil
+func (s *AuthService) MarkPhoneVerified(userID uint) error {
+	return s.db.Model(&model.User{}).Where("id = ?", userID).Update("verified", true).Error
 }
@@ -2,58 +2,148 @@ package auth
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"net/http"
-	"os"
-	"sync"
 	"time"
 
+	"ps_backend/internal/jobs"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/metrics"
+	"ps_backend/pkg/utils"
+
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type otpEntry struct {
-	code      string
-	expiresAt time.Time
+// JobSendSMS is the background job type delivering a queued text message.
+const JobSendSMS = "sms.send"
+
+// SMSPayload is the payload of a JobSendSMS job.
+type SMSPayload struct {
+	Phone   string `json:"phone"`
+	Message string `json:"message"`
+	Purpose string `json:"purpose,omitempty"` // OTP purpose, for the otp_sends_total metric
 }
 
-var (
-	otpStore      = make(map[uint]otpEntry)
-	otpStoreMutex sync.RWMutex
+// otpSends counts OTP deliveries by purpose and outcome: "sent", "failed" for
+// an attempt that failed and will be retried unless it was the last, and
+// "not_queued" when the job could not be queued.
+var otpSends = metrics.NewCounterVec("otp_sends_total", "OTP SMS deliveries by purpose and outcome.", "purpose", "outcome")
+
+// OTP purposes. A code is only accepted for the purpose it was issued for.
+const (
+	OTPPurposeVerifyPhone   = "verify_phone"
+	OTPPurposePasswordReset = "password_reset"
+	OTPPurposeChangePhone   = "change_phone"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-	// Optional: background cleanup goroutine
-	go func() {
-		for {
-			time.Sleep(1 * time.Minute)
-			now := time.Now()
-			otpStoreMutex.Lock()
-			for userID, entry := range otpStore {
-				if entry.expiresAt.Before(now) {
-					delete(otpStore, userID)
+// maxOTPAttempts is the number of wrong guesses after which a code is discarded.
+const maxOTPAttempts = 5
+
+// otpTTL is how long a code stays valid.
+const otpTTL = 5 * time.Minute
+
+// JobPurgeOTPCodes is the background job type deleting expired OTP codes.
+const JobPurgeOTPCodes = "auth.purge_otp_codes"
+
+// GenerateAndSendOTP generates a 6-digit phone verification code, stores it, and queues it for SMS delivery.
+func (s *AuthService) GenerateAndSendOTP(userID uint, phone string) error {
+	return s.SendOTP(OTPPurposeVerifyPhone, userID, phone, "Your verification code is %s")
+}
+
+// SendOTP generates a 6-digit code for the purpose, replacing any earlier one,
+// and queues it for SMS delivery. The message must contain a single %s for the code.
+func (s *AuthService) SendOTP(purpose string, userID uint, phone, message string) error {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	entry := &model.OTPCode{
+		UserID:    userID,
+		Purpose:   purpose,
+		CodeHash:  hashOTP(code),
+		Phone:     phone,
+		ExpiresAt: time.Now().Add(otpTTL),
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "purpose"}},
+		DoUpdates: clause.AssignmentColumns([]string{"code_hash", "phone", "attempts", "expires_at", "created_at"}),
+	}).Create(entry).Error; err != nil {
+		return err
+	}
+
+	if _, err := s.queue.Enqueue(JobSendSMS, SMSPayload{Phone: phone, Message: fmt.Sprintf(message, code), Purpose: purpose}); err != nil {
+		otpSends.Inc(purpose, "not_queued")
+		logrus.WithFields(logrus.Fields{
+			"userID":  userID,
+			"phone":   utils.MaskPhone(phone),
+			"purpose": purpose,
+		}).WithError(err).Error("Failed to queue OTP SMS")
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"userID":  userID,
+		"phone":   utils.MaskPhone(phone),
+		"purpose": purpose,
+	}).Info("OTP queued")
+	return nil
+}
+
+// RegisterJobs registers the SMS delivery job handler and the daily purge of
+// expired password reset tokens. SMS jobs contain one-time codes, so they are
+// deleted once delivered, redacted when they die, and retried only briefly, as
+// codes expire after 5 minutes.
+func (s *AuthService) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobSendSMS, jobs.Options{MaxAttempts: 3, Backoff: 10 * time.Second, Timeout: 30 * time.Second, DeleteOnSuccess: true, RedactDead: true},
+		func(ctx context.Context, p SMSPayload) error {
+			err := SendSMS(p.Phone, p.Message)
+			if p.Purpose != "" {
+				outcome := "sent"
+				if err != nil {
+					outcome = "failed"
 				}
+				otpSends.Inc(p.Purpose, outcome)
 			}
-			otpStoreMutex.Unlock()
+			return err
+		})
+	jobs.Handle(r, JobPurgeResetTokens, jobs.Options{MaxAttempts: 1}, func(ctx context.Context, _ struct{}) error {
+		n, err := s.PurgeExpiredResetTokens()
+		if n > 0 {
+			logrus.Infof("Purged %d expired password reset tokens", n)
 		}
-	}()
+		return err
+	})
+	r.MustSchedule("password-reset-token-purge", "40 4 * * *", JobPurgeResetTokens)
+	jobs.Handle(r, JobPurgeOTPCodes, jobs.Options{MaxAttempts: 1}, func(ctx context.Context, _ struct{}) error {
+		_, err := s.PurgeExpiredOTPCodes()
+		return err
+	})
+	r.MustSchedule("otp-code-purge", "20 * * * *", JobPurgeOTPCodes)
 }
 
-// GenerateAndSendOTP generates a 6-digit code, stores it, and sends it via SMS.
-func GenerateAndSendOTP(userID uint, phone string) error {
-	code := fmt.Sprintf("%06d", rand.Intn(1000000))
-	expiry := time.Now().Add(5 * time.Minute)
-
-	otpStoreMutex.Lock()
-	otpStore[userID] = otpEntry{code: code, expiresAt: expiry}
-	otpStoreMutex.Unlock()
+// PurgeExpiredOTPCodes deletes codes that can no longer be used.
+func (s *AuthService) PurgeExpiredOTPCodes() (int64, error) {
+	res := s.db.Where("expires_at <= ?", time.Now()).Delete(&model.OTPCode{})
+	return res.RowsAffected, res.Error
+}
 
+// SendSMS delivers a text message through the configured SMS API
+// (SMS_API_URL and SMS_API_KEY).
+func SendSMS(phone, message string) error {
 	payload := map[string]string{
 		"to":      phone,
-		"message": fmt.Sprintf("Your verification code is %s", code),
+		"message": message,
 	}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -61,10 +151,10 @@ func GenerateAndSendOTP(userID uint, phone string) error {
 		return err
 	}
 
-	apiURL := os.Getenv("SMS_API_URL")
-	apiKey := os.Getenv("SMS_API_KEY")
+	sms := config.Get().SMS
+	apiURL, apiKey := sms.URL, sms.Key
 	if apiURL == "" || apiKey == "" {
-		logrus.Error("Missing SMS_API_URL or SMS_API_KEY environment variable")
+		logrus.Error("SMS_API_URL or SMS_API_KEY is not configured")
 		return errors.New("missing SMS API configuration")
 	}
 
@@ -79,41 +169,85 @@ func GenerateAndSendOTP(userID uint, phone string) error {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to send SMS OTP")
+		logrus.WithError(err).Error("Failed to send SMS")
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		logrus.WithFields(logrus.Fields{
-			"userID": userID,
-			"phone":  phone,
-		}).Info("OTP sent successfully")
-		return nil
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send SMS, status: %s", resp.Status)
 	}
-	logrus.WithFields(logrus.Fields{
-		"userID":     userID,
-		"phone":      phone,
-		"statusCode": resp.StatusCode,
-	}).Error("Failed to send OTP SMS")
-	return fmt.Errorf("failed to send OTP SMS, status: %s", resp.Status)
+	return nil
 }
 
-// ValidateOTP checks if the code for userID is valid and removes it on success.
-func ValidateOTP(userID uint, code string) bool {
-	otpStoreMutex.Lock()
-	defer otpStoreMutex.Unlock()
-	entry, exists := otpStore[userID]
-	if !exists {
-		return false
-	}
-	if time.Now().After(entry.expiresAt) {
-		delete(otpStore, userID)
-		return false
+// ValidateOTPFor checks the code issued to userID for the purpose and removes it
+// on success. A code is discarded after too many wrong guesses.
+func (s *AuthService) ValidateOTPFor(purpose string, userID uint, code string) bool {
+	_, ok := s.validateOTP(purpose, userID, code)
+	return ok
+}
+
+// validateOTP is ValidateOTPFor that also returns the number the code was sent
+// to. The code's row is locked, so concurrent guesses are counted one by one
+// whichever server they reach.
+func (s *AuthService) validateOTP(purpose string, userID uint, code string) (string, bool) {
+	var phone string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var entry model.OTPCode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND purpose = ?", userID, purpose).First(&entry).Error; err != nil {
+			return err
+		}
+		if time.Now().After(entry.ExpiresAt) {
+			return tx.Delete(&entry).Error
+		}
+		if subtle.ConstantTimeCompare([]byte(entry.CodeHash), []byte(hashOTP(code))) != 1 {
+			if entry.Attempts+1 >= maxOTPAttempts {
+				return tx.Delete(&entry).Error
+			}
+			return tx.Model(&entry).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+		if err := tx.Delete(&entry).Error; err != nil {
+			return err
+		}
+		phone = entry.Phone
+		return nil
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.WithFields(logrus.Fields{"userID": userID, "purpose": purpose}).WithError(err).Error("Failed to check OTP")
 	}
-	if entry.code != code {
-		return false
+	return phone, err == nil && phone != ""
+}
+
+func hashOTP(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendPhoneChangeOTP sends a code to the new number of a phone change. The
+// number is not checked here, so the response to the user does not reveal
+// whether it is registered; the change is applied once the code is confirmed.
+func (s *AuthService) SendPhoneChangeOTP(userID uint, phone string) error {
+	return s.SendOTP(OTPPurposeChangePhone, userID, phone, "Your verification code is %s")
+}
+
+// PendingPhoneChange returns the number of an unexpired phone change of the user.
+func (s *AuthService) PendingPhoneChange(userID uint) (string, bool) {
+	var entry model.OTPCode
+	err := s.db.Select("phone").
+		Where("user_id = ? AND purpose = ? AND expires_at > ?", userID, OTPPurposeChangePhone, time.Now()).
+		First(&entry).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.WithField("userID", userID).WithError(err).Error("Failed to load pending phone change")
+		}
+		return "", false
 	}
-	delete(otpStore, userID)
-	return true
+	return entry.Phone, true
+}
+
+// ValidatePhoneChangeOTP checks the code of a pending phone change, consumes it
+// on success and returns the new number it was sent to.
+func (s *AuthService) ValidatePhoneChangeOTP(userID uint, code string) (string, bool) {
+	return s.validateOTP(OTPPurposeChangePhone, userID, code)
 }
@@ -0,0 +1,97 @@
+package breathing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Breathing cues pushed to the client.
+const (
+	CueInhale = "inhale"
+	CueHold   = "hold"
+	CueExhale = "exhale"
+)
+
+// Phase is a single timed cue of a breathing cycle.
+type Phase struct {
+	Cue     string `json:"cue"`
+	Seconds int    `json:"seconds"`
+}
+
+// Pattern is a named breathing cycle.
+type Pattern struct {
+	Name   string  `json:"name"`
+	Phases []Phase `json:"phases"`
+}
+
+// CustomPattern is the name used for client-defined phase lists.
+const CustomPattern = "custom"
+
+// Patterns lists the built-in breathing patterns.
+var Patterns = map[string]Pattern{
+	"box": {Name: "box", Phases: []Phase{
+		{Cue: CueInhale, Seconds: 4},
+		{Cue: CueHold, Seconds: 4},
+		{Cue: CueExhale, Seconds: 4},
+		{Cue: CueHold, Seconds: 4},
+	}},
+	"4-7-8": {Name: "4-7-8", Phases: []Phase{
+		{Cue: CueInhale, Seconds: 4},
+		{Cue: CueHold, Seconds: 7},
+		{Cue: CueExhale, Seconds: 8},
+	}},
+}
+
+// ResolvePattern returns the built-in pattern with the given name, or a custom
+// pattern built from the given phases.
+func ResolvePattern(name string, phases []Phase) (Pattern, error) {
+	if name != CustomPattern {
+		pattern, ok := Patterns[name]
+		if !ok {
+			return Pattern{}, fmt.Errorf("%w: unknown breathing pattern %q", ErrInvalidSession, name)
+		}
+		return pattern, nil
+	}
+	if len(phases) == 0 {
+		return Pattern{}, fmt.Errorf("%w: custom pattern requires phases", ErrInvalidSession)
+	}
+	for _, phase := range phases {
+		switch phase.Cue {
+		case CueInhale, CueHold, CueExhale:
+		default:
+			return Pattern{}, fmt.Errorf("%w: unknown breathing cue %q", ErrInvalidSession, phase.Cue)
+		}
+		if phase.Seconds < 1 || phase.Seconds > 30 {
+			return Pattern{}, fmt.Errorf("%w: phase duration must be between 1 and 30 seconds", ErrInvalidSession)
+		}
+	}
+	return Pattern{Name: CustomPattern, Phases: phases}, nil
+}
+
+// Spec renders the phases as "cue:seconds" pairs for storage.
+func (p Pattern) Spec() string {
+	parts := make([]string, len(p.Phases))
+	for i, phase := range p.Phases {
+		parts[i] = fmt.Sprintf("%s:%d", phase.Cue, phase.Seconds)
+	}
+	return strings.Join(parts, ",")
+}
+
+// cycleDuration returns how long one cycle of a stored phase spec takes.
+func cycleDuration(spec string) (time.Duration, error) {
+	var total time.Duration
+	for _, part := range strings.Split(spec, ",") {
+		_, seconds, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid breathing phase %q", part)
+		}
+		n, err := strconv.Atoi(seconds)
+		if err != nil {
+			return 0, fmt.Errorf("invalid breathing phase %q", part)
+		}
+		total += time.Duration(n) * time.Second
+	}
+	return total, nil
+}
@@ -0,0 +1,135 @@
+package breathing
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSessionNotFound is returned when a breathing session does not exist for the user.
+var ErrSessionNotFound = errors.New("breathing session not found")
+
+// Repository provides database access for breathing sessions.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new breathing session Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GuideExists reports whether a panic guide with the given ID exists.
+func (r *Repository) GuideExists(guideID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.PanicGuide{}).Where("id = ?", guideID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateUserSession inserts session unless admit, given a repository in the
+// same transaction and the user's running sessions, rejects it. The user's row
+// is locked, so concurrent starts of one user are admitted one at a time.
+func (r *Repository) CreateUserSession(session *model.BreathingSession, admit func(repo *Repository, running []model.BreathingSession) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&model.User{}, session.UserID).Error; err != nil {
+			return err
+		}
+		repo := NewRepository(tx)
+		running, err := repo.GetRunningSessions(session.UserID)
+		if err != nil {
+			return err
+		}
+		if err := admit(repo, running); err != nil {
+			return err
+		}
+		return tx.Create(session).Error
+	})
+}
+
+// GetRunningSessions returns the sessions still marked running.
+// When userID is non-zero only that user's sessions are returned.
+func (r *Repository) GetRunningSessions(userID uint) ([]model.BreathingSession, error) {
+	var sessions []model.BreathingSession
+	q := r.db.Where("status = ?", model.SessionStatusRunning)
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	if err := q.Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// InterruptSession marks a session interrupted if it is still running.
+func (r *Repository) InterruptSession(sessionID uint, now time.Time) (bool, error) {
+	res := r.db.Model(&model.BreathingSession{}).
+		Where("id = ? AND status = ?", sessionID, model.SessionStatusRunning).
+		Updates(map[string]interface{}{"status": model.SessionStatusInterrupted, "ended_at": now})
+	return res.RowsAffected > 0, res.Error
+}
+
+// SaveSession persists changes of a breathing session.
+func (r *Repository) SaveSession(session *model.BreathingSession) error {
+	return r.db.Save(session).Error
+}
+
+// GetUserSession returns a session owned by the given user.
+func (r *Repository) GetUserSession(userID, sessionID uint) (*model.BreathingSession, error) {
+	var session model.BreathingSession
+	err := r.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetUserSessions returns the sessions of a user, newest first.
+func (r *Repository) GetUserSessions(userID uint) ([]model.BreathingSession, error) {
+	var sessions []model.BreathingSession
+	if err := r.db.Where("user_id = ?", userID).Order("started_at desc").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Effectiveness aggregates session outcomes of a guide and pattern.
+type Effectiveness struct {
+	PanicGuideID     uint    `json:"panic_guide_id"`
+	Pattern          string  `json:"pattern"`
+	Sessions         int64   `json:"sessions"`
+	Completed        int64   `json:"completed"`
+	MeasuredSessions int64   `json:"measured_sessions"`
+	AvgHeartRateDrop float64 `json:"avg_heart_rate_drop"`
+}
+
+// GetEffectiveness aggregates heart rate drops per guide and pattern.
+// When userID is non-zero only that user's sessions are considered.
+func (r *Repository) GetEffectiveness(userID uint) ([]Effectiveness, error) {
+	var rows []Effectiveness
+	q := r.db.Model(&model.BreathingSession{}).
+		Select(`panic_guide_id, pattern,
+			COUNT(*) AS sessions,
+			COUNT(*) FILTER (WHERE status = ?) AS completed,
+			COUNT(*) FILTER (WHERE status = ? AND heart_rate_before IS NOT NULL AND heart_rate_after IS NOT NULL) AS measured_sessions,
+			COALESCE(AVG(heart_rate_before - heart_rate_after) FILTER (WHERE status = ? AND heart_rate_before IS NOT NULL AND heart_rate_after IS NOT NULL), 0) AS avg_heart_rate_drop`,
+			model.SessionStatusCompleted, model.SessionStatusCompleted, model.SessionStatusCompleted).
+		Group("panic_guide_id, pattern").
+		Order("avg_heart_rate_drop desc")
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,304 @@
+package breathing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ps_backend/internal/jobs"
+	"ps_backend/internal/vital"
+	"ps_backend/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// heartRateWindow is how old a vital sign may be to count as the "before" heart rate.
+const heartRateWindow = 10 * time.Minute
+
+// staleGrace is how long past its planned end a running session is left to its
+// pacing goroutine before it counts as interrupted.
+const staleGrace = time.Minute
+
+// JobInterruptStale is the job type that marks stale running sessions interrupted.
+const JobInterruptStale = "breathing.interrupt_stale"
+
+var (
+	// ErrSessionNotRunning is returned when stopping a session that already ended.
+	ErrSessionNotRunning = errors.New("breathing session is not running")
+	// ErrInvalidSession is returned, wrapped with the reason, for invalid session parameters.
+	ErrInvalidSession = errors.New("invalid breathing session")
+	// ErrGuideNotFound is returned when starting a session for a panic guide that does not exist.
+	ErrGuideNotFound = errors.New("panic guide not found")
+	// ErrSessionActive is returned when starting a session while another one of the user is running.
+	ErrSessionActive = errors.New("another breathing session is running")
+)
+
+// Notifier pushes messages to a connected client. pkg/websocket.Hub implements it.
+type Notifier interface {
+	SendToClient(clientID string, message []byte) int
+}
+
+// Cue is the message pushed to the client for every breathing phase.
+type Cue struct {
+	Type        string `json:"type"` // breathing_start, breathing_cue, breathing_end
+	SessionID   uint   `json:"session_id"`
+	Cycle       int    `json:"cycle,omitempty"`
+	TotalCycles int    `json:"total_cycles"`
+	Phase       string `json:"phase,omitempty"`
+	Seconds     int    `json:"seconds,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// StartInput holds the parameters of a new breathing session.
+type StartInput struct {
+	UserID       uint
+	PanicGuideID uint
+	Pattern      string
+	Phases       []Phase
+	Cycles       int
+}
+
+// Service runs server-paced breathing sessions and records their outcome.
+type Service struct {
+	repo     *Repository
+	vitals   *vital.Service
+	notifier Notifier
+
+	mu      sync.Mutex
+	running map[uint]*runningSession
+}
+
+// runningSession tracks the pacing goroutine of an active session.
+type runningSession struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewService creates a new breathing Service pushing cues through the given notifier.
+func NewService(db *gorm.DB, notifier Notifier) *Service {
+	return &Service{
+		repo:     NewRepository(db),
+		vitals:   vital.NewService(db),
+		notifier: notifier,
+		running:  make(map[uint]*runningSession),
+	}
+}
+
+// Start creates a session and begins pushing cues to the user's WebSocket connection.
+func (s *Service) Start(input StartInput) (*model.BreathingSession, error) {
+	if input.UserID == 0 || input.PanicGuideID == 0 {
+		return nil, fmt.Errorf("%w: userID and guideID must be provided", ErrInvalidSession)
+	}
+	if input.Cycles < 1 || input.Cycles > 20 {
+		return nil, fmt.Errorf("%w: cycles must be between 1 and 20", ErrInvalidSession)
+	}
+	pattern, err := ResolvePattern(input.Pattern, input.Phases)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.GuideExists(input.PanicGuideID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrGuideNotFound
+	}
+
+	now := time.Now()
+	session := &model.BreathingSession{
+		UserID:       input.UserID,
+		PanicGuideID: input.PanicGuideID,
+		Pattern:      pattern.Name,
+		Phases:       pattern.Spec(),
+		Cycles:       input.Cycles,
+		Status:       model.SessionStatusRunning,
+		StartedAt:    now,
+	}
+	if before, err := s.vitals.GetLatestVital(input.UserID, now.Add(-heartRateWindow)); err != nil {
+		logrus.WithError(err).Warnf("failed to load heart rate before session for user %d", input.UserID)
+	} else if before != nil {
+		hr := before.HeartRate
+		session.HeartRateBefore = &hr
+	}
+	err = s.repo.CreateUserSession(session, func(repo *Repository, running []model.BreathingSession) error {
+		for i := range running {
+			if !stale(&running[i], now) {
+				return ErrSessionActive
+			}
+		}
+		for _, old := range running {
+			if _, err := repo.InterruptSession(old.ID, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rs := &runningSession{cancel: cancel, done: make(chan struct{})}
+	s.mu.Lock()
+	s.running[session.ID] = rs
+	s.mu.Unlock()
+
+	go s.run(ctx, rs, session, pattern)
+	return session, nil
+}
+
+// Stop aborts a running session of the user.
+func (s *Service) Stop(userID, sessionID uint) (*model.BreathingSession, error) {
+	session, err := s.repo.GetUserSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	rs, ok := s.running[sessionID]
+	s.mu.Unlock()
+	if !ok || session.Status != model.SessionStatusRunning {
+		return nil, ErrSessionNotRunning
+	}
+	rs.cancel()
+	// run() records the aborted outcome; wait for it before reloading.
+	select {
+	case <-rs.done:
+	case <-time.After(2 * time.Second):
+	}
+	return s.repo.GetUserSession(userID, sessionID)
+}
+
+// Get returns a session of the user.
+func (s *Service) Get(userID, sessionID uint) (*model.BreathingSession, error) {
+	return s.repo.GetUserSession(userID, sessionID)
+}
+
+// List returns all sessions of the user.
+func (s *Service) List(userID uint) ([]model.BreathingSession, error) {
+	if userID == 0 {
+		return nil, errors.New("userID must be provided")
+	}
+	return s.repo.GetUserSessions(userID)
+}
+
+// RegisterJobs registers the job that interrupts stale sessions, run every minute.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobInterruptStale, jobs.Options{MaxAttempts: 1, Timeout: time.Minute}, func(ctx context.Context, _ struct{}) error {
+		_, err := s.InterruptStale(time.Now())
+		return err
+	})
+	r.MustSchedule("breathing-interrupt-stale", "* * * * *", JobInterruptStale)
+}
+
+// InterruptStale marks running sessions interrupted once their planned end has
+// passed by staleGrace. Their pacing goroutine is gone, typically because the
+// server restarted, so nothing else would ever end them.
+func (s *Service) InterruptStale(now time.Time) (int, error) {
+	sessions, err := s.repo.GetRunningSessions(0)
+	if err != nil {
+		return 0, err
+	}
+	interrupted := 0
+	for i := range sessions {
+		if !stale(&sessions[i], now) {
+			continue
+		}
+		ok, err := s.repo.InterruptSession(sessions[i].ID, now)
+		if err != nil {
+			return interrupted, err
+		}
+		if ok {
+			interrupted++
+		}
+	}
+	if interrupted > 0 {
+		logrus.Infof("Interrupted %d stale breathing sessions", interrupted)
+	}
+	return interrupted, nil
+}
+
+// stale reports whether a running session should have ended by now.
+func stale(session *model.BreathingSession, now time.Time) bool {
+	cycle, err := cycleDuration(session.Phases)
+	if err != nil {
+		return true
+	}
+	end := session.StartedAt.Add(time.Duration(session.Cycles)*cycle + staleGrace)
+	return now.After(end)
+}
+
+// Effectiveness returns heart rate outcomes per guide and pattern, for one user or everyone.
+func (s *Service) Effectiveness(userID uint) ([]Effectiveness, error) {
+	return s.repo.GetEffectiveness(userID)
+}
+
+// run paces the session, pushing a cue at the start of every phase.
+func (s *Service) run(ctx context.Context, rs *runningSession, session *model.BreathingSession, pattern Pattern) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, session.ID)
+		s.mu.Unlock()
+		rs.cancel()
+		close(rs.done)
+	}()
+
+	clientID := fmt.Sprint(session.UserID)
+	s.push(clientID, Cue{Type: "breathing_start", SessionID: session.ID, TotalCycles: session.Cycles})
+
+	for cycle := 1; cycle <= session.Cycles; cycle++ {
+		for _, phase := range pattern.Phases {
+			s.push(clientID, Cue{
+				Type:        "breathing_cue",
+				SessionID:   session.ID,
+				Cycle:       cycle,
+				TotalCycles: session.Cycles,
+				Phase:       phase.Cue,
+				Seconds:     phase.Seconds,
+			})
+			select {
+			case <-ctx.Done():
+				s.finish(clientID, session, model.SessionStatusAborted)
+				return
+			case <-time.After(time.Duration(phase.Seconds) * time.Second):
+			}
+		}
+		session.CyclesCompleted = cycle
+	}
+	s.finish(clientID, session, model.SessionStatusCompleted)
+}
+
+// finish records the outcome of a session including the heart rate measured during it.
+func (s *Service) finish(clientID string, session *model.BreathingSession, status string) {
+	now := time.Now()
+	session.Status = status
+	session.EndedAt = &now
+	if after, err := s.vitals.GetLatestVital(session.UserID, session.StartedAt); err != nil {
+		logrus.WithError(err).Warnf("failed to load heart rate after session %d", session.ID)
+	} else if after != nil {
+		hr := after.HeartRate
+		session.HeartRateAfter = &hr
+	}
+	if err := s.repo.SaveSession(session); err != nil {
+		logrus.WithError(err).Errorf("failed to save breathing session %d", session.ID)
+	}
+	s.push(clientID, Cue{Type: "breathing_end", SessionID: session.ID, TotalCycles: session.Cycles, Status: status})
+	logrus.Infof("breathing session %d %s after %d cycles", session.ID, status, session.CyclesCompleted)
+}
+
+func (s *Service) push(clientID string, cue Cue) {
+	if s.notifier == nil {
+		return
+	}
+	msg, err := json.Marshal(cue)
+	if err != nil {
+		logrus.WithError(err).Error("failed to marshal breathing cue")
+		return
+	}
+	if s.notifier.SendToClient(clientID, msg) == 0 {
+		logrus.Debugf("no WebSocket connection for user %s, cue dropped", clientID)
+	}
+}
@@ -5,13 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
 	"ps_backend/model"
+	"ps_backend/pkg/config"
 )
 
 type ChatbotService struct {
@@ -45,7 +45,7 @@ func (s *ChatbotService) SendMessage(userID uint, message string) (string, error
 
 	promptBuilder := &bytes.Buffer{}
 	fmt.Fprintf(promptBuilder, "You are chatting with user %s.\n", user.Username)
-	fmt.Fprintf(promptBuilder, "Style: %s\nTone: %s\n\n", user.SpeakingStyle, user.Tone)
+	fmt.Fprintf(promptBuilder, "Persona:\n%s\n", user.Persona.Instructions())
 	fmt.Fprintf(promptBuilder, "Conversation history:\n")
 	for _, log := range history {
 		role := "User"
@@ -57,10 +57,10 @@ func (s *ChatbotService) SendMessage(userID uint, message string) (string, error
 	fmt.Fprintf(promptBuilder, "User: %s\nBot:", message)
 	prompt := promptBuilder.String()
 
-	apiURL := os.Getenv("GEMINI_API_URL")
-	apiKey := os.Getenv("GEMINI_API_KEY")
+	gemini := config.Get().Gemini
+	apiURL, apiKey := gemini.URL, gemini.Key
 	if apiURL == "" || apiKey == "" {
-		logrus.Error("GEMINI_API_URL or GEMINI_API_KEY environment variable is not set")
+		logrus.Error("GEMINI_API_URL or GEMINI_API_KEY is not configured")
 		return "", fmt.Errorf("chatbot service is not properly configured")
 	}
 
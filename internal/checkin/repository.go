@@ -0,0 +1,144 @@
+package checkin
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for check-ins and their schedules.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new check-in Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUser retrieves the owner of a schedule.
+func (r *Repository) GetUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetSchedules returns the schedule slots of a user ordered by time.
+func (r *Repository) GetSchedules(userID uint) ([]model.CheckInSchedule, error) {
+	var slots []model.CheckInSchedule
+	err := r.db.Where("user_id = ?", userID).Order("time").Find(&slots).Error
+	return slots, err
+}
+
+// ReplaceSchedules replaces the slots of a user. Slots that keep their time keep
+// their prompt history, so an edit does not prompt twice on the same day.
+func (r *Repository) ReplaceSchedules(userID uint, times []string, enabled bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("user_id = ?", userID)
+		if len(times) > 0 {
+			q = q.Where("time NOT IN ?", times)
+		}
+		if err := q.Delete(&model.CheckInSchedule{}).Error; err != nil {
+			return err
+		}
+		for _, t := range times {
+			slot := model.CheckInSchedule{UserID: userID, Time: t}
+			if err := tx.Where(slot).Attrs(model.CheckInSchedule{Enabled: enabled}).FirstOrCreate(&slot).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.CheckInSchedule{}).Where("user_id = ?", userID).Update("enabled", enabled).Error
+	})
+}
+
+// ScheduledUser is an enabled schedule slot together with its owner's time zone.
+type ScheduledUser struct {
+	model.CheckInSchedule
+	Timezone string
+}
+
+// GetEnabledSchedules returns every enabled slot of users not pending deletion.
+func (r *Repository) GetEnabledSchedules() ([]ScheduledUser, error) {
+	var slots []ScheduledUser
+	err := r.db.Table("check_in_schedules").
+		Select("check_in_schedules.*, users.timezone").
+		Joins("JOIN users ON users.id = check_in_schedules.user_id").
+		Where("check_in_schedules.enabled AND users.deletion_scheduled_at IS NULL").
+		Scan(&slots).Error
+	return slots, err
+}
+
+// MarkPrompted records the prompt of a slot unless another worker already did;
+// it reports whether this call claimed the prompt.
+func (r *Repository) MarkPrompted(slotID uint, previous *time.Time, at time.Time) (bool, error) {
+	q := r.db.Model(&model.CheckInSchedule{}).Where("id = ?", slotID)
+	if previous == nil {
+		q = q.Where("last_prompt_at IS NULL")
+	} else {
+		q = q.Where("last_prompt_at = ?", *previous)
+	}
+	res := q.Update("last_prompt_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetSchedule retrieves a schedule slot owned by the user.
+func (r *Repository) GetSchedule(userID, slotID uint) (*model.CheckInSchedule, error) {
+	var slot model.CheckInSchedule
+	if err := r.db.Where("id = ? AND user_id = ?", slotID, userID).First(&slot).Error; err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// CreateCheckIn inserts a check-in.
+func (r *Repository) CreateCheckIn(c *model.CheckIn) error {
+	return r.db.Create(c).Error
+}
+
+// GetCheckIns returns the check-ins of a user answered within the range, oldest first.
+func (r *Repository) GetCheckIns(userID uint, from, to time.Time) ([]model.CheckIn, error) {
+	var items []model.CheckIn
+	err := r.db.Where("user_id = ? AND responded_at BETWEEN ? AND ?", userID, from, to).
+		Order("responded_at").Find(&items).Error
+	return items, err
+}
+
+// DailyVitals is the average of a user's vital signs on one local day.
+type DailyVitals struct {
+	Day          string
+	AvgHeartRate float64
+	AvgStress    float64
+	Samples      int
+}
+
+// GetDailyVitals aggregates the vitals of a user per day in the given time zone.
+func (r *Repository) GetDailyVitals(userID uint, from, to time.Time, timezone string) ([]DailyVitals, error) {
+	var rows []DailyVitals
+	err := r.db.Model(&model.VitalSign{}).
+		Select("to_char(measured_at AT TIME ZONE ?, 'YYYY-MM-DD') AS day, AVG(heart_rate) AS avg_heart_rate, AVG(stress_level) AS avg_stress, COUNT(*) AS samples", timezone).
+		Where("user_id = ? AND measured_at BETWEEN ? AND ?", userID, from, to).
+		Group("day").Order("day").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// DailyEpisodes is the number of panic episodes of a user on one local day.
+type DailyEpisodes struct {
+	Day      string
+	Episodes int
+}
+
+// GetDailyEpisodes counts the panic episodes of a user per day in the given time zone.
+func (r *Repository) GetDailyEpisodes(userID uint, from, to time.Time, timezone string) ([]DailyEpisodes, error) {
+	var rows []DailyEpisodes
+	err := r.db.Model(&model.PanicEpisode{}).
+		Select("to_char(started_at AT TIME ZONE ?, 'YYYY-MM-DD') AS day, COUNT(*) AS episodes", timezone).
+		Where("user_id = ? AND started_at BETWEEN ? AND ?", userID, from, to).
+		Group("day").Order("day").
+		Scan(&rows).Error
+	return rows, err
+}
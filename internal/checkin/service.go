@@ -0,0 +1,369 @@
+package checkin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"ps_backend/internal/jobs"
+	"ps_backend/internal/notifier"
+	"ps_backend/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidSchedule is returned for malformed, duplicate or too many schedule times.
+	ErrInvalidSchedule = errors.New("invalid check-in schedule")
+	// ErrInvalidCheckIn is returned when a score is out of range.
+	ErrInvalidCheckIn = errors.New("invalid check-in")
+	// ErrScheduleNotFound is returned when the answered schedule does not belong to the user.
+	ErrScheduleNotFound = errors.New("check-in schedule not found")
+)
+
+const (
+	// MaxScheduleTimes is the number of daily prompts a user can configure.
+	MaxScheduleTimes = 6
+	// promptWindow is how long after a scheduled time a missed prompt is still sent,
+	// e.g. after a restart.
+	promptWindow = time.Hour
+	// minCorrelationDays is the number of days with both check-ins and vitals
+	// needed before a correlation is reported.
+	minCorrelationDays = 3
+)
+
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// Notifier pushes messages to a connected client. pkg/websocket.Hub implements it.
+type Notifier interface {
+	SendToClient(clientID string, message []byte) int
+}
+
+// Prompt is the WebSocket message asking the user for a check-in.
+type Prompt struct {
+	Type       string `json:"type"` // always "checkin_due"
+	ScheduleID uint   `json:"schedule_id"`
+	Time       string `json:"time"`
+}
+
+// ResponseInput holds the answers of a check-in.
+type ResponseInput struct {
+	ScheduleID   *uint
+	Mood         int
+	Nervousness  int
+	Worry        int
+	SleepQuality *int
+	Note         string
+}
+
+// Service manages check-in schedules, prompts users when a check-in is due and
+// stores their responses.
+type Service struct {
+	repo   *Repository
+	ws     Notifier
+	pusher *notifier.Service
+}
+
+// NewService creates a check-in Service. Prompts go to open WebSocket connections
+// first and fall back to a push notification.
+func NewService(db *gorm.DB, ws Notifier, pusher *notifier.Service) *Service {
+	return &Service{repo: NewRepository(db), ws: ws, pusher: pusher}
+}
+
+// GetSchedule returns the schedule slots of a user.
+func (s *Service) GetSchedule(userID uint) ([]model.CheckInSchedule, error) {
+	return s.repo.GetSchedules(userID)
+}
+
+// SetSchedule replaces the daily prompt times (HH:MM in the user's time zone).
+func (s *Service) SetSchedule(userID uint, times []string, enabled bool) ([]model.CheckInSchedule, error) {
+	if len(times) > MaxScheduleTimes {
+		return nil, ErrInvalidSchedule
+	}
+	seen := make(map[string]bool, len(times))
+	for _, t := range times {
+		if !clockPattern.MatchString(t) || seen[t] {
+			return nil, ErrInvalidSchedule
+		}
+		seen[t] = true
+	}
+	if err := s.repo.ReplaceSchedules(userID, times, enabled); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSchedules(userID)
+}
+
+// Respond stores a check-in. The anxiety score is the GAD-2 sum of the
+// nervousness and worry items.
+func (s *Service) Respond(userID uint, in ResponseInput) (*model.CheckIn, error) {
+	if in.Mood < 1 || in.Mood > 5 || in.Nervousness < 0 || in.Nervousness > 3 || in.Worry < 0 || in.Worry > 3 ||
+		(in.SleepQuality != nil && (*in.SleepQuality < 1 || *in.SleepQuality > 5)) {
+		return nil, ErrInvalidCheckIn
+	}
+	c := &model.CheckIn{
+		UserID:       userID,
+		Mood:         in.Mood,
+		Nervousness:  in.Nervousness,
+		Worry:        in.Worry,
+		AnxietyScore: in.Nervousness + in.Worry,
+		SleepQuality: in.SleepQuality,
+		Note:         in.Note,
+		RespondedAt:  time.Now(),
+	}
+	if in.ScheduleID != nil {
+		slot, err := s.repo.GetSchedule(userID, *in.ScheduleID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.ScheduleID = &slot.ID
+		c.PromptedAt = slot.LastPromptAt
+	}
+	if err := s.repo.CreateCheckIn(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// List returns the check-ins of a user within the range.
+func (s *Service) List(userID uint, from, to time.Time) ([]model.CheckIn, error) {
+	return s.repo.GetCheckIns(userID, from, to)
+}
+
+// JobPrompts is the background job type sending due check-in prompts.
+const JobPrompts = "checkin.prompts"
+
+// RegisterJobs registers the check-in prompt job, run every minute.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobPrompts, jobs.Options{MaxAttempts: 1, Timeout: time.Minute}, func(ctx context.Context, _ struct{}) error {
+		n, err := s.SendDuePrompts(time.Now())
+		if n > 0 {
+			logrus.Infof("Sent %d check-in prompts", n)
+		}
+		return err
+	})
+	r.MustSchedule("checkin-prompts", "* * * * *", JobPrompts)
+}
+
+// SendDuePrompts prompts every slot whose time has passed today in the user's
+// time zone within the prompt window and that has not been prompted yet today.
+func (s *Service) SendDuePrompts(now time.Time) (int, error) {
+	slots, err := s.repo.GetEnabledSchedules()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, slot := range slots {
+		due, ok := slotTime(slot.Time, now.In(location(slot.Timezone)))
+		if !ok || now.Before(due) || !now.Before(due.Add(promptWindow)) {
+			continue
+		}
+		if slot.LastPromptAt != nil && !slot.LastPromptAt.Before(due) {
+			continue
+		}
+		claimed, err := s.repo.MarkPrompted(slot.ID, slot.LastPromptAt, now)
+		if err != nil {
+			logrus.WithError(err).Errorf("failed to mark check-in schedule %d prompted", slot.ID)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.prompt(slot.CheckInSchedule)
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *Service) prompt(slot model.CheckInSchedule) {
+	msg, err := json.Marshal(Prompt{Type: model.NotificationCheckInDue, ScheduleID: slot.ID, Time: slot.Time})
+	if err != nil {
+		logrus.WithError(err).Error("failed to marshal check-in prompt")
+		return
+	}
+	if s.ws != nil && s.ws.SendToClient(strconv.FormatUint(uint64(slot.UserID), 10), msg) > 0 {
+		return
+	}
+	if s.pusher == nil {
+		return
+	}
+	if _, err := s.pusher.Notify(notifier.Input{
+		UserID:   slot.UserID,
+		Category: model.NotificationCheckInDue,
+		Data:     map[string]string{"schedule_id": strconv.FormatUint(uint64(slot.ID), 10)},
+	}); err != nil {
+		logrus.WithError(err).Errorf("failed to notify user %d of check-in", slot.UserID)
+	}
+}
+
+// slotTime returns today's occurrence of an HH:MM slot in the time zone of localNow.
+func slotTime(clock string, localNow time.Time) (time.Time, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	y, m, d := localNow.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, localNow.Location()), true
+}
+
+func location(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// TrendDay summarizes one local day. Averages are nil when there is no data.
+type TrendDay struct {
+	Date         string   `json:"date"`
+	CheckIns     int      `json:"check_ins"`
+	AvgMood      *float64 `json:"avg_mood"`
+	AvgAnxiety   *float64 `json:"avg_anxiety"`
+	VitalSamples int      `json:"vital_samples"`
+	AvgHeartRate *float64 `json:"avg_heart_rate"`
+	AvgStress    *float64 `json:"avg_stress"`
+	Episodes     int      `json:"episodes"`
+}
+
+// Correlations are Pearson coefficients over days with both check-ins and
+// vitals unless noted; nil when there are too few such days or a series is constant.
+type Correlations struct {
+	PairedDays       int      `json:"paired_days"`
+	MoodStress       *float64 `json:"mood_stress"`
+	MoodHeartRate    *float64 `json:"mood_heart_rate"`
+	AnxietyStress    *float64 `json:"anxiety_stress"`
+	AnxietyHeartRate *float64 `json:"anxiety_heart_rate"`
+	AnxietyEpisodes  *float64 `json:"anxiety_episodes"` // over all days with check-ins
+}
+
+// Trend is the check-in trend report of a user.
+type Trend struct {
+	From         time.Time    `json:"from"`
+	To           time.Time    `json:"to"`
+	Timezone     string       `json:"timezone"`
+	Days         []TrendDay   `json:"days"`
+	Correlations Correlations `json:"correlations"`
+}
+
+// Trends reports daily mood and anxiety next to the daily vitals and panic
+// episodes of the user, with their correlations.
+func (s *Service) Trends(userID uint, from, to time.Time) (*Trend, error) {
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := location(user.Timezone)
+	checkIns, err := s.repo.GetCheckIns(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	vitals, err := s.repo.GetDailyVitals(userID, from, to, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	episodes, err := s.repo.GetDailyEpisodes(userID, from, to, loc.String())
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]*TrendDay)
+	day := func(date string) *TrendDay {
+		if d, ok := days[date]; ok {
+			return d
+		}
+		d := &TrendDay{Date: date}
+		days[date] = d
+		return d
+	}
+	moodSum := make(map[string]float64)
+	anxietySum := make(map[string]float64)
+	for _, c := range checkIns {
+		date := c.RespondedAt.In(loc).Format("2006-01-02")
+		day(date).CheckIns++
+		moodSum[date] += float64(c.Mood)
+		anxietySum[date] += float64(c.AnxietyScore)
+	}
+	for date, d := range days {
+		mood := moodSum[date] / float64(d.CheckIns)
+		anxiety := anxietySum[date] / float64(d.CheckIns)
+		d.AvgMood, d.AvgAnxiety = &mood, &anxiety
+	}
+	for _, v := range vitals {
+		d := day(v.Day)
+		hr, stress := v.AvgHeartRate, v.AvgStress
+		d.VitalSamples, d.AvgHeartRate, d.AvgStress = v.Samples, &hr, &stress
+	}
+	for _, e := range episodes {
+		day(e.Day).Episodes = e.Episodes
+	}
+
+	trend := &Trend{From: from, To: to, Timezone: loc.String(), Days: make([]TrendDay, 0, len(days))}
+	for _, d := range days {
+		trend.Days = append(trend.Days, *d)
+	}
+	sort.Slice(trend.Days, func(i, j int) bool { return trend.Days[i].Date < trend.Days[j].Date })
+	trend.Correlations = correlate(trend.Days)
+	return trend, nil
+}
+
+func correlate(days []TrendDay) Correlations {
+	var mood, anxiety, hr, stress, anxietyAll, episodes []float64
+	for _, d := range days {
+		if d.CheckIns == 0 {
+			continue
+		}
+		anxietyAll = append(anxietyAll, *d.AvgAnxiety)
+		episodes = append(episodes, float64(d.Episodes))
+		if d.VitalSamples == 0 {
+			continue
+		}
+		mood = append(mood, *d.AvgMood)
+		anxiety = append(anxiety, *d.AvgAnxiety)
+		hr = append(hr, *d.AvgHeartRate)
+		stress = append(stress, *d.AvgStress)
+	}
+	return Correlations{
+		PairedDays:       len(mood),
+		MoodStress:       pearson(mood, stress),
+		MoodHeartRate:    pearson(mood, hr),
+		AnxietyStress:    pearson(anxiety, stress),
+		AnxietyHeartRate: pearson(anxiety, hr),
+		AnxietyEpisodes:  pearson(anxietyAll, episodes),
+	}
+}
+
+// pearson returns the correlation coefficient of two equally long series,
+// rounded to two decimals.
+func pearson(x, y []float64) *float64 {
+	n := len(x)
+	if n < minCorrelationDays || n != len(y) {
+		return nil
+	}
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= float64(n)
+	my /= float64(n)
+	var cov, vx, vy float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		vx += (x[i] - mx) * (x[i] - mx)
+		vy += (y[i] - my) * (y[i] - my)
+	}
+	if vx == 0 || vy == 0 {
+		return nil
+	}
+	r := math.Round(cov/math.Sqrt(vx*vy)*100) / 100
+	return &r
+}
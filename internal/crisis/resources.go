@@ -0,0 +1,19 @@
+package crisis
+
+// Resource describes a hotline or service a user in crisis can reach out to.
+type Resource struct {
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Description string `json:"description"`
+}
+
+// Resources returns the crisis resources shown to users whenever the
+// regular service cannot respond (quota exceeded, upstream failure, ...).
+func Resources() []Resource {
+	return []Resource{
+		{Name: "자살예방상담전화", Phone: "109", Description: "24시간 자살 예방 상담"},
+		{Name: "정신건강위기상담전화", Phone: "1577-0199", Description: "24시간 정신건강 위기 상담"},
+		{Name: "청소년상담전화", Phone: "1388", Description: "청소년 고민 상담"},
+		{Name: "긴급신고", Phone: "119", Description: "생명이 위급한 경우 즉시 연락하세요."},
+	}
+}
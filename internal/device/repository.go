@@ -0,0 +1,77 @@
+package device
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for devices.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new device Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetDevices returns the devices of a user, most recently seen first.
+func (r *Repository) GetDevices(userID uint) ([]model.Device, error) {
+	var devices []model.Device
+	if err := r.db.Where("user_id = ?", userID).Order("last_seen_at desc").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// GetDevice retrieves a device owned by the user.
+func (r *Repository) GetDevice(userID, deviceID uint) (*model.Device, error) {
+	var device model.Device
+	if err := r.db.Where("id = ? AND user_id = ?", deviceID, userID).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// GetByInstallation retrieves the device of a user by its installation ID.
+func (r *Repository) GetByInstallation(userID uint, installationID string) (*model.Device, error) {
+	var device model.Device
+	if err := r.db.Where("user_id = ? AND installation_id = ?", userID, installationID).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// CountActive returns the number of unrevoked devices of a user.
+func (r *Repository) CountActive(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Device{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// Save inserts or updates a device.
+func (r *Repository) Save(device *model.Device) error {
+	return r.db.Save(device).Error
+}
+
+// Touch records that the device was just used.
+func (r *Repository) Touch(deviceID uint, ip string, at time.Time) error {
+	return r.db.Model(&model.Device{}).Where("id = ? AND revoked_at IS NULL", deviceID).
+		Updates(map[string]interface{}{"last_seen_at": at, "last_ip": ip}).Error
+}
+
+// Revoke marks the device revoked, forgets its push token and revokes the
+// refresh tokens issued to it.
+func (r *Repository) Revoke(device *model.Device, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(device).Updates(map[string]interface{}{"revoked_at": at, "push_token": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.RefreshToken{}).
+			Where("device_id = ? AND revoked_at IS NULL", device.ID).
+			Update("revoked_at", at).Error
+	})
+}
@@ -0,0 +1,139 @@
+package device
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// MaxDevices is the maximum number of active devices per user.
+const MaxDevices = 20
+
+var (
+	// ErrDeviceNotFound is returned when the device does not exist or belongs to another user.
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrDeviceRevoked is returned when data is submitted for a revoked device.
+	ErrDeviceRevoked = errors.New("device revoked")
+	// ErrTooManyDevices is returned when the user already has MaxDevices active devices.
+	ErrTooManyDevices = errors.New("too many devices")
+)
+
+// Input holds the details a client reports about its device.
+type Input struct {
+	InstallationID string
+	Type           string
+	Name           string
+	Model          string
+	OS             string
+	PushToken      *string // nil keeps the current push token
+	PushProvider   string  // fcm or apns; derived from the OS when empty
+}
+
+// Service provides device registration and revocation.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new device Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db)}
+}
+
+// List returns the devices of a user.
+func (s *Service) List(userID uint) ([]model.Device, error) {
+	return s.repo.GetDevices(userID)
+}
+
+// Register creates the device or updates the one with the same installation ID.
+// Registering a revoked installation again, e.g. on a new sign-in, reactivates it.
+func (s *Service) Register(userID uint, in Input, ip string) (*model.Device, error) {
+	device, err := s.repo.GetByInstallation(userID, in.InstallationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		device = &model.Device{UserID: userID, InstallationID: in.InstallationID}
+	} else if err != nil {
+		return nil, err
+	}
+	if device.ID == 0 || device.RevokedAt != nil {
+		count, err := s.repo.CountActive(userID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= MaxDevices {
+			return nil, ErrTooManyDevices
+		}
+	}
+	device.Type = in.Type
+	device.Name = strings.TrimSpace(in.Name)
+	device.Model = strings.TrimSpace(in.Model)
+	device.OS = strings.TrimSpace(in.OS)
+	if in.PushToken != nil {
+		device.PushToken = strings.TrimSpace(*in.PushToken)
+	}
+	switch {
+	case in.PushProvider != "":
+		device.PushProvider = in.PushProvider
+	case device.PushProvider == "":
+		device.PushProvider = pushProviderFor(device.OS)
+	}
+	device.LastSeenAt = time.Now()
+	device.LastIP = ip
+	device.RevokedAt = nil
+	if err := s.repo.Save(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// Resolve returns the active device of the user, for attributing submitted data.
+func (s *Service) Resolve(userID, deviceID uint) (*model.Device, error) {
+	device, err := s.repo.GetDevice(userID, deviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if device.RevokedAt != nil {
+		return nil, ErrDeviceRevoked
+	}
+	return device, nil
+}
+
+// Touch records activity of a device. A zero device ID is ignored.
+func (s *Service) Touch(deviceID uint, ip string) error {
+	if deviceID == 0 {
+		return nil
+	}
+	return s.repo.Touch(deviceID, ip, time.Now())
+}
+
+// Revoke signs the device out: its refresh tokens stop working and it no
+// longer receives push notifications. Revoking twice is a no-op.
+func (s *Service) Revoke(userID, deviceID uint) error {
+	device, err := s.repo.GetDevice(userID, deviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if device.RevokedAt != nil {
+		return nil
+	}
+	return s.repo.Revoke(device, time.Now())
+}
+
+// pushProviderFor guesses the push service of a device from its OS name.
+func pushProviderFor(os string) string {
+	os = strings.ToLower(os)
+	for _, apple := range []string{"ios", "ipados", "watchos", "macos"} {
+		if strings.Contains(os, apple) {
+			return model.PushProviderAPNs
+		}
+	}
+	return model.PushProviderFCM
+}
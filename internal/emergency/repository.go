@@ -0,0 +1,129 @@
+package emergency
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository provides database access for emergency contacts and SOS alerts.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new emergency Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUser retrieves the user an alert is sent for.
+func (r *Repository) GetUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetContacts returns the emergency contacts of a user.
+func (r *Repository) GetContacts(userID uint) ([]model.EmergencyContact, error) {
+	var contacts []model.EmergencyContact
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// GetConsentedContacts returns the contacts of a user that agreed to receive alerts.
+func (r *Repository) GetConsentedContacts(userID uint) ([]model.EmergencyContact, error) {
+	var contacts []model.EmergencyContact
+	if err := r.db.Where("user_id = ? AND consent_confirmed", userID).Order("id").Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// CountContacts returns the number of emergency contacts of a user.
+func (r *Repository) CountContacts(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.EmergencyContact{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// GetContact retrieves an emergency contact owned by the user.
+func (r *Repository) GetContact(userID, contactID uint) (*model.EmergencyContact, error) {
+	var contact model.EmergencyContact
+	if err := r.db.Where("id = ? AND user_id = ?", contactID, userID).First(&contact).Error; err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// CreateContact inserts a new emergency contact.
+func (r *Repository) CreateContact(contact *model.EmergencyContact) error {
+	return r.db.Create(contact).Error
+}
+
+// SaveContact updates an existing emergency contact.
+func (r *Repository) SaveContact(contact *model.EmergencyContact) error {
+	return r.db.Save(contact).Error
+}
+
+// DeleteContact deletes an emergency contact owned by the user and reports whether it existed.
+func (r *Repository) DeleteContact(userID, contactID uint) (bool, error) {
+	res := r.db.Where("id = ? AND user_id = ?", contactID, userID).Delete(&model.EmergencyContact{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// CountAlertsSince returns the number of alerts of a user with one of the given statuses since the given time.
+func (r *Repository) CountAlertsSince(userID uint, since time.Time, statuses ...string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.SOSAlert{}).
+		Where("user_id = ? AND created_at >= ? AND status IN ?", userID, since, statuses).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateAlert inserts a new SOS alert once setStatus, given a repository in the
+// same transaction, has set its status. The user's row is locked, so the rate
+// limit sees every earlier alert of the user whichever server created it.
+func (r *Repository) CreateAlert(alert *model.SOSAlert, setStatus func(repo *Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&model.User{}, alert.UserID).Error; err != nil {
+			return err
+		}
+		if err := setStatus(NewRepository(tx)); err != nil {
+			return err
+		}
+		return tx.Create(alert).Error
+	})
+}
+
+// FinishAlert stores the final status of an alert together with its deliveries.
+func (r *Repository) FinishAlert(alert *model.SOSAlert) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(alert.Deliveries) > 0 {
+			if err := tx.Create(&alert.Deliveries).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(alert).Update("status", alert.Status).Error
+	})
+}
+
+// GetAlerts returns the latest SOS alerts of a user with their deliveries.
+func (r *Repository) GetAlerts(userID uint, limit int) ([]model.SOSAlert, error) {
+	var alerts []model.SOSAlert
+	if err := r.db.Preload("Deliveries").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,287 @@
+package emergency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ps_backend/internal/auth"
+	"ps_backend/internal/jobs"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MaxContacts is the maximum number of emergency contacts per user.
+const MaxContacts = 5
+
+// JobPanicSOS is the background job type sending the automatic SOS after a detected panic.
+const JobPanicSOS = "sos.panic"
+
+// PanicSOSPayload is the payload of a JobPanicSOS job.
+type PanicSOSPayload struct {
+	UserID uint `json:"user_id"`
+}
+
+var (
+	// ErrContactNotFound is returned when the contact does not exist or belongs to another user.
+	ErrContactNotFound = errors.New("emergency contact not found")
+	// ErrTooManyContacts is returned when a user already has MaxContacts contacts.
+	ErrTooManyContacts = errors.New("too many emergency contacts")
+	// ErrNoContacts is returned when an SOS is requested but no contact has consented.
+	ErrNoContacts = errors.New("no consented emergency contacts")
+	// ErrRateLimited is returned when an SOS is suppressed by the rate limit.
+	ErrRateLimited = errors.New("sos alert rate limited")
+)
+
+// RateLimit controls how often SOS messages may be sent for a user.
+type RateLimit struct {
+	MaxAlerts    int           // alerts allowed per Window
+	Window       time.Duration // sliding window of MaxAlerts
+	AutoCooldown time.Duration // minimum gap before an automatic alert follows any other alert
+}
+
+// DefaultRateLimit returns the rate limit configured with SOS_RATE_LIMIT,
+// SOS_RATE_WINDOW and SOS_AUTO_COOLDOWN (defaults: 3 per 1h, 30m cooldown).
+func DefaultRateLimit() RateLimit {
+	c := config.Get().SOS
+	return RateLimit{MaxAlerts: c.Rate.Limit, Window: c.Rate.Window, AutoCooldown: c.AutoCooldown}
+}
+
+// ContactInput holds the editable fields of an emergency contact.
+type ContactInput struct {
+	Name             string
+	PhoneNumber      string
+	Relationship     string
+	ConsentConfirmed bool
+}
+
+// SOSInput describes an SOS request.
+type SOSInput struct {
+	UserID    uint
+	Trigger   string // model.SOSTriggerManual or model.SOSTriggerPanicDetection
+	Latitude  *float64
+	Longitude *float64
+}
+
+// Service manages emergency contacts and sends SOS alerts to them.
+type Service struct {
+	repo  *Repository
+	queue *jobs.Queue
+	send  func(phone, message string) error
+	limit RateLimit
+}
+
+// NewService creates a new emergency Service sending SMS through the auth SMS sender.
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		repo:  NewRepository(db),
+		queue: jobs.NewQueue(db),
+		send:  auth.SendSMS,
+		limit: DefaultRateLimit(),
+	}
+}
+
+// GetContacts returns the emergency contacts of a user.
+func (s *Service) GetContacts(userID uint) ([]model.EmergencyContact, error) {
+	return s.repo.GetContacts(userID)
+}
+
+// CreateContact adds an emergency contact for a user.
+func (s *Service) CreateContact(userID uint, in ContactInput) (*model.EmergencyContact, error) {
+	count, err := s.repo.CountContacts(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxContacts {
+		return nil, ErrTooManyContacts
+	}
+	contact := &model.EmergencyContact{UserID: userID}
+	applyContactInput(contact, in)
+	if err := s.repo.CreateContact(contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// UpdateContact replaces the fields of an emergency contact owned by the user.
+func (s *Service) UpdateContact(userID, contactID uint, in ContactInput) (*model.EmergencyContact, error) {
+	contact, err := s.repo.GetContact(userID, contactID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrContactNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A new phone number belongs to someone who has not agreed yet.
+	if contact.PhoneNumber != in.PhoneNumber {
+		contact.ConsentConfirmed = false
+	}
+	applyContactInput(contact, in)
+	if err := s.repo.SaveContact(contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// DeleteContact removes an emergency contact owned by the user.
+func (s *Service) DeleteContact(userID, contactID uint) error {
+	deleted, err := s.repo.DeleteContact(userID, contactID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+func applyContactInput(contact *model.EmergencyContact, in ContactInput) {
+	contact.Name = in.Name
+	contact.PhoneNumber = in.PhoneNumber
+	contact.Relationship = in.Relationship
+	if in.ConsentConfirmed && !contact.ConsentConfirmed {
+		now := time.Now()
+		contact.ConsentConfirmedAt = &now
+	}
+	if !in.ConsentConfirmed {
+		contact.ConsentConfirmedAt = nil
+	}
+	contact.ConsentConfirmed = in.ConsentConfirmed
+}
+
+// QueuePanicSOS queues the automatic SOS for a measurement that indicates a
+// panic attack, so vital uploads are not slowed down by SMS delivery.
+func (s *Service) QueuePanicSOS(userID uint) error {
+	_, err := s.queue.Enqueue(JobPanicSOS, PanicSOSPayload{UserID: userID})
+	return err
+}
+
+// RegisterJobs registers the automatic SOS job handler. It is not retried, so
+// that contacts are never alerted twice for the same measurement.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobPanicSOS, jobs.Options{MaxAttempts: 1, Timeout: time.Minute}, func(ctx context.Context, p PanicSOSPayload) error {
+		_, err := s.TriggerSOS(SOSInput{UserID: p.UserID, Trigger: model.SOSTriggerPanicDetection})
+		if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrNoContacts) {
+			return nil
+		}
+		return err
+	})
+}
+
+// TriggerSOS sends an SMS to every consented contact of the user. Every call is
+// recorded as an SOSAlert, including those suppressed by the rate limit, which
+// return ErrRateLimited together with the recorded alert.
+func (s *Service) TriggerSOS(in SOSInput) (*model.SOSAlert, error) {
+	if in.Trigger == "" {
+		in.Trigger = model.SOSTriggerManual
+	}
+	user, err := s.repo.GetUser(in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := s.repo.GetConsentedContacts(in.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := &model.SOSAlert{
+		UserID:    in.UserID,
+		Trigger:   in.Trigger,
+		Latitude:  in.Latitude,
+		Longitude: in.Longitude,
+		Message:   sosMessage(user, in),
+	}
+
+	err = s.repo.CreateAlert(alert, func(repo *Repository) error {
+		limited, err := s.rateLimited(repo, in)
+		if err != nil {
+			return err
+		}
+		switch {
+		case limited:
+			alert.Status = model.SOSStatusRateLimited
+		case len(contacts) == 0:
+			alert.Status = model.SOSStatusNoContacts
+		default:
+			alert.Status = model.SOSStatusSending
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch alert.Status {
+	case model.SOSStatusRateLimited:
+		return alert, ErrRateLimited
+	case model.SOSStatusNoContacts:
+		return alert, ErrNoContacts
+	}
+
+	alert.Status = model.SOSStatusFailed
+	for _, contact := range contacts {
+		delivery := model.SOSAlertDelivery{
+			SOSAlertID:         alert.ID,
+			EmergencyContactID: contact.ID,
+			PhoneNumber:        contact.PhoneNumber,
+			Success:            true,
+		}
+		if err := s.send(contact.PhoneNumber, alert.Message); err != nil {
+			delivery.Success = false
+			delivery.Error = err.Error()
+		} else {
+			alert.Status = model.SOSStatusSent
+		}
+		alert.Deliveries = append(alert.Deliveries, delivery)
+	}
+	if err := s.repo.FinishAlert(alert); err != nil {
+		return nil, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"userID":  in.UserID,
+		"alertID": alert.ID,
+		"trigger": in.Trigger,
+		"status":  alert.Status,
+	}).Info("SOS alert processed")
+	return alert, nil
+}
+
+// rateLimited reports whether an SOS of the given input must be suppressed.
+func (s *Service) rateLimited(repo *Repository, in SOSInput) (bool, error) {
+	now := time.Now()
+	active := []string{model.SOSStatusSending, model.SOSStatusSent}
+	if in.Trigger == model.SOSTriggerPanicDetection && s.limit.AutoCooldown > 0 {
+		recent, err := repo.CountAlertsSince(in.UserID, now.Add(-s.limit.AutoCooldown), active...)
+		if err != nil || recent > 0 {
+			return recent > 0, err
+		}
+	}
+	count, err := repo.CountAlertsSince(in.UserID, now.Add(-s.limit.Window), active...)
+	if err != nil {
+		return false, err
+	}
+	return count >= int64(s.limit.MaxAlerts), nil
+}
+
+func sosMessage(user *model.User, in SOSInput) string {
+	msg := fmt.Sprintf("[PanicShield] %s님이 긴급 도움을 요청했습니다.", user.Username)
+	if in.Trigger == model.SOSTriggerPanicDetection {
+		msg = fmt.Sprintf("[PanicShield] %s님에게 공황 증상이 감지되었습니다. 연락해 주세요.", user.Username)
+	}
+	if in.Latitude != nil && in.Longitude != nil {
+		msg += fmt.Sprintf(" 위치: https://maps.google.com/?q=%.6f,%.6f", *in.Latitude, *in.Longitude)
+	}
+	return msg
+}
+
+// GetAlerts returns the latest SOS alerts of a user.
+func (s *Service) GetAlerts(userID uint, limit int) ([]model.SOSAlert, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.GetAlerts(userID, limit)
+}
@@ -0,0 +1,71 @@
+package episode
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for panic episodes.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new episode Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateEpisode inserts a new panic episode.
+func (r *Repository) CreateEpisode(ep *model.PanicEpisode) error {
+	return r.db.Create(ep).Error
+}
+
+// SaveEpisode updates an existing panic episode.
+func (r *Repository) SaveEpisode(ep *model.PanicEpisode) error {
+	return r.db.Save(ep).Error
+}
+
+// GetEpisode retrieves a panic episode owned by the user.
+func (r *Repository) GetEpisode(userID, episodeID uint) (*model.PanicEpisode, error) {
+	var ep model.PanicEpisode
+	if err := r.db.Where("id = ? AND user_id = ?", episodeID, userID).First(&ep).Error; err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// GetLatestDetected returns the latest detected episode of a user started at or after since, or nil.
+func (r *Repository) GetLatestDetected(userID uint, since time.Time) (*model.PanicEpisode, error) {
+	var ep model.PanicEpisode
+	err := r.db.Where("user_id = ? AND source = ? AND started_at >= ?", userID, model.EpisodeSourceDetected, since).
+		Order("started_at desc").
+		First(&ep).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// GetEpisodes returns the episodes of a user started within [from, to], newest first.
+func (r *Repository) GetEpisodes(userID uint, from, to time.Time) ([]model.PanicEpisode, error) {
+	var episodes []model.PanicEpisode
+	if err := r.db.Where("user_id = ? AND started_at BETWEEN ? AND ?", userID, from, to).
+		Order("started_at desc").
+		Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// DeleteEpisode deletes an episode owned by the user and reports whether it existed.
+func (r *Repository) DeleteEpisode(userID, episodeID uint) (bool, error) {
+	res := r.db.Where("id = ? AND user_id = ?", episodeID, userID).Delete(&model.PanicEpisode{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,174 @@
+package episode
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// mergeWindow is how long after a detected episode started new detections are merged into it.
+const mergeWindow = 30 * time.Minute
+
+var (
+	// ErrEpisodeNotFound is returned when the episode does not exist or belongs to another user.
+	ErrEpisodeNotFound = errors.New("panic episode not found")
+	// ErrInvalidContext is returned for unknown locations or triggers.
+	ErrInvalidContext = errors.New("invalid episode location or trigger")
+)
+
+// ReportInput holds the fields of a self-reported episode.
+type ReportInput struct {
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Intensity *int
+	Notes     string
+	Location  string
+	Triggers  []string
+}
+
+// AnnotateInput holds the fields of an episode to change; nil fields are left untouched.
+type AnnotateInput struct {
+	Intensity *int
+	Notes     *string
+	Location  *string
+	Triggers  *[]string
+}
+
+// Service manages panic episodes.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new episode Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db)}
+}
+
+// RecordDetected records a panic detected from a vital sign. Detections shortly
+// after an earlier one extend that episode instead of opening a new one.
+func (s *Service) RecordDetected(vital *model.VitalSign) (*model.PanicEpisode, error) {
+	latest, err := s.repo.GetLatestDetected(vital.UserID, vital.MeasuredAt.Add(-mergeWindow))
+	if err != nil {
+		return nil, err
+	}
+	heartRate := vital.HeartRate
+	if latest != nil {
+		latest.EndedAt = &vital.MeasuredAt
+		if latest.PeakHeartRate == nil || *latest.PeakHeartRate < heartRate {
+			latest.PeakHeartRate = &heartRate
+		}
+		if err := s.repo.SaveEpisode(latest); err != nil {
+			return nil, err
+		}
+		return latest, nil
+	}
+	ep := &model.PanicEpisode{
+		UserID:        vital.UserID,
+		Source:        model.EpisodeSourceDetected,
+		StartedAt:     vital.MeasuredAt,
+		PeakHeartRate: &heartRate,
+	}
+	if err := s.repo.CreateEpisode(ep); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// Report records a self-reported episode.
+func (s *Service) Report(userID uint, in ReportInput) (*model.PanicEpisode, error) {
+	if in.StartedAt.IsZero() {
+		in.StartedAt = time.Now()
+	}
+	if in.EndedAt != nil && in.EndedAt.Before(in.StartedAt) {
+		return nil, errors.New("ended_at must not be before started_at")
+	}
+	if !validContext(in.Location, in.Triggers) {
+		return nil, ErrInvalidContext
+	}
+	ep := &model.PanicEpisode{
+		UserID:    userID,
+		Source:    model.EpisodeSourceSelfReported,
+		StartedAt: in.StartedAt,
+		EndedAt:   in.EndedAt,
+		Intensity: in.Intensity,
+		Notes:     in.Notes,
+		Location:  in.Location,
+		Triggers:  in.Triggers,
+	}
+	if err := s.repo.CreateEpisode(ep); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// Annotate updates the self-reported details of an episode owned by the user,
+// e.g. the place and triggers of a detected episode.
+func (s *Service) Annotate(userID, episodeID uint, in AnnotateInput) (*model.PanicEpisode, error) {
+	ep, err := s.repo.GetEpisode(userID, episodeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEpisodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if in.Intensity != nil {
+		ep.Intensity = in.Intensity
+	}
+	if in.Notes != nil {
+		ep.Notes = *in.Notes
+	}
+	if in.Location != nil {
+		ep.Location = *in.Location
+	}
+	if in.Triggers != nil {
+		ep.Triggers = *in.Triggers
+	}
+	if !validContext(ep.Location, ep.Triggers) {
+		return nil, ErrInvalidContext
+	}
+	if err := s.repo.SaveEpisode(ep); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+func validContext(location string, triggers []string) bool {
+	if location != "" && !contains(model.EpisodeLocations, location) {
+		return false
+	}
+	for _, t := range triggers {
+		if !contains(model.EpisodeTriggers, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the episodes of a user started within [from, to].
+func (s *Service) List(userID uint, from, to time.Time) ([]model.PanicEpisode, error) {
+	return s.repo.GetEpisodes(userID, from, to)
+}
+
+// Delete removes an episode owned by the user.
+func (s *Service) Delete(userID, episodeID uint) error {
+	deleted, err := s.repo.DeleteEpisode(userID, episodeID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrEpisodeNotFound
+	}
+	return nil
+}
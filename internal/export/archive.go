@@ -0,0 +1,58 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"ps_backend/model"
+)
+
+const archiveReadme = `PanicShield personal data archive
+
+Each JSON file holds one kind of record linked to your account.
+vitals.csv and vitals.fhir.json contain your vital sign measurements.
+Passwords are stored as one-way hashes and are not included.
+`
+
+// WriteArchive writes a zip archive with one JSON file per section plus the
+// vital signs as CSV and FHIR.
+func WriteArchive(w io.Writer, userID uint, sections []ArchiveSection, vitals []model.VitalSign) error {
+	zw := zip.NewWriter(w)
+	create := func(name string) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	}
+
+	f, err := create("README.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, archiveReadme); err != nil {
+		return err
+	}
+	for _, section := range sections {
+		f, err := create(section.Name + ".json")
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section.Data); err != nil {
+			return err
+		}
+	}
+	if f, err = create("vitals.csv"); err != nil {
+		return err
+	}
+	if err := WriteVitalsCSV(f, vitals); err != nil {
+		return err
+	}
+	if f, err = create("vitals.fhir.json"); err != nil {
+		return err
+	}
+	if err := WriteFHIRBundle(f, userID, vitals); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"ps_backend/model"
+)
+
+var csvHeader = []string{"id", "measured_at", "heart_rate", "breath_rate", "stress_level", "device_id"}
+
+// WriteVitalsCSV writes one row per vital sign, preceded by a header row.
+func WriteVitalsCSV(w io.Writer, vitals []model.VitalSign) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, v := range vitals {
+		row := []string{
+			strconv.FormatUint(uint64(v.ID), 10),
+			v.MeasuredAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(v.HeartRate),
+			strconv.Itoa(v.BreathRate),
+			strconv.Itoa(v.StressLevel),
+			"",
+		}
+		if v.DeviceID != nil {
+			row[5] = strconv.FormatUint(uint64(*v.DeviceID), 10)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
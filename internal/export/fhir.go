@@ -0,0 +1,124 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"ps_backend/model"
+)
+
+// LOINC codes of the exported vital signs.
+const (
+	loincSystem          = "http://loinc.org"
+	loincHeartRate       = "8867-4"
+	loincRespiratoryRate = "9279-1"
+	ucumSystem           = "http://unitsofmeasure.org"
+)
+
+// The FHIR R4 types below cover only the fields the export emits.
+
+type fhirBundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp"`
+	Entry        []bundleEntry `json:"entry"`
+}
+
+type bundleEntry struct {
+	FullURL  string      `json:"fullUrl"`
+	Resource observation `json:"resource"`
+}
+
+type observation struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id"`
+	Status            string            `json:"status"`
+	Category          []codeableConcept `json:"category"`
+	Code              codeableConcept   `json:"code"`
+	Subject           reference         `json:"subject"`
+	EffectiveDateTime string            `json:"effectiveDateTime"`
+	ValueQuantity     quantity          `json:"valueQuantity"`
+}
+
+type codeableConcept struct {
+	Coding []coding `json:"coding"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type coding struct {
+	System  string `json:"system"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type reference struct {
+	Reference string `json:"reference"`
+}
+
+type quantity struct {
+	Value  int    `json:"value"`
+	Unit   string `json:"unit"`
+	System string `json:"system"`
+	Code   string `json:"code"`
+}
+
+var vitalSignsCategory = []codeableConcept{{
+	Coding: []coding{{
+		System:  "http://terminology.hl7.org/CodeSystem/observation-category",
+		Code:    "vital-signs",
+		Display: "Vital Signs",
+	}},
+}}
+
+// WriteFHIRBundle writes a FHIR R4 collection Bundle with a heart rate and a
+// respiratory rate Observation for every vital sign.
+func WriteFHIRBundle(w io.Writer, userID uint, vitals []model.VitalSign) error {
+	bundle := fhirBundle{
+		ResourceType: "Bundle",
+		Type:         "collection",
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Entry:        make([]bundleEntry, 0, 2*len(vitals)),
+	}
+	subject := reference{Reference: fmt.Sprintf("Patient/%d", userID)}
+	for _, v := range vitals {
+		effective := v.MeasuredAt.UTC().Format(time.RFC3339)
+		bundle.Entry = append(bundle.Entry,
+			newObservation(fmt.Sprintf("hr-%d", v.ID), subject, effective, loincHeartRate, "Heart rate", v.HeartRate, "beats/minute"),
+			newObservation(fmt.Sprintf("rr-%d", v.ID), subject, effective, loincRespiratoryRate, "Respiratory rate", v.BreathRate, "breaths/minute"),
+		)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
+
+func newObservation(id string, subject reference, effective, code, display string, value int, unit string) bundleEntry {
+	return bundleEntry{
+		FullURL: "urn:uuid:" + newUUID(),
+		Resource: observation{
+			ResourceType: "Observation",
+			ID:           id,
+			Status:       "final",
+			Category:     vitalSignsCategory,
+			Code: codeableConcept{
+				Coding: []coding{{System: loincSystem, Code: code, Display: display}},
+				Text:   display,
+			},
+			Subject:           subject,
+			EffectiveDateTime: effective,
+			ValueQuantity:     quantity{Value: value, Unit: unit, System: ucumSystem, Code: "/min"},
+		},
+	}
+}
+
+// newUUID returns a random (version 4) UUID for bundle entry URLs.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,249 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"ps_backend/model"
+)
+
+// The PDF report is written by hand: one A4 page with the standard Helvetica
+// fonts and vector charts, which is all the summary needs.
+
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	marginLeft = 60.0
+	chartWidth = pageWidth - 2*marginLeft
+)
+
+// ReportData is the input of the PDF summary report.
+type ReportData struct {
+	Username string
+	From, To time.Time
+	Vitals   []model.VitalSign
+	Episodes []model.PanicEpisode
+}
+
+// dayStat aggregates one calendar day (UTC) of the report period.
+type dayStat struct {
+	day         time.Time
+	stressSum   int
+	measurement int
+	episodes    int
+}
+
+func (d dayStat) avgStress() (float64, bool) {
+	if d.measurement == 0 {
+		return 0, false
+	}
+	return float64(d.stressSum) / float64(d.measurement), true
+}
+
+func dailyStats(data ReportData) []dayStat {
+	start := data.From.UTC().Truncate(24 * time.Hour)
+	end := data.To.UTC().Truncate(24 * time.Hour)
+	var days []dayStat
+	index := make(map[time.Time]int)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		index[d] = len(days)
+		days = append(days, dayStat{day: d})
+	}
+	for _, v := range data.Vitals {
+		if i, ok := index[v.MeasuredAt.UTC().Truncate(24*time.Hour)]; ok {
+			days[i].stressSum += v.StressLevel
+			days[i].measurement++
+		}
+	}
+	for _, ep := range data.Episodes {
+		if i, ok := index[ep.StartedAt.UTC().Truncate(24*time.Hour)]; ok {
+			days[i].episodes++
+		}
+	}
+	return days
+}
+
+// WritePDFReport writes a one-page PDF with summary figures, a chart of the
+// daily average stress level and a chart of daily panic episode counts.
+func WritePDFReport(w io.Writer, data ReportData) error {
+	days := dailyStats(data)
+	c := &pdfCanvas{}
+
+	c.text(marginLeft, 790, 20, true, "PanicShield Health Report")
+	c.text(marginLeft, 765, 10, false, "User: "+data.Username)
+	c.text(marginLeft, 750, 10, false, fmt.Sprintf("Period: %s - %s (UTC)", data.From.UTC().Format("2006-01-02"), data.To.UTC().Format("2006-01-02")))
+	c.text(marginLeft, 735, 10, false, "Generated: "+time.Now().UTC().Format("2006-01-02 15:04")+" UTC")
+
+	var hrSum, rrSum, stressSum, hrMax int
+	for _, v := range data.Vitals {
+		hrSum += v.HeartRate
+		rrSum += v.BreathRate
+		stressSum += v.StressLevel
+		if v.HeartRate > hrMax {
+			hrMax = v.HeartRate
+		}
+	}
+	c.text(marginLeft, 705, 13, true, "Summary")
+	lines := []string{fmt.Sprintf("Measurements: %d", len(data.Vitals))}
+	if n := len(data.Vitals); n > 0 {
+		lines = append(lines,
+			fmt.Sprintf("Average heart rate: %.1f bpm (max %d bpm)", float64(hrSum)/float64(n), hrMax),
+			fmt.Sprintf("Average respiratory rate: %.1f /min", float64(rrSum)/float64(n)),
+			fmt.Sprintf("Average stress level: %.1f / 100", float64(stressSum)/float64(n)),
+		)
+	}
+	lines = append(lines, fmt.Sprintf("Panic episodes: %d", len(data.Episodes)))
+	for i, line := range lines {
+		c.text(marginLeft+10, 685-float64(i)*15, 10, false, line)
+	}
+
+	c.text(marginLeft, 580, 13, true, "Daily average stress level (0-100)")
+	c.stressChart(days, 400, 160)
+
+	c.text(marginLeft, 330, 13, true, "Panic episodes per day")
+	c.episodeChart(days, 150, 160)
+
+	return c.writeDocument(w)
+}
+
+// pdfCanvas collects the drawing operators of the page content stream.
+type pdfCanvas struct {
+	ops bytes.Buffer
+}
+
+func (c *pdfCanvas) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&c.ops, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+func (c *pdfCanvas) axes(bottom, height float64, yLabels map[float64]string) {
+	fmt.Fprintf(&c.ops, "0.6 G 0.5 w\n")
+	for frac, label := range yLabels {
+		y := bottom + frac*height
+		fmt.Fprintf(&c.ops, "%.2f %.2f m %.2f %.2f l S\n", marginLeft, y, marginLeft+chartWidth, y)
+		c.text(marginLeft-25, y-3, 8, false, label)
+	}
+	fmt.Fprintf(&c.ops, "0 G 1 w %.2f %.2f m %.2f %.2f l %.2f %.2f l S\n",
+		marginLeft, bottom+height, marginLeft, bottom, marginLeft+chartWidth, bottom)
+}
+
+func (c *pdfCanvas) dayLabels(days []dayStat, bottom float64) {
+	if len(days) == 0 {
+		return
+	}
+	c.text(marginLeft, bottom-14, 8, false, days[0].day.Format("2006-01-02"))
+	if len(days) > 1 {
+		c.text(marginLeft+chartWidth-45, bottom-14, 8, false, days[len(days)-1].day.Format("2006-01-02"))
+	}
+}
+
+func (c *pdfCanvas) stressChart(days []dayStat, bottom, height float64) {
+	c.axes(bottom, height, map[float64]string{0: "0", 0.5: "50", 1: "100"})
+	c.dayLabels(days, bottom)
+	step := chartWidth / float64(max(len(days)-1, 1))
+	fmt.Fprintf(&c.ops, "0.85 0.33 0.1 RG 0.85 0.33 0.1 rg 1.5 w\n")
+	var points [][2]float64
+	drawing := false
+	for i, d := range days {
+		avg, ok := d.avgStress()
+		if !ok {
+			// Days without measurements break the line.
+			if drawing {
+				c.ops.WriteString("S\n")
+				drawing = false
+			}
+			continue
+		}
+		x, y := marginLeft+float64(i)*step, bottom+avg/100*height
+		op := "l"
+		if !drawing {
+			op = "m"
+			drawing = true
+		}
+		fmt.Fprintf(&c.ops, "%.2f %.2f %s\n", x, y, op)
+		points = append(points, [2]float64{x, y})
+	}
+	if drawing {
+		c.ops.WriteString("S\n")
+	}
+	// Mark every day so isolated measurements stay visible.
+	for _, p := range points {
+		fmt.Fprintf(&c.ops, "%.2f %.2f 3 3 re f\n", p[0]-1.5, p[1]-1.5)
+	}
+	c.ops.WriteString("0 G 0 g\n")
+}
+
+func (c *pdfCanvas) episodeChart(days []dayStat, bottom, height float64) {
+	maxCount := 1
+	for _, d := range days {
+		maxCount = max(maxCount, d.episodes)
+	}
+	c.axes(bottom, height, map[float64]string{0: "0", 1: fmt.Sprint(maxCount)})
+	c.dayLabels(days, bottom)
+	if len(days) == 0 {
+		return
+	}
+	slot := chartWidth / float64(len(days))
+	barWidth := slot * 0.7
+	c.ops.WriteString("0.2 0.4 0.75 rg\n")
+	for i, d := range days {
+		if d.episodes == 0 {
+			continue
+		}
+		h := float64(d.episodes) / float64(maxCount) * height
+		fmt.Fprintf(&c.ops, "%.2f %.2f %.2f %.2f re f\n", marginLeft+float64(i)*slot+(slot-barWidth)/2, bottom, barWidth, h)
+	}
+	c.ops.WriteString("0 g\n")
+}
+
+// writeDocument wraps the content stream into a complete single-page PDF file.
+func (c *pdfCanvas) writeDocument(w io.Writer) error {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", c.ops.Len(), c.ops.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfEscape escapes a string for a PDF literal. The standard fonts only cover
+// Latin characters, so anything outside printable ASCII is replaced by '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
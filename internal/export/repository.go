@@ -0,0 +1,164 @@
+package export
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for export jobs.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new export Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUser retrieves the owner of an export.
+func (r *Repository) GetUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateJob inserts a new export job.
+func (r *Repository) CreateJob(job *model.ExportJob) error {
+	return r.db.Create(job).Error
+}
+
+// SaveJob updates an existing export job.
+func (r *Repository) SaveJob(job *model.ExportJob) error {
+	return r.db.Save(job).Error
+}
+
+// GetJob retrieves an export job by ID.
+func (r *Repository) GetJob(id uint) (*model.ExportJob, error) {
+	var job model.ExportJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetUserJob retrieves an export job owned by the user.
+func (r *Repository) GetUserJob(userID, id uint) (*model.ExportJob, error) {
+	var job model.ExportJob
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetUserJobs returns the latest export jobs of a user.
+func (r *Repository) GetUserJobs(userID uint, limit int) ([]model.ExportJob, error) {
+	var jobs []model.ExportJob
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetExpiredJobs returns jobs whose files expired before the given time.
+func (r *Repository) GetExpiredJobs(before time.Time) ([]model.ExportJob, error) {
+	var jobs []model.ExportJob
+	if err := r.db.Where("expires_at < ?", before).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// DeleteJob deletes an export job.
+func (r *Repository) DeleteJob(id uint) error {
+	return r.db.Delete(&model.ExportJob{}, id).Error
+}
+
+// ArchiveSection is one file of a personal data archive.
+type ArchiveSection struct {
+	Name string
+	Data interface{}
+}
+
+// GetArchiveSections loads every record that belongs to the user. The password
+// hash is never included.
+func (r *Repository) GetArchiveSections(userID uint) ([]ArchiveSection, error) {
+	user, err := r.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	sections := []ArchiveSection{{Name: "profile", Data: map[string]interface{}{
+		"id":                    user.ID,
+		"username":              user.Username,
+		"phone_number":          user.PhoneNumber,
+		"verified":              user.Verified,
+		"display_name":          user.DisplayName,
+		"persona":               user.Persona,
+		"timezone":              user.Timezone,
+		"locale":                user.Locale,
+		"plan":                  user.Plan,
+		"role":                  user.Role,
+		"created_at":            user.CreatedAt,
+		"deletion_scheduled_at": user.DeletionScheduledAt,
+	}}}
+
+	var (
+		chatLogs   []model.ChatbotLog
+		interests  []model.Interest
+		subs       []model.SubInterest
+		bookmarks  []model.UserPanicGuide
+		usage      []model.LLMUsage
+		breathing  []model.BreathingSession
+		contacts   []model.EmergencyContact
+		alerts     []model.SOSAlert
+		episodes   []model.PanicEpisode
+		shares     []model.CareShare
+		accessLogs []model.CareShareAccessLog
+		exports    []model.ExportJob
+		logins     []model.LoginHistory
+		devices    []model.Device
+		notices    []model.Notification
+		noticePref []model.NotificationPreference
+		schedules  []model.CheckInSchedule
+		checkIns   []model.CheckIn
+		surveys    []model.QuestionnaireResponse
+		insights   []model.UserInsight
+	)
+	queries := []struct {
+		name  string
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{"chat_logs", &chatLogs, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"interests", &interests, r.db.Joins("JOIN user_interests ui ON ui.interest_id = interests.id").Where("ui.user_id = ?", userID)},
+		{"sub_interests", &subs, r.db.Joins("JOIN user_sub_interests us ON us.sub_interest_id = sub_interests.id").Where("us.user_id = ?", userID)},
+		{"bookmarks", &bookmarks, r.db.Where("user_id = ?", userID)},
+		{"llm_usage", &usage, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"breathing_sessions", &breathing, r.db.Where("user_id = ?", userID).Order("started_at")},
+		{"emergency_contacts", &contacts, r.db.Where("user_id = ?", userID)},
+		{"sos_alerts", &alerts, r.db.Preload("Deliveries").Where("user_id = ?", userID).Order("created_at")},
+		{"panic_episodes", &episodes, r.db.Where("user_id = ?", userID).Order("started_at")},
+		{"care_shares", &shares, r.db.Where("user_id = ?", userID)},
+		{"care_share_access_logs", &accessLogs, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"exports", &exports, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"login_history", &logins, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"devices", &devices, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"notifications", &notices, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"notification_preferences", &noticePref, r.db.Where("user_id = ?", userID)},
+		{"checkin_schedules", &schedules, r.db.Where("user_id = ?", userID).Order("time")},
+		{"checkins", &checkIns, r.db.Where("user_id = ?", userID).Order("responded_at")},
+		{"questionnaires", &surveys, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{"insights", &insights, r.db.Where("user_id = ?", userID)},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+		sections = append(sections, ArchiveSection{Name: q.name, Data: q.dest})
+	}
+	return sections, nil
+}
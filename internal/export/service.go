@@ -0,0 +1,325 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ps_backend/internal/episode"
+	"ps_backend/internal/jobs"
+	"ps_backend/internal/vital"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxRangeDays limits the period of a single export.
+const maxRangeDays = 366
+
+// Background job types.
+const (
+	JobRun     = "export.run"     // generates the file of an export
+	JobCleanup = "export.cleanup" // removes expired exports
+)
+
+// RunPayload is the payload of a JobRun job.
+type RunPayload struct {
+	ExportJobID uint `json:"export_job_id"`
+}
+
+var (
+	// ErrJobNotFound is returned when the export does not exist or belongs to another user.
+	ErrJobNotFound = errors.New("export not found")
+	// ErrJobNotReady is returned when a download is requested before the export completed.
+	ErrJobNotReady = errors.New("export is not ready")
+	// ErrInvalidLink is returned for a bad or expired download signature.
+	ErrInvalidLink = errors.New("invalid or expired download link")
+	// ErrInvalidRequest is returned for an unknown format or an invalid period.
+	ErrInvalidRequest = errors.New("invalid export request")
+)
+
+var formats = map[string]struct{ ext, contentType string }{
+	model.ExportFormatCSV:  {".csv", "text/csv"},
+	model.ExportFormatFHIR: {".json", "application/fhir+json"},
+	model.ExportFormatPDF:  {".pdf", "application/pdf"},
+
+	model.ExportFormatArchive: {".zip", "application/zip"},
+}
+
+// Service generates health report exports in the background and hands them out via signed links.
+type Service struct {
+	repo      *Repository
+	vitals    *vital.Service
+	episodes  *episode.Service
+	queue     *jobs.Queue
+	dir       string
+	linkTTL   time.Duration
+	retention time.Duration
+}
+
+// NewService creates a new export Service. Files are written to EXPORT_DIR,
+// kept for EXPORT_RETENTION (default 72h) and download links are valid for
+// EXPORT_LINK_TTL (default 1h).
+func NewService(db *gorm.DB) *Service {
+	cfg := config.Get().Export
+	return &Service{
+		repo:      NewRepository(db),
+		vitals:    vital.NewService(db),
+		episodes:  episode.NewService(db),
+		queue:     jobs.NewQueue(db),
+		dir:       cfg.Dir,
+		linkTTL:   cfg.LinkTTL,
+		retention: cfg.Retention,
+	}
+}
+
+// Request creates an export job and generates the file asynchronously.
+func (s *Service) Request(userID uint, format string, from, to time.Time) (*model.ExportJob, error) {
+	if _, ok := formats[format]; !ok || format == model.ExportFormatArchive {
+		return nil, ErrInvalidRequest
+	}
+	if to.Before(from) || to.Sub(from) > maxRangeDays*24*time.Hour {
+		return nil, ErrInvalidRequest
+	}
+	return s.start(userID, format, from, to)
+}
+
+// RequestArchive creates an export job bundling all personal data of the user
+// since the account was created.
+func (s *Service) RequestArchive(userID uint) (*model.ExportJob, error) {
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.start(userID, model.ExportFormatArchive, user.CreatedAt, time.Now())
+}
+
+func (s *Service) start(userID uint, format string, from, to time.Time) (*model.ExportJob, error) {
+	job := &model.ExportJob{
+		UserID: userID,
+		Format: format,
+		From:   from,
+		To:     to,
+		Status: model.ExportStatusPending,
+	}
+	if err := s.repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+	if _, err := s.queue.Enqueue(JobRun, RunPayload{ExportJobID: job.ID}); err != nil {
+		job.Status = model.ExportStatusFailed
+		job.Error = "could not be queued"
+		if saveErr := s.repo.SaveJob(job); saveErr != nil {
+			logrus.WithError(saveErr).WithField("jobID", job.ID).Error("Failed to save export job")
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// RegisterJobs registers the export job handlers and the hourly cleanup.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobRun, jobs.Options{MaxAttempts: 3, Timeout: 15 * time.Minute}, func(ctx context.Context, p RunPayload) error {
+		return s.run(p.ExportJobID)
+	})
+	jobs.Handle(r, JobCleanup, jobs.Options{MaxAttempts: 1}, func(ctx context.Context, _ struct{}) error {
+		n, err := s.PurgeExpired()
+		if n > 0 {
+			logrus.Infof("Purged %d expired exports", n)
+		}
+		return err
+	})
+	r.MustSchedule("export-cleanup", "15 * * * *", JobCleanup)
+}
+
+// run generates the file of an export and records the outcome. Generation
+// failures are recorded on the export; only database errors are returned so
+// the job is retried.
+func (s *Service) run(jobID uint) error {
+	job, err := s.repo.GetJob(jobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // deleted with its user in the meantime
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status != model.ExportStatusPending && job.Status != model.ExportStatusRunning {
+		return nil
+	}
+	job.Status = model.ExportStatusRunning
+	if err := s.repo.SaveJob(job); err != nil {
+		return err
+	}
+
+	if err := s.generate(job); err != nil {
+		logrus.WithError(err).WithField("jobID", jobID).Error("Export failed")
+		job.Status = model.ExportStatusFailed
+		job.Error = err.Error()
+	} else {
+		now := time.Now()
+		expires := now.Add(s.retention)
+		job.Status = model.ExportStatusCompleted
+		job.CompletedAt = &now
+		job.ExpiresAt = &expires
+	}
+	return s.repo.SaveJob(job)
+}
+
+func (s *Service) generate(job *model.ExportJob) error {
+	f := formats[job.Format]
+	vitals, err := s.vitals.GetVitalsBetween(job.UserID, job.From, job.To)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	job.FileName = fmt.Sprintf("panicshield-%s-%s%s", job.From.UTC().Format("20060102"), job.To.UTC().Format("20060102"), f.ext)
+	if job.Format == model.ExportFormatArchive {
+		job.FileName = fmt.Sprintf("panicshield-data-%s%s", job.To.UTC().Format("20060102"), f.ext)
+	}
+	job.ContentType = f.contentType
+	job.FilePath = filepath.Join(s.dir, fmt.Sprintf("export-%d%s", job.ID, f.ext))
+
+	file, err := os.OpenFile(job.FilePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := s.write(file, job, vitals); err != nil {
+		file.Close()
+		os.Remove(job.FilePath)
+		return err
+	}
+	info, err := file.Stat()
+	if err == nil {
+		job.SizeBytes = info.Size()
+	}
+	return file.Close()
+}
+
+func (s *Service) write(w io.Writer, job *model.ExportJob, vitals []model.VitalSign) error {
+	switch job.Format {
+	case model.ExportFormatCSV:
+		return WriteVitalsCSV(w, vitals)
+	case model.ExportFormatFHIR:
+		return WriteFHIRBundle(w, job.UserID, vitals)
+	case model.ExportFormatArchive:
+		sections, err := s.repo.GetArchiveSections(job.UserID)
+		if err != nil {
+			return err
+		}
+		return WriteArchive(w, job.UserID, sections, vitals)
+	default:
+		user, err := s.repo.GetUser(job.UserID)
+		if err != nil {
+			return err
+		}
+		episodes, err := s.episodes.List(job.UserID, job.From, job.To)
+		if err != nil {
+			return err
+		}
+		return WritePDFReport(w, ReportData{
+			Username: user.Username,
+			From:     job.From,
+			To:       job.To,
+			Vitals:   vitals,
+			Episodes: episodes,
+		})
+	}
+}
+
+// WriteUserArchive writes the data archive of the user directly to w, without
+// creating an export job.
+func (s *Service) WriteUserArchive(w io.Writer, userID uint) error {
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	job := &model.ExportJob{UserID: userID, Format: model.ExportFormatArchive, From: user.CreatedAt, To: time.Now()}
+	vitals, err := s.vitals.GetVitalsBetween(userID, job.From, job.To)
+	if err != nil {
+		return err
+	}
+	return s.write(w, job, vitals)
+}
+
+// Get returns an export job of the user.
+func (s *Service) Get(userID, jobID uint) (*model.ExportJob, error) {
+	job, err := s.repo.GetUserJob(userID, jobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// List returns the latest export jobs of the user.
+func (s *Service) List(userID uint) ([]model.ExportJob, error) {
+	return s.repo.GetUserJobs(userID, 50)
+}
+
+// DownloadURL returns a signed download path for a completed job and its expiry.
+// The link never outlives the file.
+func (s *Service) DownloadURL(job *model.ExportJob) (string, time.Time, error) {
+	if job.Status != model.ExportStatusCompleted || job.ExpiresAt == nil {
+		return "", time.Time{}, ErrJobNotReady
+	}
+	expires := time.Now().Add(s.linkTTL)
+	if job.ExpiresAt.Before(expires) {
+		expires = *job.ExpiresAt
+	}
+	sig, err := Sign(job.ID, expires.Unix())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	q := url.Values{}
+	q.Set("expires", fmt.Sprint(expires.Unix()))
+	q.Set("signature", sig)
+	return fmt.Sprintf("/api/exports/%d/download?%s", job.ID, q.Encode()), expires, nil
+}
+
+// OpenSigned verifies a download link and returns the job whose file may be served.
+func (s *Service) OpenSigned(jobID uint, expires int64, signature string) (*model.ExportJob, error) {
+	if !Verify(jobID, expires, signature) {
+		return nil, ErrInvalidLink
+	}
+	job, err := s.repo.GetJob(jobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != model.ExportStatusCompleted || job.ExpiresAt == nil || time.Now().After(*job.ExpiresAt) {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// PurgeExpired deletes expired export files and their jobs. Failed jobs are kept
+// for the user to see until they are removed with the account.
+func (s *Service) PurgeExpired() (int, error) {
+	jobs, err := s.repo.GetExpiredJobs(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, job := range jobs {
+		if job.FilePath != "" {
+			if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+				logrus.WithError(err).WithField("jobID", job.ID).Warn("Failed to remove export file")
+				continue
+			}
+		}
+		if err := s.repo.DeleteJob(job.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"ps_backend/pkg/config"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// ErrSigningKeyMissing is returned when neither EXPORT_SIGNING_SECRET nor ACCESS_SECRET is set.
+var ErrSigningKeyMissing = errors.New("export signing key not configured")
+
+// signingKeyInfo separates the key derived from the access secret from any other
+// use of that secret.
+const signingKeyInfo = "ps_backend export link signing key"
+
+// signingKey returns EXPORT_SIGNING_SECRET. Without it, which config validation
+// only allows in development, a separate key is derived from the access secret
+// with HKDF, so a link signature never reveals anything about the JWT key.
+func signingKey() ([]byte, error) {
+	cfg := config.Get()
+	if cfg.Export.SigningSecret != "" {
+		return []byte(cfg.Export.SigningSecret), nil
+	}
+	if cfg.Auth.AccessSecret == "" {
+		return nil, ErrSigningKeyMissing
+	}
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(cfg.Auth.AccessSecret), nil, []byte(signingKeyInfo)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func mac(key []byte, jobID uint, expires int64) []byte {
+	h := hmac.New(sha256.New, key)
+	fmt.Fprintf(h, "export:%d:%d", jobID, expires)
+	return h.Sum(nil)
+}
+
+// Sign returns the signature of a download link for the job valid until expires (Unix seconds).
+func Sign(jobID uint, expires int64) (string, error) {
+	key, err := signingKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac(key, jobID, expires)), nil
+}
+
+// Verify reports whether the signature matches the job and has not expired.
+func Verify(jobID uint, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	key, err := signingKey()
+	if err != nil {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sig, mac(key, jobID, expires))
+}
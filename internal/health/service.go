@@ -0,0 +1,171 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"ps_backend/db"
+	"ps_backend/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+// Check statuses. A degraded check is reported but does not make the server
+// unready.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Config controls the readiness checks.
+type Config struct {
+	Timeout    time.Duration // deadline of the database checks and of one LLM probe
+	LLMTTL     time.Duration // how long an LLM probe result is reused
+	RequireLLM bool          // an unreachable LLM fails readiness instead of degrading it
+}
+
+// LoadConfig reads READYZ_TIMEOUT (default 2s), READYZ_LLM_CACHE_TTL (default
+// 30s) and READYZ_REQUIRE_LLM (default false).
+func LoadConfig() Config {
+	c := config.Get().Readyz
+	return Config{Timeout: c.Timeout, LLMTTL: c.LLMCacheTTL, RequireLLM: c.RequireLLM}
+}
+
+// Check is the result of one readiness check.
+type Check struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the result of all readiness checks.
+type Report struct {
+	Ready  bool    `json:"ready"`
+	Checks []Check `json:"checks"`
+}
+
+// Service runs the readiness checks of the server.
+type Service struct {
+	db     *gorm.DB
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex // held during an LLM probe so concurrent checks share it
+	llmCheck *Check
+}
+
+// NewService creates a new health Service.
+func NewService(db *gorm.DB) *Service {
+	cfg := LoadConfig()
+	return &Service{db: db, cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Ready checks that the database answers, that its schema is the one this
+// build expects and that the LLM API is reachable. The LLM result is cached, as
+// the checks run every few seconds and the API is outside our control.
+func (s *Service) Ready(ctx context.Context) Report {
+	database := s.checkDatabase(ctx)
+	checks := []Check{database}
+	if database.Status == StatusOK {
+		checks = append(checks, s.checkMigrations(ctx))
+	} else {
+		checks = append(checks, Check{Name: "migrations", Status: StatusFail, Error: "database unavailable", CheckedAt: time.Now()})
+	}
+	checks = append(checks, s.checkLLM(ctx))
+
+	report := Report{Ready: true, Checks: checks}
+	for _, c := range checks {
+		if c.Status == StatusFail {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func (s *Service) checkDatabase(ctx context.Context) Check {
+	return run("database", func() error {
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+		sqlDB, err := s.db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+func (s *Service) checkMigrations(ctx context.Context) Check {
+	return run("migrations", func() error {
+		ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+		migrator, err := db.NewMigrator(s.db.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		return migrator.Check()
+	})
+}
+
+// checkLLM returns the cached probe result, probing again once it is older than
+// the TTL.
+func (s *Service) checkLLM(ctx context.Context) Check {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.llmCheck != nil && time.Since(s.llmCheck.CheckedAt) < s.cfg.LLMTTL {
+		return *s.llmCheck
+	}
+	// The result is shared, so a caller hanging up must not fail the probe.
+	check := run("llm", func() error { return s.probeLLM(context.WithoutCancel(ctx)) })
+	if check.Status == StatusFail && !s.cfg.RequireLLM {
+		check.Status = StatusDegraded
+	}
+	s.llmCheck = &check
+	return check
+}
+
+// probeLLM sends an unauthenticated request to the host of the Gemini API, taken
+// from the same setting the chatbot uses. Any answer below 500 shows the API is
+// reachable; the key is not checked, so a probe does not count against the quota.
+func (s *Service) probeLLM(ctx context.Context) error {
+	u, err := url.Parse(config.Get().GeminiURL())
+	if err != nil {
+		return err
+	}
+	probe := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s answered %s", probe.Host, resp.Status)
+	}
+	return nil
+}
+
+// run times fn and turns its error into a check result.
+func run(name string, fn func() error) Check {
+	started := time.Now()
+	err := fn()
+	check := Check{Name: name, Status: StatusOK, LatencyMs: time.Since(started).Milliseconds(), CheckedAt: time.Now()}
+	if err != nil {
+		check.Status = StatusFail
+		check.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			check.Error = "timed out"
+		}
+	}
+	return check
+}
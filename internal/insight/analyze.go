@@ -0,0 +1,215 @@
+package insight
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ps_backend/model"
+)
+
+const (
+	// minPatternEpisodes is how many episodes must match before a pattern is reported.
+	minPatternEpisodes = 3
+	// minPatternShare is the share of episodes a time, place or trigger needs to be reported.
+	minPatternShare = 0.4
+	// minTriggerShare is lower because an episode can have several triggers.
+	minTriggerShare = 0.3
+	// minSignalShare is the share needed for vitals and sleep patterns, among
+	// episodes that have such data.
+	minSignalShare = 0.5
+	// risingHeartRate is the heart rate increase, in bpm, counted as rising before an episode.
+	risingHeartRate = 10
+	// poorSleep is the highest sleep rating counted as poor sleep.
+	poorSleep = 2
+)
+
+// EpisodeFacts is what is known about the context of one episode.
+type EpisodeFacts struct {
+	LocalStart    time.Time // start in the user's time zone
+	Location      string
+	Triggers      []string
+	HeartRateRise *float64 // recent minus baseline average before the start; nil without vitals
+	SleepQuality  *int     // first sleep rating reported since the previous evening, before the episode; nil if none
+}
+
+var weekdayKeys = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func partOfDay(t time.Time) string {
+	switch h := t.Hour(); {
+	case h < 6:
+		return "night"
+	case h < 12:
+		return "morning"
+	case h < 18:
+		return "afternoon"
+	default:
+		return "evening"
+	}
+}
+
+func dayType(t time.Time) string {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return "weekend"
+	}
+	return "weekday"
+}
+
+// Analyze counts the episodes by context and reports the patterns that stand
+// out, strongest first. No patterns are reported below minEpisodes episodes.
+func Analyze(episodes []EpisodeFacts, minEpisodes int) (model.EpisodeStats, []model.Insight) {
+	stats := model.EpisodeStats{
+		Episodes:    len(episodes),
+		ByPartOfDay: make(map[string]int),
+		ByWeekday:   make(map[string]int),
+		ByLocation:  make(map[string]int),
+		ByTrigger:   make(map[string]int),
+	}
+	cells := make(map[string]int)
+	cellsPoorSleep := make(map[string]int)
+	for _, ep := range episodes {
+		cell := dayType(ep.LocalStart) + "_" + partOfDay(ep.LocalStart)
+		cells[cell]++
+		stats.ByPartOfDay[partOfDay(ep.LocalStart)]++
+		stats.ByWeekday[weekdayKeys[ep.LocalStart.Weekday()]]++
+		if ep.Location != "" {
+			stats.ByLocation[ep.Location]++
+		}
+		for _, t := range ep.Triggers {
+			stats.ByTrigger[t]++
+		}
+		if ep.HeartRateRise != nil {
+			stats.WithVitals++
+			if *ep.HeartRateRise >= risingHeartRate {
+				stats.RisingHeartRate++
+			}
+		}
+		if ep.SleepQuality != nil {
+			stats.WithSleep++
+			if *ep.SleepQuality <= poorSleep {
+				stats.AfterPoorSleep++
+				cellsPoorSleep[cell]++
+			}
+		}
+	}
+
+	total := len(episodes)
+	if total < minEpisodes || total == 0 {
+		return stats, []model.Insight{}
+	}
+	insights := []model.Insight{}
+	add := func(kind, key string, count, of int, threshold float64, ko, en string) bool {
+		if count < minPatternEpisodes || of == 0 {
+			return false
+		}
+		share := float64(count) / float64(of)
+		if share < threshold {
+			return false
+		}
+		pct := int(math.Round(share * 100))
+		insights = append(insights, model.Insight{
+			Kind:     kind,
+			Key:      key,
+			Share:    math.Round(share*100) / 100,
+			Episodes: count,
+			Total:    of,
+			Messages: map[string]string{"ko": fmt.Sprintf(ko, pct), "en": fmt.Sprintf(en, pct)},
+		})
+		return true
+	}
+
+	// A time pattern mentions poor sleep when the two together still cover enough episodes.
+	cell, count := top(cells)
+	sleepMentioned := false
+	if cell != "" {
+		ko, en := cellLabels(cell)
+		if add(model.InsightTimePattern, cell+"_after_poor_sleep", cellsPoorSleep[cell], total, minPatternShare,
+			"공황 에피소드의 %d%%가 잠을 설친 날 "+ko+"에 일어났어요.", "%d%% of episodes happen on "+en+" after poor sleep.") {
+			sleepMentioned = true
+		} else {
+			add(model.InsightTimePattern, cell, count, total, minPatternShare,
+				"공황 에피소드의 %d%%가 "+ko+"에 일어났어요.", "%d%% of episodes happen on "+en+".")
+		}
+	}
+	if loc, count := top(stats.ByLocation); loc != "" {
+		ko, en := locationLabel(loc)
+		add(model.InsightLocation, loc, count, total, minPatternShare,
+			"공황 에피소드의 %d%%가 "+ko+"에서 일어났어요.", "%d%% of episodes happen "+en+".")
+	}
+	if trig, count := top(stats.ByTrigger); trig != "" {
+		ko, en := triggerLabel(trig)
+		add(model.InsightTrigger, trig, count, total, minTriggerShare,
+			"공황 에피소드의 %d%%에 '"+ko+"'이(가) 계기로 기록됐어요.", en+" was reported as a trigger in %d%% of episodes.")
+	}
+	add(model.InsightRisingHeartRate, "heart_rate_rise", stats.RisingHeartRate, stats.WithVitals, minSignalShare,
+		"바이탈이 있는 에피소드의 %d%%는 시작 전 30분 동안 심박수가 10bpm 이상 올랐어요.",
+		"In %d%% of episodes with vitals, heart rate rose by 10 bpm or more in the 30 minutes before.")
+	if !sleepMentioned {
+		add(model.InsightPoorSleep, "poor_sleep", stats.AfterPoorSleep, stats.WithSleep, minSignalShare,
+			"수면 기록이 있는 에피소드의 %d%%는 잠을 설친 날 일어났어요.",
+			"%d%% of episodes with a sleep record happen after poor sleep.")
+	}
+	sort.SliceStable(insights, func(i, j int) bool { return insights[i].Share > insights[j].Share })
+	return stats, insights
+}
+
+// top returns the most frequent key, preferring the alphabetically first on ties.
+func top(counts map[string]int) (string, int) {
+	best, bestCount := "", 0
+	for k, n := range counts {
+		if n > bestCount || (n == bestCount && k < best) {
+			best, bestCount = k, n
+		}
+	}
+	return best, bestCount
+}
+
+func cellLabels(cell string) (string, string) {
+	day, part, _ := strings.Cut(cell, "_")
+	koDay, enDay := "평일", "weekday"
+	if day == "weekend" {
+		koDay, enDay = "주말", "weekend"
+	}
+	koPart := map[string]string{"night": "밤", "morning": "아침", "afternoon": "오후", "evening": "저녁"}[part]
+	enPart := map[string]string{"night": "nights", "morning": "mornings", "afternoon": "afternoons", "evening": "evenings"}[part]
+	return koDay + " " + koPart, enDay + " " + enPart
+}
+
+var locationLabels = map[string][2]string{
+	"home":         {"집", "at home"},
+	"work":         {"직장", "at work"},
+	"school":       {"학교", "at school"},
+	"commute":      {"출퇴근길", "while commuting"},
+	"public_place": {"공공장소", "in public places"},
+	"outdoors":     {"야외", "outdoors"},
+	"other":        {"기타 장소", "in other places"},
+}
+
+func locationLabel(key string) (string, string) {
+	if l, ok := locationLabels[key]; ok {
+		return l[0], l[1]
+	}
+	return key, "at " + key
+}
+
+var triggerLabels = map[string][2]string{
+	"caffeine":      {"카페인", "Caffeine"},
+	"alcohol":       {"음주", "Alcohol"},
+	"lack_of_sleep": {"수면 부족", "Lack of sleep"},
+	"work_stress":   {"업무 스트레스", "Work stress"},
+	"conflict":      {"갈등", "Conflict"},
+	"crowds":        {"사람 많은 곳", "Crowds"},
+	"driving":       {"운전", "Driving"},
+	"health_worry":  {"건강 걱정", "Health worry"},
+	"exercise":      {"운동", "Exercise"},
+	"other":         {"기타", "Other"},
+}
+
+func triggerLabel(key string) (string, string) {
+	if l, ok := triggerLabels[key]; ok {
+		return l[0], l[1]
+	}
+	return key, key
+}
@@ -0,0 +1,96 @@
+package insight
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for episode analytics.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new insight Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUser retrieves a user by ID.
+func (r *Repository) GetUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetEpisodes returns the episodes of a user started within [from, to].
+func (r *Repository) GetEpisodes(userID uint, from, to time.Time) ([]model.PanicEpisode, error) {
+	var episodes []model.PanicEpisode
+	err := r.db.Where("user_id = ? AND started_at BETWEEN ? AND ?", userID, from, to).
+		Order("started_at").Find(&episodes).Error
+	return episodes, err
+}
+
+// HeartRateRise compares the average heart rate of the user in the 10 minutes
+// before start with the 20 minutes before that. It returns nil when either
+// period has no samples.
+func (r *Repository) HeartRateRise(userID uint, start time.Time) (*float64, error) {
+	var row struct {
+		Baseline *float64
+		Recent   *float64
+	}
+	split := start.Add(-10 * time.Minute)
+	err := r.db.Model(&model.VitalSign{}).
+		Select("AVG(heart_rate) FILTER (WHERE measured_at < ?) AS baseline, AVG(heart_rate) FILTER (WHERE measured_at >= ?) AS recent", split, split).
+		Where("user_id = ? AND measured_at >= ? AND measured_at < ?", userID, start.Add(-30*time.Minute), start).
+		Scan(&row).Error
+	if err != nil || row.Baseline == nil || row.Recent == nil {
+		return nil, err
+	}
+	rise := *row.Recent - *row.Baseline
+	return &rise, nil
+}
+
+// FirstSleepQuality returns the first sleep rating the user reported within
+// [from, to), or nil.
+func (r *Repository) FirstSleepQuality(userID uint, from, to time.Time) (*int, error) {
+	var items []model.CheckIn
+	err := r.db.Where("user_id = ? AND sleep_quality IS NOT NULL AND responded_at >= ? AND responded_at < ?", userID, from, to).
+		Order("responded_at").Limit(1).Find(&items).Error
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[0].SleepQuality, nil
+}
+
+// UsersWithEpisodes returns the users with an episode started at or after since.
+func (r *Repository) UsersWithEpisodes(since time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.PanicEpisode{}).Where("started_at >= ?", since).
+		Distinct().Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// GetInsight returns the cached insights of a user, or gorm.ErrRecordNotFound.
+func (r *Repository) GetInsight(userID uint) (*model.UserInsight, error) {
+	var ui model.UserInsight
+	if err := r.db.First(&ui, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &ui, nil
+}
+
+// SaveInsight inserts or replaces the cached insights of a user.
+func (r *Repository) SaveInsight(ui *model.UserInsight) error {
+	return r.db.Save(ui).Error
+}
+
+// DeleteInsightsBefore removes cached insights computed before the time.
+func (r *Repository) DeleteInsightsBefore(before time.Time) (int64, error) {
+	res := r.db.Where("computed_at < ?", before).Delete(&model.UserInsight{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,135 @@
+package insight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ps_backend/internal/jobs"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// sleepWindowStartHour is the local hour on the day before an episode from
+// which sleep ratings count for the episode.
+const sleepWindowStartHour = 18
+
+// Config controls the analysis window and the nightly batch.
+type Config struct {
+	WindowDays  int    // episodes started within this many days are analyzed
+	MinEpisodes int    // fewer episodes produce statistics but no insights
+	BatchAt     string // HH:MM server time of the nightly batch
+}
+
+// LoadConfig reads INSIGHTS_WINDOW_DAYS (default 90), INSIGHTS_MIN_EPISODES
+// (default 5) and INSIGHTS_BATCH_AT (default 03:00).
+func LoadConfig() Config {
+	c := config.Get().Insights
+	return Config{WindowDays: c.WindowDays, MinEpisodes: c.MinEpisodes, BatchAt: c.BatchAt}
+}
+
+// Service computes and caches per-user episode insights.
+type Service struct {
+	repo *Repository
+	cfg  Config
+}
+
+// NewService creates a new insight Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db), cfg: LoadConfig()}
+}
+
+// Get returns the cached insights of a user, computing them on first use.
+func (s *Service) Get(userID uint) (*model.UserInsight, error) {
+	ui, err := s.repo.GetInsight(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.Compute(userID, time.Now())
+	}
+	return ui, err
+}
+
+// Compute analyzes the episodes of a user within the window ending at now and
+// caches the result.
+func (s *Service) Compute(userID uint, now time.Time) (*model.UserInsight, error) {
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	from := now.AddDate(0, 0, -s.cfg.WindowDays)
+	episodes, err := s.repo.GetEpisodes(userID, from, now)
+	if err != nil {
+		return nil, err
+	}
+	facts := make([]EpisodeFacts, 0, len(episodes))
+	for _, ep := range episodes {
+		local := ep.StartedAt.In(loc)
+		f := EpisodeFacts{LocalStart: local, Location: ep.Location, Triggers: ep.Triggers}
+		if f.HeartRateRise, err = s.repo.HeartRateRise(userID, ep.StartedAt); err != nil {
+			return nil, err
+		}
+		// Only ratings reported before the episode, since the evening before its
+		// day, describe the night that preceded it.
+		prevEvening := time.Date(local.Year(), local.Month(), local.Day()-1, sleepWindowStartHour, 0, 0, 0, loc)
+		if f.SleepQuality, err = s.repo.FirstSleepQuality(userID, prevEvening, ep.StartedAt); err != nil {
+			return nil, err
+		}
+		facts = append(facts, f)
+	}
+	stats, insights := Analyze(facts, s.cfg.MinEpisodes)
+	ui := &model.UserInsight{
+		UserID:     userID,
+		From:       from,
+		To:         now,
+		Stats:      stats,
+		Insights:   insights,
+		ComputedAt: time.Now(),
+	}
+	if err := s.repo.SaveInsight(ui); err != nil {
+		return nil, err
+	}
+	return ui, nil
+}
+
+// RunBatch recomputes the insights of every user with episodes in the window
+// and drops cached insights of the others, which are recomputed on demand.
+func (s *Service) RunBatch() (int, error) {
+	started := time.Now()
+	ids, err := s.repo.UsersWithEpisodes(started.AddDate(0, 0, -s.cfg.WindowDays))
+	if err != nil {
+		return 0, err
+	}
+	computed := 0
+	for _, id := range ids {
+		if _, err := s.Compute(id, started); err != nil {
+			logrus.WithError(err).Errorf("failed to compute insights for user %d", id)
+			continue
+		}
+		computed++
+	}
+	if _, err := s.repo.DeleteInsightsBefore(started); err != nil {
+		return computed, err
+	}
+	return computed, nil
+}
+
+// JobBatch is the background job type recomputing the insights of all users.
+const JobBatch = "insight.batch"
+
+// RegisterJobs registers the nightly insight batch at the configured time.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobBatch, jobs.Options{MaxAttempts: 2, Timeout: time.Hour}, func(ctx context.Context, _ struct{}) error {
+		n, err := s.RunBatch()
+		logrus.Infof("Computed insights for %d users", n)
+		return err
+	})
+	at, _ := time.Parse("15:04", s.cfg.BatchAt)
+	r.MustSchedule("insight-batch", fmt.Sprintf("%d %d * * *", at.Minute(), at.Hour()), JobBatch)
+}
@@ -52,6 +52,34 @@ func (r *Repository) CreateSubInterest(interestID uint, name string) (*model.Sub
 	return subInterest, nil
 }
 
+// DeleteInterest removes an interest with its sub-interests and every user and guide assignment.
+func (r *Repository) DeleteInterest(interestID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_sub_interests WHERE sub_interest_id IN (SELECT id FROM sub_interests WHERE interest_id = ?)", interestID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("interest_id = ?", interestID).Delete(&model.SubInterest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("user_interests").Where("interest_id = ?", interestID).Delete(nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("panic_guide_interests").Where("interest_id = ?", interestID).Delete(nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Interest{}, interestID).Error
+	})
+}
+
+// GetInterestByName retrieves an interest by its name.
+func (r *Repository) GetInterestByName(name string) (*model.Interest, error) {
+	var interest model.Interest
+	if err := r.db.Where("name = ?", name).First(&interest).Error; err != nil {
+		return nil, err
+	}
+	return &interest, nil
+}
+
 // AssignInterestToUser assigns an interest to a user using a join table.
 func (r *Repository) AssignInterestToUser(userID, interestID uint) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
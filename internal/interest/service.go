@@ -66,6 +66,22 @@ func (s *Service) CreateSub(interestID uint, name string) (*model.SubInterest, e
 	return s.repo.CreateSubInterest(interestID, name)
 }
 
+// Delete removes an interest and all of its assignments.
+func (s *Service) Delete(interestID uint) error {
+	if interestID == 0 {
+		return errors.New("interestID must be provided")
+	}
+	return s.repo.DeleteInterest(interestID)
+}
+
+// GetByName returns the interest with the given name.
+func (s *Service) GetByName(name string) (*model.Interest, error) {
+	if name == "" {
+		return nil, errors.New("interest name cannot be empty")
+	}
+	return s.repo.GetInterestByName(name)
+}
+
 // AssignToUser links an interest to a user.
 func (s *Service) AssignToUser(userID, interestID uint) error {
 	if userID == 0 || interestID == 0 {
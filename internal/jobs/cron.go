@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week (0 or 7 is Sunday). Fields accept *, lists, ranges
+// and steps, e.g. "*/15 8-18 * * 1-5". The aliases @hourly, @daily and
+// @weekly are also accepted.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // bit sets
+	domAny, dowAny                bool
+}
+
+var cronAliases = map[string]string{
+	"@hourly": "0 * * * *",
+	"@daily":  "0 0 * * *",
+	"@weekly": "0 0 * * 0",
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(spec string) (*Cron, error) {
+	if alias, ok := cronAliases[spec]; ok {
+		spec = alias
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields", spec)
+	}
+	var c Cron
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %w", spec, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %w", spec, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron %q: day of month: %w", spec, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %w", spec, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron %q: day of week: %w", spec, err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return &c, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], s
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			var err error
+			if i := strings.Index(rng, "-"); i >= 0 {
+				lo, err = strconv.Atoi(rng[:i])
+				if err == nil {
+					hi, err = strconv.Atoi(rng[i+1:])
+				}
+			} else {
+				lo, err = strconv.Atoi(rng)
+				hi = lo
+				if step > 1 {
+					hi = max
+				}
+			}
+			if err != nil || lo < min || hi > max || lo > hi {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default: // both restricted: either matches, as in classic cron
+		return dom || dow
+	}
+}
+
+// Next returns the first matching minute strictly after t, in t's location.
+// It returns the zero time if nothing matches within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
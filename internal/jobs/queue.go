@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrJobNotFound is returned when the job does not exist or is not dead.
+var ErrJobNotFound = errors.New("job not found")
+
+// Queue enqueues jobs and inspects the queue. Any server can enqueue; jobs run
+// on whichever server has a Runner handling their type.
+type Queue struct {
+	repo *Repository
+}
+
+// NewQueue creates a new job Queue.
+func NewQueue(db *gorm.DB) *Queue {
+	return &Queue{repo: NewRepository(db)}
+}
+
+// Enqueue adds a job to run as soon as a worker is free.
+func (q *Queue) Enqueue(jobType string, payload interface{}) (*model.Job, error) {
+	return q.EnqueueAt(jobType, payload, time.Now())
+}
+
+// EnqueueAt adds a job to run at or after runAt. The payload is stored as JSON.
+func (q *Queue) EnqueueAt(jobType string, payload interface{}, runAt time.Time) (*model.Job, error) {
+	job, err := newJob(jobType, payload, runAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func newJob(jobType string, payload interface{}, runAt time.Time) (*model.Job, error) {
+	if payload == nil {
+		payload = struct{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Job{Type: jobType, Status: model.JobStatusQueued, Payload: string(data), RunAt: runAt}, nil
+}
+
+// Stats summarizes the queue for the admin status endpoint.
+type Stats struct {
+	Counts    []StatusCount       `json:"counts"`
+	Schedules []model.JobSchedule `json:"schedules"`
+}
+
+// Stats returns the job counts per type and status and the recurring schedules.
+func (q *Queue) Stats() (*Stats, error) {
+	counts, err := q.repo.CountByStatus()
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := q.repo.GetSchedules()
+	if err != nil {
+		return nil, err
+	}
+	return &Stats{Counts: counts, Schedules: schedules}, nil
+}
+
+// List returns jobs newest first; older pages are fetched with beforeID.
+func (q *Queue) List(status, jobType string, beforeID uint, limit int) ([]model.Job, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return q.repo.GetJobs(status, jobType, beforeID, limit)
+}
+
+// Retry moves a dead job back into the queue.
+func (q *Queue) Retry(id uint) error {
+	ok, err := q.repo.RequeueDead(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// Discard deletes a dead job.
+func (q *Queue) Discard(id uint) error {
+	ok, err := q.repo.DeleteDead(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrJobNotFound
+	}
+	return nil
+}
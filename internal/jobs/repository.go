@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository provides database access for the job queue.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new jobs Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateJob inserts a job.
+func (r *Repository) CreateJob(job *model.Job) error {
+	return r.db.Create(job).Error
+}
+
+// ClaimJob locks the oldest due job of one of the types for the worker and
+// returns it, or nil if there is none. Jobs locked by other workers are skipped.
+func (r *Repository) ClaimJob(types []string, worker string, now time.Time) (*model.Job, error) {
+	var jobs []model.Job
+	err := r.db.Raw(`UPDATE jobs SET status = ?, locked_by = ?, locked_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = ? AND type IN ? AND run_at <= ?
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.JobStatusRunning, worker, now, now, model.JobStatusQueued, types, now).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// ErrLockLost is returned when a job is finished by a worker that no longer
+// holds its lock, because the job was released as stale in the meantime.
+var ErrLockLost = errors.New("job lock lost")
+
+// FinishJob records the outcome of a job claimed by the worker.
+func (r *Repository) FinishJob(id uint, worker, status string, runAt *time.Time, lastError string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     status,
+		"locked_by":  "",
+		"locked_at":  nil,
+		"last_error": lastError,
+	}
+	if runAt != nil {
+		updates["run_at"] = *runAt
+	} else {
+		updates["finished_at"] = now
+	}
+	res := r.db.Model(&model.Job{}).Where("id = ? AND status = ? AND locked_by = ?", id, model.JobStatusRunning, worker).Updates(updates)
+	return lockResult(res)
+}
+
+// DeleteJob removes a job claimed by the worker.
+func (r *Repository) DeleteJob(id uint, worker string) error {
+	res := r.db.Where("id = ? AND status = ? AND locked_by = ?", id, model.JobStatusRunning, worker).Delete(&model.Job{})
+	return lockResult(res)
+}
+
+// TouchJob renews the lock of a running job so it is not released as stale.
+func (r *Repository) TouchJob(id uint, worker string, at time.Time) error {
+	res := r.db.Model(&model.Job{}).Where("id = ? AND status = ? AND locked_by = ?", id, model.JobStatusRunning, worker).
+		Update("locked_at", at)
+	return lockResult(res)
+}
+
+func lockResult(res *gorm.DB) error {
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// RedactPayload replaces the payload of a dead job with an empty object.
+func (r *Repository) RedactPayload(id uint) error {
+	return r.db.Model(&model.Job{}).Where("id = ? AND status = ?", id, model.JobStatusDead).
+		Update("payload", "{}").Error
+}
+
+// GetStaleJobs returns running jobs locked before the time, whose worker
+// presumably died.
+func (r *Repository) GetStaleJobs(lockedBefore time.Time) ([]model.Job, error) {
+	var jobs []model.Job
+	err := r.db.Where("status = ? AND locked_at < ?", model.JobStatusRunning, lockedBefore).Find(&jobs).Error
+	return jobs, err
+}
+
+// ReleaseJob returns a stale running job to the queue or the dead letters,
+// unless it finished in the meantime.
+func (r *Repository) ReleaseJob(job *model.Job, status, lastError string) error {
+	updates := map[string]interface{}{"status": status, "locked_by": "", "locked_at": nil, "last_error": lastError}
+	if status == model.JobStatusDead {
+		updates["finished_at"] = time.Now()
+	}
+	return r.db.Model(&model.Job{}).
+		Where("id = ? AND status = ? AND locked_at = ?", job.ID, model.JobStatusRunning, job.LockedAt).
+		Updates(updates).Error
+}
+
+// DeleteFinishedBefore removes jobs in the status finished before the time.
+func (r *Repository) DeleteFinishedBefore(status string, before time.Time) (int64, error) {
+	res := r.db.Where("status = ? AND finished_at < ?", status, before).Delete(&model.Job{})
+	return res.RowsAffected, res.Error
+}
+
+// StatusCount is the number of jobs of a type in a status.
+type StatusCount struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// CountByStatus counts the jobs per type and status.
+func (r *Repository) CountByStatus() ([]StatusCount, error) {
+	var rows []StatusCount
+	err := r.db.Model(&model.Job{}).Select("type, status, COUNT(*) AS count").
+		Group("type, status").Order("type, status").Scan(&rows).Error
+	return rows, err
+}
+
+// GetJobs returns jobs newest first, optionally filtered by status and type.
+func (r *Repository) GetJobs(status, jobType string, beforeID uint, limit int) ([]model.Job, error) {
+	q := r.db.Order("id desc").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if jobType != "" {
+		q = q.Where("type = ?", jobType)
+	}
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+	var jobs []model.Job
+	err := q.Find(&jobs).Error
+	return jobs, err
+}
+
+// RequeueDead puts a dead job back in the queue with fresh attempts.
+func (r *Repository) RequeueDead(id uint) (bool, error) {
+	res := r.db.Model(&model.Job{}).Where("id = ? AND status = ?", id, model.JobStatusDead).
+		Updates(map[string]interface{}{
+			"status":      model.JobStatusQueued,
+			"attempts":    0,
+			"run_at":      time.Now(),
+			"finished_at": nil,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteDead removes a dead job.
+func (r *Repository) DeleteDead(id uint) (bool, error) {
+	res := r.db.Where("id = ? AND status = ?", id, model.JobStatusDead).Delete(&model.Job{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// UpsertSchedule creates a schedule or updates its spec and job type. The next
+// run is reset when the spec changes.
+func (r *Repository) UpsertSchedule(s *model.JobSchedule) error {
+	var existing model.JobSchedule
+	err := r.db.First(&existing, "name = ?", s.Name).Error
+	if err == nil && existing.Spec == s.Spec && existing.JobType == s.JobType {
+		return nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"spec", "job_type", "next_run_at", "updated_at"}),
+	}).Create(s).Error
+}
+
+// GetSchedules returns every schedule.
+func (r *Repository) GetSchedules() ([]model.JobSchedule, error) {
+	var schedules []model.JobSchedule
+	err := r.db.Order("name").Find(&schedules).Error
+	return schedules, err
+}
+
+// FireSchedule advances a due schedule and enqueues its job in one transaction.
+// It reports false when another server fired it first.
+func (r *Repository) FireSchedule(s *model.JobSchedule, next time.Time, job *model.Job) (bool, error) {
+	fired := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&model.JobSchedule{}).
+			Where("name = ? AND next_run_at = ?", s.Name, s.NextRunAt).
+			Updates(map[string]interface{}{"next_run_at": next, "last_run_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		fired = true
+		return tx.Create(job).Error
+	})
+	return fired, err
+}
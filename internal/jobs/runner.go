@@ -0,0 +1,369 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// JobCleanup is the built-in job type removing old succeeded and dead jobs.
+const JobCleanup = "jobs.cleanup"
+
+// Options control how a job type is run.
+type Options struct {
+	MaxAttempts     int           // attempts before the job is dead; default 5
+	Backoff         time.Duration // delay before the first retry, doubled after every attempt; default 30s
+	Timeout         time.Duration // context deadline of one attempt; default 5m
+	DeleteOnSuccess bool          // remove the job when it succeeds, e.g. when the payload is sensitive
+	RedactDead      bool          // clear the payload when the job dies, e.g. when it holds one-time codes
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 30 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Minute
+	}
+	return o
+}
+
+// maxBackoff caps the retry delay.
+const maxBackoff = time.Hour
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix; the job goes straight to
+// the dead letters.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Config controls the workers of a Runner.
+type Config struct {
+	Workers       int
+	PollInterval  time.Duration
+	LockTimeout   time.Duration // running jobs whose lock was not renewed for this long are assumed lost and released
+	Retention     time.Duration // succeeded jobs are kept this long
+	DeadRetention time.Duration // dead jobs are kept this long for inspection and retries
+}
+
+// LoadConfig reads the jobs section of the configuration (JOBS_WORKERS,
+// JOBS_POLL_INTERVAL, JOBS_LOCK_TIMEOUT, JOBS_RETENTION, JOBS_DEAD_RETENTION).
+func LoadConfig() Config {
+	c := config.Get().Jobs
+	return Config{
+		Workers:       c.Workers,
+		PollInterval:  c.PollInterval,
+		LockTimeout:   c.LockTimeout,
+		Retention:     c.Retention,
+		DeadRetention: c.DeadRetention,
+	}
+}
+
+type handler struct {
+	run  func(ctx context.Context, payload []byte) error
+	opts Options
+}
+
+type schedule struct {
+	name    string
+	spec    string
+	cron    *Cron
+	jobType string
+}
+
+// Runner claims queued jobs and runs them with the registered handlers, and
+// enqueues recurring jobs on schedule.
+type Runner struct {
+	repo      *Repository
+	cfg       Config
+	id        string
+	handlers  map[string]handler
+	schedules []schedule
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a Runner configured from the environment.
+func NewRunner(db *gorm.DB) *Runner {
+	host, _ := os.Hostname()
+	r := &Runner{
+		repo:     NewRepository(db),
+		cfg:      LoadConfig(),
+		id:       fmt.Sprintf("%s:%d", host, os.Getpid()),
+		handlers: make(map[string]handler),
+	}
+	Handle(r, JobCleanup, Options{MaxAttempts: 1}, func(ctx context.Context, _ struct{}) error {
+		succeeded, dead, err := r.Cleanup()
+		if succeeded+dead > 0 {
+			logrus.Infof("Removed %d succeeded and %d dead jobs", succeeded, dead)
+		}
+		return err
+	})
+	r.MustSchedule("jobs-cleanup", "30 4 * * *", JobCleanup)
+	return r
+}
+
+// Cleanup deletes succeeded jobs older than JOBS_RETENTION and dead jobs older
+// than JOBS_DEAD_RETENTION and returns how many of each were removed.
+func (r *Runner) Cleanup() (succeeded, dead int64, err error) {
+	now := time.Now()
+	succeeded, err = r.repo.DeleteFinishedBefore(model.JobStatusSucceeded, now.Add(-r.cfg.Retention))
+	if err != nil {
+		return 0, 0, err
+	}
+	dead, err = r.repo.DeleteFinishedBefore(model.JobStatusDead, now.Add(-r.cfg.DeadRetention))
+	return succeeded, dead, err
+}
+
+// Handle registers the handler of a job type. The JSON payload is decoded into T;
+// payloads that do not decode go to the dead letters.
+func Handle[T any](r *Runner, jobType string, opts Options, fn func(ctx context.Context, payload T) error) {
+	r.handlers[jobType] = handler{
+		opts: opts.withDefaults(),
+		run: func(ctx context.Context, data []byte) error {
+			var payload T
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return Permanent(fmt.Errorf("decode payload: %w", err))
+			}
+			return fn(ctx, payload)
+		},
+	}
+}
+
+// Schedule enqueues a job of the type, with an empty payload, whenever the
+// cron expression matches in the server's time zone.
+func (r *Runner) Schedule(name, spec, jobType string) error {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	r.schedules = append(r.schedules, schedule{name: name, spec: spec, cron: c, jobType: jobType})
+	return nil
+}
+
+// MustSchedule is like Schedule but panics on an invalid expression.
+func (r *Runner) MustSchedule(name, spec, jobType string) {
+	if err := r.Schedule(name, spec, jobType); err != nil {
+		panic(err)
+	}
+}
+
+// Start launches the workers, the scheduler and the lock reaper.
+func (r *Runner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	now := time.Now()
+	for _, s := range r.schedules {
+		err := r.repo.UpsertSchedule(&model.JobSchedule{Name: s.name, Spec: s.spec, JobType: s.jobType, NextRunAt: s.cron.Next(now)})
+		if err != nil {
+			logrus.WithError(err).Errorf("failed to register job schedule %s", s.name)
+		}
+	}
+	types := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	for i := 0; i < r.cfg.Workers; i++ {
+		worker := fmt.Sprintf("%s/%d", r.id, i)
+		r.loop(ctx, r.cfg.PollInterval, func() bool { return r.work(ctx, worker, types) })
+	}
+	r.loop(ctx, 30*time.Second, func() bool { r.fireSchedules(); return false })
+	r.loop(ctx, time.Minute, func() bool { r.releaseStale(); return false })
+	logrus.Infof("Job runner %s started with %d workers for %d job types", r.id, r.cfg.Workers, len(types))
+}
+
+// Stop stops claiming jobs and waits for running ones to finish.
+func (r *Runner) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+}
+
+// loop calls fn until the context is canceled, waiting the interval whenever
+// fn reports there is nothing more to do right away.
+func (r *Runner) loop(ctx context.Context, interval time.Duration, fn func() bool) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			if fn() {
+				if ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// work claims and runs one job; it reports whether a job was found.
+func (r *Runner) work(ctx context.Context, worker string, types []string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	job, err := r.repo.ClaimJob(types, worker, time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to claim job")
+		return false
+	}
+	if job == nil {
+		return false
+	}
+	h := r.handlers[job.Type]
+	stop := r.heartbeat(job.ID, worker)
+	err = r.run(h, job)
+	stop()
+	log := logrus.WithFields(logrus.Fields{"jobID": job.ID, "type": job.Type, "attempt": job.Attempts})
+	switch {
+	case err == nil && h.opts.DeleteOnSuccess:
+		err = r.repo.DeleteJob(job.ID, worker)
+	case err == nil:
+		err = r.repo.FinishJob(job.ID, worker, model.JobStatusSucceeded, nil, "")
+	case errors.As(err, new(permanentError)) || job.Attempts >= h.opts.MaxAttempts:
+		log.WithError(err).Error("Job failed, moved to dead letters")
+		err = r.repo.FinishJob(job.ID, worker, model.JobStatusDead, nil, err.Error())
+		if err == nil && h.opts.RedactDead {
+			err = r.repo.RedactPayload(job.ID)
+		}
+	default:
+		retryAt := time.Now().Add(backoff(h.opts.Backoff, job.Attempts))
+		log.WithError(err).Warnf("Job failed, retrying at %s", retryAt.Format(time.RFC3339))
+		err = r.repo.FinishJob(job.ID, worker, model.JobStatusQueued, &retryAt, err.Error())
+	}
+	if errors.Is(err, ErrLockLost) {
+		log.Warn("Job was released while running; its outcome is discarded")
+	} else if err != nil {
+		log.WithError(err).Error("Failed to record job outcome")
+	}
+	return true
+}
+
+// heartbeat renews the lock of a running job every third of JOBS_LOCK_TIMEOUT,
+// so only jobs of workers that died are released as stale, however long a job
+// takes. The returned function stops it.
+func (r *Runner) heartbeat(jobID uint, worker string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(r.cfg.LockTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := r.repo.TouchJob(jobID, worker, time.Now()); err != nil {
+					logrus.WithError(err).WithField("jobID", jobID).Warn("Failed to renew job lock")
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// run calls the handler with the attempt timeout, turning panics into errors.
+func (r *Runner) run(h handler, job *model.Job) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h.run(ctx, []byte(job.Payload))
+}
+
+// backoff returns the delay before the retry following the given attempt.
+func backoff(base time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// fireSchedules enqueues the jobs of due schedules.
+func (r *Runner) fireSchedules() {
+	stored, err := r.repo.GetSchedules()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load job schedules")
+		return
+	}
+	now := time.Now()
+	for i := range stored {
+		s := &stored[i]
+		if s.NextRunAt.After(now) {
+			continue
+		}
+		c, err := ParseCron(s.Spec)
+		if err != nil {
+			logrus.WithError(err).Errorf("invalid job schedule %s", s.Name)
+			continue
+		}
+		job, _ := newJob(s.JobType, nil, now)
+		if _, err := r.repo.FireSchedule(s, c.Next(now), job); err != nil {
+			logrus.WithError(err).Errorf("failed to fire job schedule %s", s.Name)
+		}
+	}
+}
+
+// releaseStale returns jobs of crashed workers to the queue, or to the dead
+// letters when they used up their attempts.
+func (r *Runner) releaseStale() {
+	stale, err := r.repo.GetStaleJobs(time.Now().Add(-r.cfg.LockTimeout))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load stale jobs")
+		return
+	}
+	for i := range stale {
+		job := &stale[i]
+		status := model.JobStatusQueued
+		h, ok := r.handlers[job.Type]
+		if ok && job.Attempts >= h.opts.MaxAttempts {
+			status = model.JobStatusDead
+		}
+		msg := fmt.Sprintf("worker %s stopped renewing the lock for %s", job.LockedBy, r.cfg.LockTimeout)
+		err := r.repo.ReleaseJob(job, status, msg)
+		if err == nil && status == model.JobStatusDead && h.opts.RedactDead {
+			err = r.repo.RedactPayload(job.ID)
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("jobID", job.ID).Error("Failed to release stale job")
+			continue
+		}
+		logrus.WithField("jobID", job.ID).Warnf("Released stale job: %s", msg)
+	}
+}
@@ -0,0 +1,137 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"ps_backend/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APNsProvider sends notifications through the Apple Push Notification service
+// using token-based (.p8 key) authentication.
+type APNsProvider struct {
+	host   string
+	topic  string
+	keyID  string
+	teamID string
+	key    *ecdsa.PrivateKey
+	client *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsProviderFromEnv creates the provider from APNS_KEY_FILE, APNS_KEY_ID,
+// APNS_TEAM_ID and APNS_TOPIC (the app bundle ID). It returns nil when APNs is
+// not configured. APNS_PRODUCTION=true selects the production gateway.
+func NewAPNsProviderFromEnv() (*APNsProvider, error) {
+	cfg := config.Get().APNs
+	path := cfg.KeyFile
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse APNs key: %w", err)
+	}
+	p := &APNsProvider{
+		host:   "https://api.sandbox.push.apple.com",
+		topic:  cfg.Topic,
+		keyID:  cfg.KeyID,
+		teamID: cfg.TeamID,
+		key:    key,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if p.topic == "" || p.keyID == "" || p.teamID == "" {
+		return nil, fmt.Errorf("APNS_TOPIC, APNS_KEY_ID and APNS_TEAM_ID are required")
+	}
+	if cfg.Production {
+		p.host = "https://api.push.apple.com"
+	}
+	return p, nil
+}
+
+// Name implements Provider.
+func (p *APNsProvider) Name() string { return "apns" }
+
+// Send implements Provider.
+func (p *APNsProvider) Send(ctx context.Context, msg Message) error {
+	token, err := p.authToken()
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+			"sound": "default",
+		},
+	}
+	for k, v := range msg.Data {
+		if k != "aps" {
+			payload[k] = v
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.host+"/3/device/"+msg.Token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+token)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var reason struct {
+		Reason string `json:"reason"`
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	_ = json.Unmarshal(detail, &reason)
+	if resp.StatusCode == http.StatusGone || reason.Reason == "BadDeviceToken" || reason.Reason == "Unregistered" {
+		return ErrUnregistered
+	}
+	return fmt.Errorf("apns: %s: %s", resp.Status, reason.Reason)
+}
+
+// authToken returns the provider JWT, which Apple accepts for up to an hour;
+// it is renewed after 45 minutes.
+func (p *APNsProvider) authToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < 45*time.Minute {
+		return p.token, nil
+	}
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": p.teamID, "iat": now.Unix()})
+	t.Header["kid"] = p.keyID
+	signed, err := t.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token, p.issuedAt = signed, now
+	return signed, nil
+}
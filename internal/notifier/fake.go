@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FakeProvider records messages in memory instead of sending them. It is used
+// in tests and, with PUSH_FAKE=true, in local development.
+type FakeProvider struct {
+	name string
+
+	mu   sync.Mutex
+	sent []Message
+	// Err, when set, is returned by Send instead of recording the message.
+	Err error
+}
+
+// NewFakeProvider creates a fake provider registered under the given name.
+func NewFakeProvider(name string) *FakeProvider {
+	return &FakeProvider{name: name}
+}
+
+// Name implements Provider.
+func (p *FakeProvider) Name() string { return p.name }
+
+// Send implements Provider.
+func (p *FakeProvider) Send(_ context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return p.Err
+	}
+	p.sent = append(p.sent, msg)
+	logrus.WithFields(logrus.Fields{"provider": p.name, "title": msg.Title}).Info("Fake push notification sent")
+	return nil
+}
+
+// Sent returns a copy of the messages sent so far.
+func (p *FakeProvider) Sent() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.sent...)
+}
@@ -0,0 +1,165 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ps_backend/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMProvider sends notifications through the Firebase Cloud Messaging HTTP v1 API,
+// authenticating with a Google service account.
+type FCMProvider struct {
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	key         *rsa.PrivateKey
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMProviderFromEnv creates the provider from the service account JSON file
+// in FCM_CREDENTIALS_FILE. It returns nil when FCM is not configured.
+// FCM_PROJECT_ID overrides the project of the service account.
+func NewFCMProviderFromEnv() (*FCMProvider, error) {
+	cfg := config.Get().FCM
+	path := cfg.CredentialsFile
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var account struct {
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("parse FCM credentials: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parse FCM private key: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if cfg.ProjectID != "" {
+		account.ProjectID = cfg.ProjectID
+	}
+	return &FCMProvider{
+		projectID:   account.ProjectID,
+		clientEmail: account.ClientEmail,
+		tokenURI:    account.TokenURI,
+		key:         key,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name implements Provider.
+func (p *FCMProvider) Name() string { return "fcm" }
+
+// Send implements Provider.
+func (p *FCMProvider) Send(ctx context.Context, msg Message) error {
+	token, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{"message": map[string]interface{}{
+		"token":        msg.Token,
+		"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+		"data":         msg.Data,
+	}}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", p.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound || strings.Contains(string(detail), "UNREGISTERED") {
+		return ErrUnregistered
+	}
+	return fmt.Errorf("fcm: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+}
+
+// token returns a cached OAuth2 access token, exchanging a signed service
+// account assertion for a new one shortly before it expires.
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Until(p.expiresAt) > time.Minute {
+		return p.accessToken, nil
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.clientEmail,
+		"scope": fcmScope,
+		"aud":   p.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm token exchange: %s", resp.Status)
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	p.accessToken = out.AccessToken
+	p.expiresAt = now.Add(time.Duration(out.ExpiresIn) * time.Second)
+	return p.accessToken, nil
+}
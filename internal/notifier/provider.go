@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+
+	"ps_backend/pkg/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnregistered is returned by a provider when the push token is no longer
+// valid, e.g. because the app was uninstalled. The token is then forgotten.
+var ErrUnregistered = errors.New("push token unregistered")
+
+// Message is a push notification to a single device.
+type Message struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Provider delivers push notifications through one push service.
+type Provider interface {
+	// Name returns the provider name stored on devices, e.g. "fcm" or "apns".
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// ConfiguredProviders returns the providers configured in the environment.
+// With PUSH_FAKE=true, in-memory fakes replace FCM and APNs for local development.
+func ConfiguredProviders() []Provider {
+	if config.Get().Push.Fake {
+		logrus.Warn("PUSH_FAKE is set; push notifications are only logged")
+		return []Provider{NewFakeProvider("fcm"), NewFakeProvider("apns")}
+	}
+	var providers []Provider
+	if p, err := NewFCMProviderFromEnv(); err != nil {
+		logrus.WithError(err).Error("FCM provider misconfigured")
+	} else if p != nil {
+		providers = append(providers, p)
+	}
+	if p, err := NewAPNsProviderFromEnv(); err != nil {
+		logrus.WithError(err).Error("APNs provider misconfigured")
+	} else if p != nil {
+		providers = append(providers, p)
+	}
+	return providers
+}
@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for notifications and preferences.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new notifier Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUser retrieves the recipient of a notification.
+func (r *Repository) GetUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetPreference returns the stored preferences of a user, or the defaults.
+func (r *Repository) GetPreference(userID uint) (*model.NotificationPreference, error) {
+	var pref model.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		pref = model.DefaultNotificationPreference(userID)
+		return &pref, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+// SavePreference inserts or updates the preferences of a user.
+func (r *Repository) SavePreference(pref *model.NotificationPreference) error {
+	return r.db.Save(pref).Error
+}
+
+// CreateNotification inserts a new notification.
+func (r *Repository) CreateNotification(n *model.Notification) error {
+	return r.db.Create(n).Error
+}
+
+// GetNotification retrieves a notification by ID.
+func (r *Repository) GetNotification(id uint) (*model.Notification, error) {
+	var n model.Notification
+	if err := r.db.First(&n, id).Error; err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// GetPushDevices returns the active devices of a user that have a push token.
+func (r *Repository) GetPushDevices(userID uint) ([]model.Device, error) {
+	var devices []model.Device
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND push_token <> ''", userID).Find(&devices).Error
+	return devices, err
+}
+
+// ClearPushToken forgets a push token the provider reported as unregistered.
+func (r *Repository) ClearPushToken(deviceID uint, token string) error {
+	return r.db.Model(&model.Device{}).Where("id = ? AND push_token = ?", deviceID, token).
+		Update("push_token", "").Error
+}
+
+// FinishNotification stores the deliveries and final status of a notification.
+func (r *Repository) FinishNotification(n *model.Notification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(n.Deliveries) > 0 {
+			if err := tx.Create(&n.Deliveries).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(n).Updates(map[string]interface{}{"status": n.Status, "sent_at": n.SentAt}).Error
+	})
+}
+
+// GetInbox returns notifications of a user newest first, optionally only those
+// older than beforeID.
+func (r *Repository) GetInbox(userID, beforeID uint, limit int) ([]model.Notification, error) {
+	q := r.db.Where("user_id = ?", userID)
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+	var items []model.Notification
+	err := q.Order("id desc").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// CountUnread returns the number of unread notifications of a user.
+func (r *Repository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one notification of the user read and reports whether it exists.
+func (r *Repository) MarkRead(userID, id uint, at time.Time) (bool, error) {
+	var n model.Notification
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&n).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if n.ReadAt != nil {
+		return true, nil
+	}
+	return true, r.db.Model(&n).Update("read_at", at).Error
+}
+
+// MarkAllRead marks every unread notification of the user read.
+func (r *Repository) MarkAllRead(userID uint, at time.Time) (int64, error) {
+	res := r.db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,317 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"ps_backend/internal/jobs"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotificationNotFound is returned when the notification does not exist or belongs to another user.
+	ErrNotificationNotFound = errors.New("notification not found")
+	// ErrInvalidPreference is returned for unknown categories or malformed quiet hours.
+	ErrInvalidPreference = errors.New("invalid notification preference")
+)
+
+// JobDeliver is the background job type pushing a notification to devices.
+const JobDeliver = "notification.deliver"
+
+// DeliverPayload is the payload of a JobDeliver job.
+type DeliverPayload struct {
+	NotificationID uint `json:"notification_id"`
+}
+
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// RetryPolicy controls how often a failed push to a device is retried.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration // doubled after every attempt
+}
+
+// LoadRetryPolicy reads PUSH_MAX_ATTEMPTS (default 3) and PUSH_RETRY_BACKOFF (default 2s).
+func LoadRetryPolicy() RetryPolicy {
+	c := config.Get().Push
+	return RetryPolicy{MaxAttempts: c.MaxAttempts, Backoff: c.RetryBackoff}
+}
+
+// Input describes a notification to send. Empty title and body are filled from
+// the category's template in the recipient's locale.
+type Input struct {
+	UserID   uint
+	Category string
+	Title    string
+	Body     string
+	Data     map[string]string
+}
+
+// PreferenceUpdate holds the preference fields to change; nil fields are left untouched.
+type PreferenceUpdate struct {
+	DisabledCategories *[]string
+	QuietHoursEnabled  *bool
+	QuietHoursStart    *string
+	QuietHoursEnd      *string
+}
+
+// Service stores notifications and pushes them to the user's devices.
+type Service struct {
+	repo      *Repository
+	queue     *jobs.Queue
+	providers map[string]Provider
+	retry     RetryPolicy
+}
+
+// NewService creates a notifier Service using the providers configured in the environment.
+func NewService(db *gorm.DB) *Service {
+	return NewServiceWithProviders(db, ConfiguredProviders()...)
+}
+
+// NewServiceWithProviders creates a notifier Service with the given providers,
+// e.g. fakes in tests.
+func NewServiceWithProviders(db *gorm.DB, providers ...Provider) *Service {
+	s := &Service{repo: NewRepository(db), queue: jobs.NewQueue(db), providers: make(map[string]Provider), retry: LoadRetryPolicy()}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// Notify stores a notification in the user's inbox and queues a job pushing it
+// to their devices unless the category is turned off or, for
+// non-critical categories, the user is in quiet hours.
+func (s *Service) Notify(in Input) (*model.Notification, error) {
+	user, err := s.repo.GetUser(in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	pref, err := s.repo.GetPreference(in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Title == "" && in.Body == "" {
+		locale := user.Locale
+		if locale == "" {
+			locale = utils.DefaultLocale()
+		}
+		in.Title, in.Body = defaultText(in.Category, locale)
+	}
+
+	n := &model.Notification{
+		UserID:   in.UserID,
+		Category: in.Category,
+		Title:    in.Title,
+		Body:     in.Body,
+		Data:     in.Data,
+		Status:   model.NotificationStatusPending,
+	}
+	if !pref.CategoryEnabled(in.Category) ||
+		(!model.CriticalNotification(in.Category) && pref.InQuietHours(localTime(user))) {
+		n.Status = model.NotificationStatusSuppressed
+	}
+	if err := s.repo.CreateNotification(n); err != nil {
+		return nil, err
+	}
+	if n.Status == model.NotificationStatusPending {
+		if _, err := s.queue.Enqueue(JobDeliver, DeliverPayload{NotificationID: n.ID}); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// RegisterJobs registers the notification delivery job handler.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobDeliver, jobs.Options{MaxAttempts: 3}, func(ctx context.Context, p DeliverPayload) error {
+		err := s.Deliver(p.NotificationID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // deleted with its user in the meantime
+		}
+		return err
+	})
+}
+
+func localTime(user *model.User) time.Time {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Now().In(loc)
+}
+
+// Deliver pushes a pending notification to every device of the user that has a
+// push token, retrying failed sends, and records the outcome per device.
+func (s *Service) Deliver(notificationID uint) error {
+	n, err := s.repo.GetNotification(notificationID)
+	if err != nil {
+		return err
+	}
+	if n.Status != model.NotificationStatusPending {
+		return nil
+	}
+	devices, err := s.repo.GetPushDevices(n.UserID)
+	if err != nil {
+		return err
+	}
+
+	n.Status = model.NotificationStatusNoDevices
+	if len(devices) > 0 {
+		n.Status = model.NotificationStatusFailed
+	}
+	msg := Message{Title: n.Title, Body: n.Body, Data: n.Data}
+	for _, device := range devices {
+		delivery := model.NotificationDelivery{NotificationID: n.ID, DeviceID: device.ID, Provider: device.PushProvider}
+		msg.Token = device.PushToken
+		delivery.Attempts, err = s.send(device.PushProvider, msg)
+		switch {
+		case err == nil:
+			delivery.Success = true
+			n.Status = model.NotificationStatusSent
+		case errors.Is(err, ErrUnregistered):
+			delivery.Error = err.Error()
+			if err := s.repo.ClearPushToken(device.ID, device.PushToken); err != nil {
+				logrus.WithError(err).WithField("deviceID", device.ID).Error("Failed to clear push token")
+			}
+		default:
+			delivery.Error = truncate(err.Error(), 255)
+		}
+		n.Deliveries = append(n.Deliveries, delivery)
+	}
+	if n.Status == model.NotificationStatusSent {
+		now := time.Now()
+		n.SentAt = &now
+	}
+	if err := s.repo.FinishNotification(n); err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"userID":         n.UserID,
+		"notificationID": n.ID,
+		"category":       n.Category,
+		"status":         n.Status,
+	}).Info("Notification processed")
+	return nil
+}
+
+// send pushes the message with the named provider, retrying with exponential
+// backoff. It returns the number of attempts made.
+func (s *Service) send(providerName string, msg Message) (int, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return 0, fmt.Errorf("push provider %q not configured", providerName)
+	}
+	backoff := s.retry.Backoff
+	var err error
+	for attempt := 1; attempt <= s.retry.MaxAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err = provider.Send(ctx, msg)
+		cancel()
+		if err == nil || errors.Is(err, ErrUnregistered) || attempt == s.retry.MaxAttempts {
+			return attempt, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return s.retry.MaxAttempts, err
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// Inbox returns up to limit notifications of the user older than beforeID
+// (0 for the newest) together with the number of unread notifications.
+func (s *Service) Inbox(userID, beforeID uint, limit int) ([]model.Notification, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	items, err := s.repo.GetInbox(userID, beforeID, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, unread, nil
+}
+
+// MarkRead marks a notification of the user read.
+func (s *Service) MarkRead(userID, id uint) error {
+	found, err := s.repo.MarkRead(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification of the user read and returns how many changed.
+func (s *Service) MarkAllRead(userID uint) (int64, error) {
+	return s.repo.MarkAllRead(userID, time.Now())
+}
+
+// GetPreferences returns the notification preferences of the user.
+func (s *Service) GetPreferences(userID uint) (*model.NotificationPreference, error) {
+	return s.repo.GetPreference(userID)
+}
+
+// UpdatePreferences applies a partial update to the user's preferences.
+func (s *Service) UpdatePreferences(userID uint, in PreferenceUpdate) (*model.NotificationPreference, error) {
+	pref, err := s.repo.GetPreference(userID)
+	if err != nil {
+		return nil, err
+	}
+	if in.DisabledCategories != nil {
+		disabled := []string{}
+		for _, c := range *in.DisabledCategories {
+			if !knownCategory(c) {
+				return nil, fmt.Errorf("%w: unknown category %q", ErrInvalidPreference, c)
+			}
+			disabled = append(disabled, c)
+		}
+		pref.DisabledCategories = disabled
+	}
+	if in.QuietHoursEnabled != nil {
+		pref.QuietHoursEnabled = *in.QuietHoursEnabled
+	}
+	for _, t := range []struct {
+		dst *string
+		src *string
+	}{{&pref.QuietHoursStart, in.QuietHoursStart}, {&pref.QuietHoursEnd, in.QuietHoursEnd}} {
+		if t.src == nil {
+			continue
+		}
+		if !clockPattern.MatchString(*t.src) {
+			return nil, fmt.Errorf("%w: quiet hours must be HH:MM", ErrInvalidPreference)
+		}
+		*t.dst = *t.src
+	}
+	if err := s.repo.SavePreference(pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+func knownCategory(category string) bool {
+	for _, c := range model.NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
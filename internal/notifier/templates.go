@@ -0,0 +1,32 @@
+package notifier
+
+import "ps_backend/model"
+
+// templates holds the default title and body of each category by locale.
+var templates = map[string]map[string][2]string{
+	model.NotificationPanicDetected: {
+		"ko": {"괜찮으세요?", "공황 증상이 감지됐어요. 앱을 열어 호흡 가이드를 따라해 보세요."},
+		"en": {"Are you okay?", "We noticed signs of a panic attack. Open the app for a guided breathing exercise."},
+	},
+	model.NotificationCheckInDue: {
+		"ko": {"오늘 기분은 어떠세요?", "잠깐 시간을 내어 체크인을 기록해 주세요."},
+		"en": {"How are you feeling?", "Take a moment to record your check-in."},
+	},
+	model.NotificationCareShare: {
+		"ko": {"공유 초대가 수락됐어요", "전문가가 공유 초대를 수락했습니다."},
+		"en": {"Sharing invitation accepted", "Your clinician accepted your sharing invitation."},
+	},
+}
+
+// defaultText returns the template for the category in the locale, falling back to Korean.
+func defaultText(category, locale string) (string, string) {
+	byLocale, ok := templates[category]
+	if !ok {
+		return "", ""
+	}
+	t, ok := byLocale[locale]
+	if !ok {
+		t = byLocale["ko"]
+	}
+	return t[0], t[1]
+}
@@ -0,0 +1,340 @@
+package panic_guide
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBookmarkNotFound is returned when the user has not bookmarked the guide.
+var ErrBookmarkNotFound = errors.New("bookmark not found")
+
+// GuideListItem is a panic guide annotated with the requesting user's bookmark state.
+type GuideListItem struct {
+	model.PanicGuide
+	IsBookmarked bool `json:"is_bookmarked"`
+}
+
+// BookmarkedGuide is a bookmarked panic guide with its folder and ordering.
+type BookmarkedGuide struct {
+	model.PanicGuide
+	Folder       string    `json:"folder"`
+	Position     int       `json:"position"`
+	Pinned       bool      `json:"pinned"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+// BookmarkFolder summarizes a bookmark folder of a user.
+type BookmarkFolder struct {
+	Folder string `json:"folder"`
+	Count  int64  `json:"count"`
+}
+
+// BookmarkUpdate holds optional changes to a bookmark.
+type BookmarkUpdate struct {
+	Folder   *string
+	Position *int
+	Pinned   *bool
+}
+
+// BookmarkPanicGuide creates a user_panic_guides entry at the end of its folder.
+// It is idempotent and reports whether a new bookmark was created.
+func (r *Repository) BookmarkPanicGuide(userID, guideID uint, folder string, pinned bool) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.PanicGuide{}).Where("id = ?", guideID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrGuideNotFound
+		}
+
+		if err := lockBookmarks(tx, userID); err != nil {
+			return err
+		}
+		position, err := nextPosition(tx, userID, folder)
+		if err != nil {
+			return err
+		}
+		entry := &model.UserPanicGuide{
+			UserID:       userID,
+			PanicGuideID: guideID,
+			Folder:       folder,
+			Position:     position,
+			Pinned:       pinned,
+		}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "panic_guide_id"}},
+			DoNothing: true,
+		}).Create(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected > 0
+		return nil
+	})
+	return created, err
+}
+
+// lockBookmarks locks the user's row, so bookmark writes of one user that pick
+// a position run one at a time. Locking the folder's rows would not cover a
+// folder that is still empty.
+func lockBookmarks(tx *gorm.DB, userID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.User{}, userID).Error
+}
+
+// nextPosition returns the position after the last bookmark of the folder.
+func nextPosition(tx *gorm.DB, userID uint, folder string) (int, error) {
+	var maxPosition int
+	err := tx.Model(&model.UserPanicGuide{}).
+		Where("user_id = ? AND folder = ?", userID, folder).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&maxPosition).Error
+	return maxPosition + 1, err
+}
+
+// RemoveBookmark deletes the bookmark of a guide.
+func (r *Repository) RemoveBookmark(userID, guideID uint) error {
+	result := r.db.Where("user_id = ? AND panic_guide_id = ?", userID, guideID).Delete(&model.UserPanicGuide{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// UpdateBookmark changes the folder, position or pin state of a bookmark. A
+// bookmark moved to another folder without a position goes to its end.
+func (r *Repository) UpdateBookmark(userID, guideID uint, update BookmarkUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBookmarks(tx, userID); err != nil {
+			return err
+		}
+		var entry model.UserPanicGuide
+		if err := tx.Where("user_id = ? AND panic_guide_id = ?", userID, guideID).First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookmarkNotFound
+			}
+			return err
+		}
+
+		changes := map[string]interface{}{}
+		if update.Folder != nil && *update.Folder != entry.Folder {
+			changes["folder"] = *update.Folder
+			if update.Position == nil {
+				position, err := nextPosition(tx, userID, *update.Folder)
+				if err != nil {
+					return err
+				}
+				changes["position"] = position
+			}
+		}
+		if update.Position != nil {
+			changes["position"] = *update.Position
+		}
+		if update.Pinned != nil {
+			changes["pinned"] = *update.Pinned
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return tx.Model(&model.UserPanicGuide{}).
+			Where("user_id = ? AND panic_guide_id = ?", userID, guideID).
+			Updates(changes).Error
+	})
+}
+
+// ReorderBookmarks assigns positions within a folder following the given guide order.
+func (r *Repository) ReorderBookmarks(userID uint, folder string, guideIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, guideID := range guideIDs {
+			result := tx.Model(&model.UserPanicGuide{}).
+				Where("user_id = ? AND panic_guide_id = ? AND folder = ?", userID, guideID, folder).
+				Update("position", i+1)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrBookmarkNotFound
+			}
+		}
+		return nil
+	})
+}
+
+// GetUserBookmarkedGuides returns guides bookmarked by a user, pinned first and then by folder and position.
+// An empty folder filter returns bookmarks of all folders.
+func (r *Repository) GetUserBookmarkedGuides(userID uint, folder *string) ([]BookmarkedGuide, error) {
+	var bookmarks []model.UserPanicGuide
+	q := r.db.Where("user_id = ?", userID)
+	if folder != nil {
+		q = q.Where("folder = ?", *folder)
+	}
+	if err := q.Order("pinned desc, folder asc, position asc, bookmarked_at asc").Find(&bookmarks).Error; err != nil {
+		return nil, err
+	}
+	if len(bookmarks) == 0 {
+		return []BookmarkedGuide{}, nil
+	}
+
+	ids := make([]uint, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.PanicGuideID
+	}
+	var guides []model.PanicGuide
+	if err := withOrderedSteps(r.db).Where("id IN ?", ids).Find(&guides).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.PanicGuide, len(guides))
+	for _, g := range guides {
+		byID[g.ID] = g
+	}
+
+	result := make([]BookmarkedGuide, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		guide, ok := byID[b.PanicGuideID]
+		if !ok {
+			continue
+		}
+		result = append(result, BookmarkedGuide{
+			PanicGuide:   guide,
+			Folder:       b.Folder,
+			Position:     b.Position,
+			Pinned:       b.Pinned,
+			BookmarkedAt: b.BookmarkedAt,
+		})
+	}
+	return result, nil
+}
+
+// GetBookmarkFolders lists the folders of a user with the number of bookmarks in each.
+func (r *Repository) GetBookmarkFolders(userID uint) ([]BookmarkFolder, error) {
+	var folders []BookmarkFolder
+	if err := r.db.Model(&model.UserPanicGuide{}).
+		Select("folder, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("folder").
+		Order("folder asc").
+		Scan(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// GetBookmarkedIDs returns the set of guide IDs bookmarked by a user.
+func (r *Repository) GetBookmarkedIDs(userID uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := r.db.Model(&model.UserPanicGuide{}).
+		Where("user_id = ?", userID).
+		Pluck("panic_guide_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// Bookmark marks a panic guide as bookmarked for a user. Bookmarking the same
+// guide again is a no-op; the returned flag reports whether a bookmark was created.
+func (s *Service) Bookmark(userID, guideID uint, folder string, pinned bool) (bool, error) {
+	if userID == 0 || guideID == 0 {
+		return false, errors.New("userID and guideID must be provided")
+	}
+	return s.repo.BookmarkPanicGuide(userID, guideID, folder, pinned)
+}
+
+// Unbookmark removes a guide from the user's bookmarks.
+func (s *Service) Unbookmark(userID, guideID uint) error {
+	if userID == 0 || guideID == 0 {
+		return errors.New("userID and guideID must be provided")
+	}
+	return s.repo.RemoveBookmark(userID, guideID)
+}
+
+// UpdateBookmark moves, reorders or pins a bookmark.
+func (s *Service) UpdateBookmark(userID, guideID uint, update BookmarkUpdate) error {
+	if userID == 0 || guideID == 0 {
+		return errors.New("userID and guideID must be provided")
+	}
+	return s.repo.UpdateBookmark(userID, guideID, update)
+}
+
+// ReorderBookmarks sets the order of bookmarks within a folder.
+func (s *Service) ReorderBookmarks(userID uint, folder string, guideIDs []uint) error {
+	if userID == 0 {
+		return errors.New("userID must be provided")
+	}
+	return s.repo.ReorderBookmarks(userID, folder, guideIDs)
+}
+
+// GetBookmarks retrieves the bookmarked guides of a user, optionally limited to one folder.
+func (s *Service) GetBookmarks(userID uint, folder *string) ([]BookmarkedGuide, error) {
+	if userID == 0 {
+		return nil, errors.New("userID must be provided")
+	}
+	return s.repo.GetUserBookmarkedGuides(userID, folder)
+}
+
+// GetBookmarkFolders lists the bookmark folders of a user.
+func (s *Service) GetBookmarkFolders(userID uint) ([]BookmarkFolder, error) {
+	if userID == 0 {
+		return nil, errors.New("userID must be provided")
+	}
+	return s.repo.GetBookmarkFolders(userID)
+}
+
+// GetAllForUser returns all guides in the locale, flagged with whether the user bookmarked them.
+// A zero userID marks every guide as not bookmarked.
+func (s *Service) GetAllForUser(userID uint, locale string) ([]GuideListItem, error) {
+	guides, err := s.repo.GetAllPanicGuides()
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]*model.PanicGuide, len(guides))
+	for i := range guides {
+		refs[i] = &guides[i]
+	}
+	if err := s.Localize(refs, locale); err != nil {
+		return nil, err
+	}
+	bookmarked := map[uint]bool{}
+	if userID != 0 {
+		if bookmarked, err = s.repo.GetBookmarkedIDs(userID); err != nil {
+			return nil, err
+		}
+	}
+	items := make([]GuideListItem, len(guides))
+	for i, g := range guides {
+		items[i] = GuideListItem{PanicGuide: g, IsBookmarked: bookmarked[g.ID]}
+	}
+	return items, nil
+}
+
+// GetByIDForUser returns a guide in the locale, flagged with whether the user bookmarked it.
+func (s *Service) GetByIDForUser(id, userID uint, locale string) (*GuideListItem, error) {
+	guide, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Localize([]*model.PanicGuide{guide}, locale); err != nil {
+		return nil, err
+	}
+	item := &GuideListItem{PanicGuide: *guide}
+	if userID != 0 {
+		bookmarked, err := s.repo.GetBookmarkedIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+		item.IsBookmarked = bookmarked[id]
+	}
+	return item, nil
+}
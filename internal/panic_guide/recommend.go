@@ -0,0 +1,238 @@
+package panic_guide
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"ps_backend/internal/interest"
+	"ps_backend/internal/vital"
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Recommendation strategies.
+const (
+	StrategyPersonalized = "personalized"
+	StrategyPopularity   = "popularity"
+)
+
+// Score weights of the recommendation components.
+const (
+	weightBookmark      = 3.0
+	weightEffectiveness = 3.0 // applied to the heart rate drop normalized by 10 bpm, clamped to [-1, 1]
+	weightInterest      = 1.5 // per matching interest
+	weightVitalState    = 2.0
+	weightPopularity    = 1.0 // applied to popularity normalized to [0, 1]
+)
+
+// vitalStateWindow is how recent a vital sign must be to describe the current state.
+const vitalStateWindow = 30 * time.Minute
+
+// ScoreBreakdown explains how a recommendation score was composed.
+type ScoreBreakdown struct {
+	Bookmark      float64 `json:"bookmark"`
+	Effectiveness float64 `json:"effectiveness"`
+	Interest      float64 `json:"interest"`
+	VitalState    float64 `json:"vital_state"`
+	Popularity    float64 `json:"popularity"`
+}
+
+// Total sums all score components.
+func (b ScoreBreakdown) Total() float64 {
+	return b.Bookmark + b.Effectiveness + b.Interest + b.VitalState + b.Popularity
+}
+
+// Recommendation is a ranked guide with an explainable score.
+type Recommendation struct {
+	Guide     model.PanicGuide `json:"guide"`
+	Score     float64          `json:"score"`
+	Breakdown ScoreBreakdown   `json:"breakdown"`
+	Reasons   []string         `json:"reasons"`
+}
+
+// RecommendationResult is the ranked list returned to the user.
+type RecommendationResult struct {
+	Strategy string           `json:"strategy"`
+	Items    []Recommendation `json:"items"`
+}
+
+// guideStat holds per-guide aggregates used for scoring.
+type guideStat struct {
+	PanicGuideID uint
+	Value        float64
+}
+
+// Recommender ranks panic guides per user.
+type Recommender struct {
+	db        *gorm.DB
+	repo      *Repository
+	interests *interest.Service
+	vitals    *vital.Service
+}
+
+// NewRecommender creates a new Recommender using the given DB connection.
+func NewRecommender(db *gorm.DB) *Recommender {
+	return &Recommender{
+		db:        db,
+		repo:      NewRepository(db),
+		interests: interest.NewService(db),
+		vitals:    vital.NewService(db),
+	}
+}
+
+// Recommend ranks all guides for the user. Users without bookmarks, sessions
+// or interests get guides ranked by popularity.
+func (r *Recommender) Recommend(userID uint, limit int) (*RecommendationResult, error) {
+	if userID == 0 {
+		return nil, errors.New("userID must be provided")
+	}
+	guides, err := r.repo.GetAllPanicGuides()
+	if err != nil {
+		return nil, err
+	}
+
+	popularity, err := r.popularity()
+	if err != nil {
+		return nil, err
+	}
+	bookmarked, err := r.repo.GetBookmarkedIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	drops, err := r.heartRateDrops(userID)
+	if err != nil {
+		return nil, err
+	}
+	userInterests, err := r.interests.GetUserInterests(userID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := r.vitals.GetLatestVital(userID, time.Now().Add(-vitalStateWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := StrategyPersonalized
+	if len(bookmarked) == 0 && len(drops) == 0 && len(userInterests) == 0 {
+		strategy = StrategyPopularity
+	}
+
+	maxPopularity := 0.0
+	for _, v := range popularity {
+		maxPopularity = math.Max(maxPopularity, v)
+	}
+	interestNames := make(map[uint]string, len(userInterests))
+	for _, in := range userInterests {
+		interestNames[in.ID] = in.Name
+	}
+	elevated := latest != nil && (latest.HeartRate >= 100 || latest.StressLevel >= 70)
+
+	items := make([]Recommendation, 0, len(guides))
+	for _, guide := range guides {
+		var b ScoreBreakdown
+		var reasons []string
+
+		if maxPopularity > 0 && popularity[guide.ID] > 0 {
+			b.Popularity = weightPopularity * popularity[guide.ID] / maxPopularity
+			reasons = append(reasons, "popular with other users")
+		}
+
+		if strategy == StrategyPersonalized {
+			if bookmarked[guide.ID] {
+				b.Bookmark = weightBookmark
+				reasons = append(reasons, "you bookmarked this guide")
+			}
+			if drop, ok := drops[guide.ID]; ok {
+				b.Effectiveness = weightEffectiveness * math.Max(-1, math.Min(1, drop/10))
+				if drop > 0 {
+					reasons = append(reasons, fmt.Sprintf("lowered your heart rate by %.1f bpm on average", drop))
+				}
+			}
+			for _, in := range guide.Interests {
+				if name, ok := interestNames[in.ID]; ok {
+					b.Interest += weightInterest
+					reasons = append(reasons, "matches your interest "+name)
+				}
+			}
+		}
+
+		if elevated {
+			if hasStepType(guide, model.StepTypeBreathing) {
+				b.VitalState += weightVitalState
+				reasons = append(reasons, "breathing exercise suited to your elevated heart rate")
+			}
+			if guide.EstimatedSeconds > 0 && guide.EstimatedSeconds <= 120 {
+				b.VitalState += weightVitalState / 2
+				reasons = append(reasons, "short enough to do right now")
+			}
+		}
+
+		items = append(items, Recommendation{
+			Guide:     guide,
+			Score:     math.Round(b.Total()*100) / 100,
+			Breakdown: b,
+			Reasons:   reasons,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return &RecommendationResult{Strategy: strategy, Items: items}, nil
+}
+
+// popularity counts bookmarks and completed breathing sessions per guide across all users.
+func (r *Recommender) popularity() (map[uint]float64, error) {
+	var stats []guideStat
+	err := r.db.Raw(`
+		SELECT panic_guide_id, COUNT(*) AS value FROM (
+			SELECT panic_guide_id FROM user_panic_guides
+			UNION ALL
+			SELECT panic_guide_id FROM breathing_sessions WHERE status = ?
+		) AS activity
+		GROUP BY panic_guide_id`, model.SessionStatusCompleted).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return toMap(stats), nil
+}
+
+// heartRateDrops returns the user's average heart rate drop per guide over measured sessions.
+func (r *Recommender) heartRateDrops(userID uint) (map[uint]float64, error) {
+	var stats []guideStat
+	err := r.db.Model(&model.BreathingSession{}).
+		Select("panic_guide_id, AVG(heart_rate_before - heart_rate_after) AS value").
+		Where("user_id = ? AND status = ? AND heart_rate_before IS NOT NULL AND heart_rate_after IS NOT NULL",
+			userID, model.SessionStatusCompleted).
+		Group("panic_guide_id").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return toMap(stats), nil
+}
+
+func toMap(stats []guideStat) map[uint]float64 {
+	m := make(map[uint]float64, len(stats))
+	for _, s := range stats {
+		m[s.PanicGuideID] = s.Value
+	}
+	return m
+}
+
+func hasStepType(guide model.PanicGuide, stepType string) bool {
+	for _, step := range guide.Steps {
+		if step.Type == stepType {
+			return true
+		}
+	}
+	return false
+}
@@ -3,10 +3,15 @@ package panic_guide
 import (
 	"errors"
 	"ps_backend/model"
+	"ps_backend/pkg/utils"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+// ErrGuideNotFound is returned when the requested panic guide does not exist.
+var ErrGuideNotFound = errors.New("panic guide not found")
+
 // Repository provides database access for panic guides.
 type Repository struct {
 	db *gorm.DB
@@ -17,42 +22,88 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// GetAllPanicGuides retrieves all panic guides.
+// withOrderedSteps preloads guide steps in their display order and the tagged interests.
+func withOrderedSteps(db *gorm.DB) *gorm.DB {
+	return db.Preload("Steps", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("position asc")
+	}).Preload("Interests")
+}
+
+// GetAllPanicGuides retrieves all panic guides with their steps.
 func (r *Repository) GetAllPanicGuides() ([]model.PanicGuide, error) {
 	var guides []model.PanicGuide
-	if err := r.db.Find(&guides).Error; err != nil {
+	if err := withOrderedSteps(r.db).Order("id asc").Find(&guides).Error; err != nil {
 		return nil, err
 	}
 	return guides, nil
 }
 
-// CreatePanicGuide inserts a new panic guide with given title and description.
-func (r *Repository) CreatePanicGuide(title, description string) (*model.PanicGuide, error) {
-	guide := &model.PanicGuide{Title: title, Description: description}
-	if err := r.db.Create(guide).Error; err != nil {
+// GetPanicGuideByID retrieves a single panic guide with its steps.
+func (r *Repository) GetPanicGuideByID(id uint) (*model.PanicGuide, error) {
+	var guide model.PanicGuide
+	if err := withOrderedSteps(r.db).First(&guide, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGuideNotFound
+		}
 		return nil, err
 	}
-	return guide, nil
+	return &guide, nil
 }
 
-// BookmarkPanicGuide creates a user_panic_guides entry.
-func (r *Repository) BookmarkPanicGuide(userID, guideID uint) error {
-	entry := &model.UserPanicGuide{UserID: userID, PanicGuideID: guideID}
-	return r.db.Create(entry).Error
+// CreatePanicGuide inserts a new panic guide together with its steps and interest tags.
+func (r *Repository) CreatePanicGuide(guide *model.PanicGuide) error {
+	return r.db.Omit("Interests.*").Create(guide).Error
 }
 
-// GetUserBookmarkedGuides returns guides bookmarked by a user.
-func (r *Repository) GetUserBookmarkedGuides(userID uint) ([]model.PanicGuide, error) {
-	var guides []model.PanicGuide
-	if err := r.db.
-		Table("panic_guides").
-		Select("panic_guides.*").
-		Joins("join user_panic_guides on user_panic_guides.panic_guide_id = panic_guides.id").
-		Where("user_panic_guides.user_id = ?", userID).
-		Scan(&guides).Error; err != nil {
-		return nil, err
-	}
-	return guides, nil
+// UpdatePanicGuide saves guide fields and replaces all of its steps.
+func (r *Repository) UpdatePanicGuide(guide *model.PanicGuide) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PanicGuide{ID: guide.ID}).Updates(map[string]interface{}{
+			"title":             guide.Title,
+			"locale":            guide.Locale,
+			"description":       guide.Description,
+			"difficulty":        guide.Difficulty,
+			"estimated_seconds": guide.EstimatedSeconds,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Interests.*").Model(&model.PanicGuide{ID: guide.ID}).Association("Interests").Replace(guide.Interests); err != nil {
+			return err
+		}
+		if err := tx.Where("panic_guide_id = ?", guide.ID).Delete(&model.PanicGuideStep{}).Error; err != nil {
+			return err
+		}
+		for i := range guide.Steps {
+			guide.Steps[i].ID = 0
+			guide.Steps[i].PanicGuideID = guide.ID
+		}
+		if len(guide.Steps) == 0 {
+			return nil
+		}
+		return tx.Create(&guide.Steps).Error
+	})
+}
+
+// DeletePanicGuide removes a guide along with its steps and bookmarks.
+func (r *Repository) DeletePanicGuide(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("panic_guide_id = ?", id).Delete(&model.PanicGuideStep{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("panic_guide_id = ?", id).Delete(&model.UserPanicGuide{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.PanicGuide{ID: id}).Association("Interests").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("panic_guide_id = ?", id).Delete(&model.PanicGuideStepTranslation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("panic_guide_id = ?", id).Delete(&model.PanicGuideTranslation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.PanicGuide{}, id).Error
+	})
 }
 
 // Service provides business logic for panic guides.
@@ -70,29 +121,84 @@ func (s *Service) GetAll() ([]model.PanicGuide, error) {
 	return s.repo.GetAllPanicGuides()
 }
 
-// Create adds a new panic guide with given title and description.
-func (s *Service) Create(title, description string) (*model.PanicGuide, error) {
-	if title == "" {
-		return nil, errors.New("title cannot be empty")
+// GetByID retrieves a panic guide with its ordered steps.
+func (s *Service) GetByID(id uint) (*model.PanicGuide, error) {
+	if id == 0 {
+		return nil, errors.New("guideID must be provided")
+	}
+	return s.repo.GetPanicGuideByID(id)
+}
+
+// Create adds a new panic guide with its steps.
+func (s *Service) Create(guide *model.PanicGuide) (*model.PanicGuide, error) {
+	if err := prepareGuide(guide); err != nil {
+		return nil, err
 	}
-	if description == "" {
-		return nil, errors.New("description cannot be empty")
+	if err := s.repo.CreatePanicGuide(guide); err != nil {
+		return nil, err
 	}
-	return s.repo.CreatePanicGuide(title, description)
+	return guide, nil
 }
 
-// Bookmark marks a panic guide as bookmarked for a user.
-func (s *Service) Bookmark(userID, guideID uint) error {
-	if userID == 0 || guideID == 0 {
-		return errors.New("userID and guideID must be provided")
+// Update replaces the content and steps of an existing panic guide.
+func (s *Service) Update(id uint, guide *model.PanicGuide) (*model.PanicGuide, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	guide.ID = id
+	if err := prepareGuide(guide); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePanicGuide(guide); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPanicGuideByID(id)
+}
+
+// Delete removes a panic guide.
+func (s *Service) Delete(id uint) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
 	}
-	return s.repo.BookmarkPanicGuide(userID, guideID)
+	return s.repo.DeletePanicGuide(id)
 }
 
-// GetBookmarks retrieves all bookmarked guides for a user.
-func (s *Service) GetBookmarks(userID uint) ([]model.PanicGuide, error) {
-	if userID == 0 {
-		return nil, errors.New("userID must be provided")
+// prepareGuide validates a guide, numbers its steps and computes the estimated total time.
+func prepareGuide(guide *model.PanicGuide) error {
+	if guide == nil {
+		return errors.New("guide must be provided")
+	}
+	if guide.Title == "" {
+		return errors.New("title cannot be empty")
+	}
+	if guide.Description == "" {
+		return errors.New("description cannot be empty")
+	}
+	if guide.Difficulty == "" {
+		guide.Difficulty = model.DifficultyEasy
+	}
+	if guide.Locale == "" {
+		guide.Locale = utils.DefaultLocale()
+	}
+	guide.Locale = strings.ToLower(guide.Locale)
+	if !utils.IsSupportedLocale(guide.Locale) {
+		return ErrUnsupportedLocale
+	}
+	// A guide without explicit steps becomes a single-step guide.
+	if len(guide.Steps) == 0 {
+		guide.Steps = []model.PanicGuideStep{{Type: model.StepTypeGeneral, Instruction: guide.Description}}
+	}
+	total := 0
+	for i := range guide.Steps {
+		if guide.Steps[i].Instruction == "" {
+			return errors.New("step instruction cannot be empty")
+		}
+		if guide.Steps[i].DurationSeconds < 0 {
+			return errors.New("step duration cannot be negative")
+		}
+		guide.Steps[i].Position = i + 1
+		total += guide.Steps[i].DurationSeconds
 	}
-	return s.repo.GetUserBookmarkedGuides(userID)
+	guide.EstimatedSeconds = total
+	return nil
 }
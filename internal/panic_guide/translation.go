@@ -0,0 +1,248 @@
+package panic_guide
+
+import (
+	"errors"
+	"strings"
+
+	"ps_backend/model"
+	"ps_backend/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnsupportedLocale is returned for locales not listed in SUPPORTED_LOCALES.
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+// ErrTranslationNotFound is returned when a guide has no translation for the locale.
+var ErrTranslationNotFound = errors.New("translation not found")
+
+// GuideTranslation is a guide translation together with its step translations.
+type GuideTranslation struct {
+	model.PanicGuideTranslation
+	Steps []model.PanicGuideStepTranslation `json:"steps"`
+}
+
+// MissingTranslation reports the parts of a guide not yet translated into a locale.
+type MissingTranslation struct {
+	PanicGuideID uint   `json:"panic_guide_id"`
+	Title        string `json:"title"`
+	MissingGuide bool   `json:"missing_guide"`
+	MissingSteps []int  `json:"missing_steps"` // step positions
+}
+
+// GetTranslations loads guide and step translations of the given guides in one locale.
+func (r *Repository) GetTranslations(guideIDs []uint, locale string) (map[uint]model.PanicGuideTranslation, map[uint]map[int]model.PanicGuideStepTranslation, error) {
+	var guideRows []model.PanicGuideTranslation
+	if err := r.db.Where("panic_guide_id IN ? AND locale = ?", guideIDs, locale).Find(&guideRows).Error; err != nil {
+		return nil, nil, err
+	}
+	var stepRows []model.PanicGuideStepTranslation
+	if err := r.db.Where("panic_guide_id IN ? AND locale = ?", guideIDs, locale).Find(&stepRows).Error; err != nil {
+		return nil, nil, err
+	}
+	guides := make(map[uint]model.PanicGuideTranslation, len(guideRows))
+	for _, t := range guideRows {
+		guides[t.PanicGuideID] = t
+	}
+	steps := make(map[uint]map[int]model.PanicGuideStepTranslation)
+	for _, t := range stepRows {
+		if steps[t.PanicGuideID] == nil {
+			steps[t.PanicGuideID] = make(map[int]model.PanicGuideStepTranslation)
+		}
+		steps[t.PanicGuideID][t.Position] = t
+	}
+	return guides, steps, nil
+}
+
+// UpsertTranslation creates or replaces the translation of a guide in one locale.
+// Step translations not included are removed.
+func (r *Repository) UpsertTranslation(t *GuideTranslation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "panic_guide_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"title", "description", "updated_at"}),
+		}).Create(&t.PanicGuideTranslation).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("panic_guide_id = ? AND locale = ?", t.PanicGuideID, t.Locale).
+			Delete(&model.PanicGuideStepTranslation{}).Error; err != nil {
+			return err
+		}
+		if len(t.Steps) == 0 {
+			return nil
+		}
+		return tx.Create(&t.Steps).Error
+	})
+}
+
+// DeleteTranslation removes a guide translation and its step translations.
+func (r *Repository) DeleteTranslation(guideID uint, locale string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("panic_guide_id = ? AND locale = ?", guideID, locale).Delete(&model.PanicGuideTranslation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTranslationNotFound
+		}
+		return tx.Where("panic_guide_id = ? AND locale = ?", guideID, locale).Delete(&model.PanicGuideStepTranslation{}).Error
+	})
+}
+
+// GetGuideTranslations returns all translations of a guide.
+func (r *Repository) GetGuideTranslations(guideID uint) ([]GuideTranslation, error) {
+	var guideRows []model.PanicGuideTranslation
+	if err := r.db.Where("panic_guide_id = ?", guideID).Order("locale asc").Find(&guideRows).Error; err != nil {
+		return nil, err
+	}
+	var stepRows []model.PanicGuideStepTranslation
+	if err := r.db.Where("panic_guide_id = ?", guideID).Order("position asc").Find(&stepRows).Error; err != nil {
+		return nil, err
+	}
+	result := make([]GuideTranslation, len(guideRows))
+	for i, t := range guideRows {
+		result[i] = GuideTranslation{PanicGuideTranslation: t, Steps: []model.PanicGuideStepTranslation{}}
+		for _, st := range stepRows {
+			if st.Locale == t.Locale {
+				result[i].Steps = append(result[i].Steps, st)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Localize rewrites guide content into the locale when the guide and all of
+// its steps are translated. A partly translated guide keeps its original
+// content and Locale, so Locale always names the language of every field.
+func (s *Service) Localize(guides []*model.PanicGuide, locale string) error {
+	var ids []uint
+	for _, g := range guides {
+		if g.Locale != locale {
+			ids = append(ids, g.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	guideTr, stepTr, err := s.repo.GetTranslations(ids, locale)
+	if err != nil {
+		return err
+	}
+	for _, g := range guides {
+		t, ok := guideTr[g.ID]
+		if !ok || g.Locale == locale || !stepsTranslated(g, stepTr[g.ID]) {
+			continue
+		}
+		g.Title = t.Title
+		g.Description = t.Description
+		g.Locale = locale
+		for i := range g.Steps {
+			st := stepTr[g.ID][g.Steps[i].Position]
+			g.Steps[i].Instruction = st.Instruction
+			if st.AudioURL != "" {
+				g.Steps[i].AudioURL = st.AudioURL
+			}
+		}
+	}
+	return nil
+}
+
+// stepsTranslated reports whether every step of the guide has a translation.
+func stepsTranslated(g *model.PanicGuide, steps map[int]model.PanicGuideStepTranslation) bool {
+	for _, step := range g.Steps {
+		if _, ok := steps[step.Position]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SaveTranslation validates and stores the translation of a guide.
+func (s *Service) SaveTranslation(t *GuideTranslation) error {
+	t.Locale = strings.ToLower(t.Locale)
+	if !utils.IsSupportedLocale(t.Locale) {
+		return ErrUnsupportedLocale
+	}
+	if t.Title == "" || t.Description == "" {
+		return errors.New("title and description cannot be empty")
+	}
+	guide, err := s.GetByID(t.PanicGuideID)
+	if err != nil {
+		return err
+	}
+	positions := make(map[int]bool, len(guide.Steps))
+	for _, step := range guide.Steps {
+		positions[step.Position] = true
+	}
+	for i := range t.Steps {
+		if !positions[t.Steps[i].Position] {
+			return errors.New("step position does not exist in guide")
+		}
+		if t.Steps[i].Instruction == "" {
+			return errors.New("step instruction cannot be empty")
+		}
+		t.Steps[i].ID = 0
+		t.Steps[i].PanicGuideID = t.PanicGuideID
+		t.Steps[i].Locale = t.Locale
+	}
+	return s.repo.UpsertTranslation(t)
+}
+
+// GetTranslations returns all translations of a guide.
+func (s *Service) GetTranslations(guideID uint) ([]GuideTranslation, error) {
+	if _, err := s.GetByID(guideID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGuideTranslations(guideID)
+}
+
+// DeleteTranslation removes the translation of a guide in one locale.
+func (s *Service) DeleteTranslation(guideID uint, locale string) error {
+	if guideID == 0 {
+		return errors.New("guideID must be provided")
+	}
+	return s.repo.DeleteTranslation(guideID, strings.ToLower(locale))
+}
+
+// MissingTranslations lists guides lacking a guide or step translation for the locale.
+// Guides authored in that locale are never reported.
+func (s *Service) MissingTranslations(locale string) ([]MissingTranslation, error) {
+	locale = strings.ToLower(locale)
+	if !utils.IsSupportedLocale(locale) {
+		return nil, ErrUnsupportedLocale
+	}
+	guides, err := s.repo.GetAllPanicGuides()
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for _, g := range guides {
+		ids = append(ids, g.ID)
+	}
+	if len(ids) == 0 {
+		return []MissingTranslation{}, nil
+	}
+	guideTr, stepTr, err := s.repo.GetTranslations(ids, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	report := []MissingTranslation{}
+	for _, g := range guides {
+		if g.Locale == locale {
+			continue
+		}
+		_, translated := guideTr[g.ID]
+		entry := MissingTranslation{PanicGuideID: g.ID, Title: g.Title, MissingGuide: !translated, MissingSteps: []int{}}
+		for _, step := range g.Steps {
+			if _, ok := stepTr[g.ID][step.Position]; !ok {
+				entry.MissingSteps = append(entry.MissingSteps, step.Position)
+			}
+		}
+		if entry.MissingGuide || len(entry.MissingSteps) > 0 {
+			report = append(report, entry)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,84 @@
+package prompt
+
+import (
+	"errors"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrPromptNotFound is returned when the requested prompt does not exist.
+var ErrPromptNotFound = errors.New("prompt not found")
+
+// Repository provides database access for chatbot prompts.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new prompt Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetAllPrompts returns all prompts ordered by name.
+func (r *Repository) GetAllPrompts() ([]model.ChatbotPrompt, error) {
+	var prompts []model.ChatbotPrompt
+	if err := r.db.Order("name asc").Find(&prompts).Error; err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// GetPromptByID returns a prompt by ID.
+func (r *Repository) GetPromptByID(id uint) (*model.ChatbotPrompt, error) {
+	var p model.ChatbotPrompt
+	if err := r.db.First(&p, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromptNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetActivePrompt returns the active prompt, or nil when none is active.
+func (r *Repository) GetActivePrompt() (*model.ChatbotPrompt, error) {
+	var p model.ChatbotPrompt
+	err := r.db.Where("active = ?", true).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePrompt inserts a new prompt.
+func (r *Repository) CreatePrompt(p *model.ChatbotPrompt) error {
+	return r.db.Create(p).Error
+}
+
+// SavePrompt persists changes of a prompt.
+func (r *Repository) SavePrompt(p *model.ChatbotPrompt) error {
+	return r.db.Save(p).Error
+}
+
+// DeletePrompt removes a prompt by ID.
+func (r *Repository) DeletePrompt(id uint) error {
+	return r.db.Delete(&model.ChatbotPrompt{}, id).Error
+}
+
+// ActivatePrompt makes the prompt the only active one.
+func (r *Repository) ActivatePrompt(id, updatedBy uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ChatbotPrompt{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.ChatbotPrompt{ID: id}).Updates(map[string]interface{}{
+			"active":     true,
+			"updated_by": updatedBy,
+		}).Error
+	})
+}
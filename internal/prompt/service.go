@@ -0,0 +1,78 @@
+package prompt
+
+import (
+	"errors"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Service provides management of chatbot system prompts.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new prompt Service using the given DB connection.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db)}
+}
+
+// GetAll returns all prompts.
+func (s *Service) GetAll() ([]model.ChatbotPrompt, error) {
+	return s.repo.GetAllPrompts()
+}
+
+// GetActive returns the active prompt, or nil when none is active.
+func (s *Service) GetActive() (*model.ChatbotPrompt, error) {
+	return s.repo.GetActivePrompt()
+}
+
+// Create adds a new, inactive prompt.
+func (s *Service) Create(name, content string, updatedBy uint) (*model.ChatbotPrompt, error) {
+	if name == "" || content == "" {
+		return nil, errors.New("name and content cannot be empty")
+	}
+	p := &model.ChatbotPrompt{Name: name, Content: content, UpdatedBy: updatedBy}
+	if err := s.repo.CreatePrompt(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update changes the name and content of a prompt.
+func (s *Service) Update(id uint, name, content string, updatedBy uint) (*model.ChatbotPrompt, error) {
+	if name == "" || content == "" {
+		return nil, errors.New("name and content cannot be empty")
+	}
+	p, err := s.repo.GetPromptByID(id)
+	if err != nil {
+		return nil, err
+	}
+	p.Name = name
+	p.Content = content
+	p.UpdatedBy = updatedBy
+	if err := s.repo.SavePrompt(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Delete removes a prompt.
+func (s *Service) Delete(id uint) error {
+	if _, err := s.repo.GetPromptByID(id); err != nil {
+		return err
+	}
+	return s.repo.DeletePrompt(id)
+}
+
+// Activate makes the prompt the one used by the chatbot.
+func (s *Service) Activate(id, updatedBy uint) (*model.ChatbotPrompt, error) {
+	if _, err := s.repo.GetPromptByID(id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ActivatePrompt(id, updatedBy); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPromptByID(id)
+}
@@ -0,0 +1,217 @@
+package questionnaire
+
+// Scoring rules.
+const (
+	ScoringSum = "sum" // total score is the sum of the item values
+)
+
+// Option is one value of an instrument's response scale.
+type Option struct {
+	Value  int               `json:"value"`
+	Labels map[string]string `json:"labels"`
+}
+
+// Item is one question of an instrument.
+type Item struct {
+	Number int               `json:"number"` // 1-based
+	Text   map[string]string `json:"text"`
+}
+
+// Band maps a total score range to a severity.
+type Band struct {
+	Min      int               `json:"min"`
+	Max      int               `json:"max"`
+	Severity string            `json:"severity"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// Instrument is a versioned questionnaire definition. Published versions must
+// not change, so that stored responses can always be re-scored; edits are a
+// new version.
+type Instrument struct {
+	Code         string            `json:"code"`
+	Version      int               `json:"version"`
+	Title        map[string]string `json:"title"`
+	Instructions map[string]string `json:"instructions"`
+	Items        []Item            `json:"items"`
+	Options      []Option          `json:"options"` // shared by every item
+	Scoring      string            `json:"scoring"`
+	Bands        []Band            `json:"bands"`
+	SafetyItems  []int             `json:"safety_items"` // item numbers for which any answer above 0 needs a safety response
+}
+
+func l(ko, en string) map[string]string {
+	return map[string]string{"ko": ko, "en": en}
+}
+
+func items(texts ...map[string]string) []Item {
+	out := make([]Item, len(texts))
+	for i, t := range texts {
+		out[i] = Item{Number: i + 1, Text: t}
+	}
+	return out
+}
+
+var frequencyOptions = []Option{
+	{Value: 0, Labels: l("전혀 없음", "Not at all")},
+	{Value: 1, Labels: l("며칠 동안", "Several days")},
+	{Value: 2, Labels: l("일주일 이상", "More than half the days")},
+	{Value: 3, Labels: l("거의 매일", "Nearly every day")},
+}
+
+// instruments holds every published instrument version.
+var instruments = []Instrument{
+	{
+		Code:         "phq9",
+		Version:      1,
+		Title:        l("PHQ-9 우울 척도", "PHQ-9 Depression Scale"),
+		Instructions: l("지난 2주 동안 다음 문제들로 얼마나 자주 불편을 겪으셨나요?", "Over the last 2 weeks, how often have you been bothered by any of the following problems?"),
+		Items: items(
+			l("일을 하는 것에 대한 흥미나 재미가 거의 없음", "Little interest or pleasure in doing things"),
+			l("기분이 가라앉거나, 우울하거나, 희망이 없다고 느낌", "Feeling down, depressed, or hopeless"),
+			l("잠들기 어렵거나 자주 깸, 또는 너무 많이 잠", "Trouble falling or staying asleep, or sleeping too much"),
+			l("피곤하거나 기운이 거의 없음", "Feeling tired or having little energy"),
+			l("식욕이 줄었거나 지나치게 많이 먹음", "Poor appetite or overeating"),
+			l("자신이 실패자라고 느끼거나, 자신이나 가족을 실망시켰다고 느낌", "Feeling bad about yourself, or that you are a failure or have let yourself or your family down"),
+			l("신문을 읽거나 TV를 보는 것과 같은 일에 집중하기 어려움", "Trouble concentrating on things, such as reading the newspaper or watching television"),
+			l("다른 사람들이 눈치챌 정도로 말과 행동이 느림, 또는 너무 안절부절못해서 평소보다 많이 움직임", "Moving or speaking so slowly that other people could have noticed, or being so fidgety or restless that you have been moving around a lot more than usual"),
+			l("차라리 죽는 것이 낫겠다고 생각하거나 어떻게든 스스로를 해치려고 생각함", "Thoughts that you would be better off dead, or of hurting yourself in some way"),
+		),
+		Options: frequencyOptions,
+		Scoring: ScoringSum,
+		Bands: []Band{
+			{Min: 0, Max: 4, Severity: "minimal", Labels: l("우울 아님", "Minimal")},
+			{Min: 5, Max: 9, Severity: "mild", Labels: l("가벼운 우울", "Mild")},
+			{Min: 10, Max: 14, Severity: "moderate", Labels: l("중간 정도 우울", "Moderate")},
+			{Min: 15, Max: 19, Severity: "moderately_severe", Labels: l("약간 심한 우울", "Moderately severe")},
+			{Min: 20, Max: 27, Severity: "severe", Labels: l("심한 우울", "Severe")},
+		},
+		SafetyItems: []int{9},
+	},
+	{
+		Code:         "gad7",
+		Version:      1,
+		Title:        l("GAD-7 불안 척도", "GAD-7 Anxiety Scale"),
+		Instructions: l("지난 2주 동안 다음 문제들로 얼마나 자주 불편을 겪으셨나요?", "Over the last 2 weeks, how often have you been bothered by the following problems?"),
+		Items: items(
+			l("초조하거나 불안하거나 조마조마하게 느낌", "Feeling nervous, anxious, or on edge"),
+			l("걱정하는 것을 멈추거나 조절할 수 없음", "Not being able to stop or control worrying"),
+			l("여러 가지 것들에 대해 걱정을 너무 많이 함", "Worrying too much about different things"),
+			l("편하게 있기가 어려움", "Trouble relaxing"),
+			l("너무 안절부절못해서 가만히 있기 힘듦", "Being so restless that it is hard to sit still"),
+			l("쉽게 짜증이 나거나 쉽게 성을 냄", "Becoming easily annoyed or irritable"),
+			l("마치 끔찍한 일이 생길 것처럼 두렵게 느낌", "Feeling afraid, as if something awful might happen"),
+		),
+		Options: frequencyOptions,
+		Scoring: ScoringSum,
+		Bands: []Band{
+			{Min: 0, Max: 4, Severity: "minimal", Labels: l("불안 아님", "Minimal")},
+			{Min: 5, Max: 9, Severity: "mild", Labels: l("가벼운 불안", "Mild")},
+			{Min: 10, Max: 14, Severity: "moderate", Labels: l("중간 정도 불안", "Moderate")},
+			{Min: 15, Max: 21, Severity: "severe", Labels: l("심한 불안", "Severe")},
+		},
+	},
+	{
+		Code:         "pdss",
+		Version:      1,
+		Title:        l("공황장애 심각도 척도 (PDSS)", "Panic Disorder Severity Scale (PDSS)"),
+		Instructions: l("지난 일주일 동안의 경험을 떠올리며 답해 주세요.", "Answer each question about the past week."),
+		Items: items(
+			l("공황발작(또는 증상이 일부만 나타난 발작)이 얼마나 자주 있었나요?", "How often did you have panic attacks or limited-symptom attacks?"),
+			l("발작이 있는 동안 얼마나 괴로웠나요?", "How distressing were the attacks while you were having them?"),
+			l("다음 발작이 언제 올지, 또는 발작이 무엇을 의미하는지 얼마나 걱정했나요?", "How much did you worry about when the next attack would happen or what the attacks might mean?"),
+			l("발작이 두려워 피하거나 두려워한 장소나 상황이 있었나요?", "Were there places or situations you avoided or feared because of the attacks?"),
+			l("발작과 비슷한 신체 감각이 들까 봐 피하거나 두려워한 활동이 있었나요?", "Were there activities you avoided or feared because they cause sensations like those of an attack?"),
+			l("증상이 일이나 집안일을 하는 데 얼마나 지장을 주었나요?", "How much did the symptoms interfere with your work or responsibilities at home?"),
+			l("증상이 사회생활에 얼마나 지장을 주었나요?", "How much did the symptoms interfere with your social life?"),
+		),
+		Options: []Option{
+			{Value: 0, Labels: l("전혀 없음", "None")},
+			{Value: 1, Labels: l("약간", "Mild")},
+			{Value: 2, Labels: l("보통", "Moderate")},
+			{Value: 3, Labels: l("심함", "Severe")},
+			{Value: 4, Labels: l("매우 심함", "Extreme")},
+		},
+		Scoring: ScoringSum,
+		Bands: []Band{
+			{Min: 0, Max: 1, Severity: "normal", Labels: l("정상", "Normal")},
+			{Min: 2, Max: 5, Severity: "borderline", Labels: l("경계", "Borderline")},
+			{Min: 6, Max: 9, Severity: "mild", Labels: l("가벼운 공황장애", "Mild")},
+			{Min: 10, Max: 13, Severity: "moderate", Labels: l("중간 정도 공황장애", "Moderate")},
+			{Min: 14, Max: 16, Severity: "marked", Labels: l("뚜렷한 공황장애", "Marked")},
+			{Min: 17, Max: 28, Severity: "severe", Labels: l("심한 공황장애", "Severe")},
+		},
+	},
+}
+
+// Latest returns the newest version of every instrument.
+func Latest() []Instrument {
+	latest := make(map[string]int)
+	var codes []string
+	for i, inst := range instruments {
+		j, ok := latest[inst.Code]
+		if !ok {
+			codes = append(codes, inst.Code)
+		}
+		if !ok || inst.Version > instruments[j].Version {
+			latest[inst.Code] = i
+		}
+	}
+	out := make([]Instrument, 0, len(codes))
+	for _, code := range codes {
+		out = append(out, instruments[latest[code]])
+	}
+	return out
+}
+
+// Find returns the instrument with the code and version; version 0 means the newest.
+func Find(code string, version int) (Instrument, bool) {
+	var found Instrument
+	ok := false
+	for _, inst := range instruments {
+		if inst.Code != code {
+			continue
+		}
+		if version == inst.Version {
+			return inst, true
+		}
+		if version == 0 && (!ok || inst.Version > found.Version) {
+			found, ok = inst, true
+		}
+	}
+	return found, ok
+}
+
+// Score computes the total score, severity band and safety flag of the answers.
+// It reports false when the number of answers or a value does not fit the instrument.
+func (inst Instrument) Score(answers []int) (total int, severity string, safety bool, ok bool) {
+	if inst.Scoring != ScoringSum || len(answers) != len(inst.Items) {
+		return 0, "", false, false
+	}
+	for _, a := range answers {
+		if !inst.validValue(a) {
+			return 0, "", false, false
+		}
+		total += a
+	}
+	for _, n := range inst.SafetyItems {
+		if answers[n-1] > 0 {
+			safety = true
+		}
+	}
+	for _, b := range inst.Bands {
+		if total >= b.Min && total <= b.Max {
+			severity = b.Severity
+		}
+	}
+	return total, severity, safety, true
+}
+
+func (inst Instrument) validValue(v int) bool {
+	for _, o := range inst.Options {
+		if o.Value == v {
+			return true
+		}
+	}
+	return false
+}
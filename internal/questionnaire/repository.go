@@ -0,0 +1,45 @@
+package questionnaire
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for questionnaire responses.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new questionnaire Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateResponse inserts a scored response.
+func (r *Repository) CreateResponse(resp *model.QuestionnaireResponse) error {
+	return r.db.Create(resp).Error
+}
+
+// GetResponse retrieves a response owned by the user.
+func (r *Repository) GetResponse(userID, id uint) (*model.QuestionnaireResponse, error) {
+	var resp model.QuestionnaireResponse
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&resp).Error; err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetResponses returns the responses of a user within the range, oldest first,
+// optionally limited to one instrument.
+func (r *Repository) GetResponses(userID uint, instrument string, from, to time.Time) ([]model.QuestionnaireResponse, error) {
+	var items []model.QuestionnaireResponse
+	q := r.db.Where("user_id = ? AND created_at BETWEEN ? AND ?", userID, from, to)
+	if instrument != "" {
+		q = q.Where("instrument = ?", instrument)
+	}
+	err := q.Order("created_at").Find(&items).Error
+	return items, err
+}
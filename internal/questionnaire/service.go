@@ -0,0 +1,79 @@
+package questionnaire
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInstrumentNotFound is returned for unknown instrument codes or versions.
+	ErrInstrumentNotFound = errors.New("instrument not found")
+	// ErrInvalidAnswers is returned when the answers do not match the instrument's items or scale.
+	ErrInvalidAnswers = errors.New("invalid answers")
+	// ErrResponseNotFound is returned when the response does not exist or belongs to another user.
+	ErrResponseNotFound = errors.New("questionnaire response not found")
+)
+
+// Service scores and stores questionnaire responses.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new questionnaire Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db)}
+}
+
+// Submit scores the answers with the instrument version (0 for the newest) and
+// stores the response.
+func (s *Service) Submit(userID uint, code string, version int, answers []int) (*model.QuestionnaireResponse, error) {
+	inst, ok := Find(code, version)
+	if !ok {
+		return nil, ErrInstrumentNotFound
+	}
+	total, severity, safety, ok := inst.Score(answers)
+	if !ok {
+		return nil, ErrInvalidAnswers
+	}
+	resp := &model.QuestionnaireResponse{
+		UserID:     userID,
+		Instrument: inst.Code,
+		Version:    inst.Version,
+		Answers:    answers,
+		TotalScore: total,
+		Severity:   severity,
+		SafetyFlag: safety,
+	}
+	if err := s.repo.CreateResponse(resp); err != nil {
+		return nil, err
+	}
+	if safety {
+		logrus.WithFields(logrus.Fields{"userID": userID, "responseID": resp.ID, "instrument": inst.Code}).
+			Warn("Questionnaire safety item answered positively")
+	}
+	return resp, nil
+}
+
+// Get returns a response of the user.
+func (s *Service) Get(userID, id uint) (*model.QuestionnaireResponse, error) {
+	resp, err := s.repo.GetResponse(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrResponseNotFound
+	}
+	return resp, err
+}
+
+// History returns the responses of a user within the range, optionally for one instrument.
+func (s *Service) History(userID uint, instrument string, from, to time.Time) ([]model.QuestionnaireResponse, error) {
+	if instrument != "" {
+		if _, ok := Find(instrument, 0); !ok {
+			return nil, ErrInstrumentNotFound
+		}
+	}
+	return s.repo.GetResponses(userID, instrument, from, to)
+}
@@ -0,0 +1,150 @@
+package sharing
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// ShareView is a care share together with the usernames of both parties.
+type ShareView struct {
+	model.CareShare
+	Username          string `json:"username"`
+	ClinicianUsername string `json:"clinician_username"`
+}
+
+// ChatSummary aggregates a user's chatbot conversation of one day without message content.
+type ChatSummary struct {
+	Day            time.Time `json:"day"`
+	UserMessages   int64     `json:"user_messages"`
+	BotMessages    int64     `json:"bot_messages"`
+	FirstMessageAt time.Time `json:"first_message_at"`
+	LastMessageAt  time.Time `json:"last_message_at"`
+}
+
+// Repository provides database access for care shares and their access logs.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new sharing Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateShare inserts a new care share.
+func (r *Repository) CreateShare(share *model.CareShare) error {
+	return r.db.Create(share).Error
+}
+
+// SaveShare updates an existing care share.
+func (r *Repository) SaveShare(share *model.CareShare) error {
+	return r.db.Save(share).Error
+}
+
+// ActivateShare assigns a pending share to the clinician. It reports false when
+// the share was no longer pending, e.g. because another clinician accepted it first.
+func (r *Repository) ActivateShare(shareID, clinicianID uint, acceptedAt time.Time) (bool, error) {
+	res := r.db.Model(&model.CareShare{}).
+		Where("id = ? AND status = ?", shareID, model.ShareStatusPending).
+		Updates(map[string]interface{}{
+			"clinician_id": clinicianID,
+			"status":       model.ShareStatusActive,
+			"accepted_at":  acceptedAt,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetShareByCode retrieves a care share by its invitation code.
+func (r *Repository) GetShareByCode(code string) (*model.CareShare, error) {
+	var share model.CareShare
+	if err := r.db.Where("invite_code = ?", code).First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// GetUserShare retrieves a care share issued by the user.
+func (r *Repository) GetUserShare(userID, shareID uint) (*model.CareShare, error) {
+	var share model.CareShare
+	if err := r.db.Where("id = ? AND user_id = ?", shareID, userID).First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// GetActiveShare retrieves the active care share between a user and a clinician.
+func (r *Repository) GetActiveShare(clinicianID, userID uint) (*model.CareShare, error) {
+	var share model.CareShare
+	if err := r.db.Where("clinician_id = ? AND user_id = ? AND status = ?", clinicianID, userID, model.ShareStatusActive).
+		First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *Repository) shareViews() *gorm.DB {
+	return r.db.Table("care_shares").
+		Select("care_shares.*, u.username AS username, cl.username AS clinician_username").
+		Joins("JOIN users u ON u.id = care_shares.user_id").
+		Joins("LEFT JOIN users cl ON cl.id = care_shares.clinician_id").
+		Order("care_shares.created_at desc")
+}
+
+// GetUserShares returns every care share issued by the user.
+func (r *Repository) GetUserShares(userID uint) ([]ShareView, error) {
+	var views []ShareView
+	if err := r.shareViews().Where("care_shares.user_id = ?", userID).Find(&views).Error; err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// GetClinicianShares returns the active care shares accepted by the clinician.
+func (r *Repository) GetClinicianShares(clinicianID uint) ([]ShareView, error) {
+	var views []ShareView
+	if err := r.shareViews().
+		Where("care_shares.clinician_id = ? AND care_shares.status = ?", clinicianID, model.ShareStatusActive).
+		Find(&views).Error; err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// CreateAccessLog inserts an access log entry.
+func (r *Repository) CreateAccessLog(entry *model.CareShareAccessLog) error {
+	return r.db.Create(entry).Error
+}
+
+// GetAccessLogs returns the latest access log entries of a care share.
+func (r *Repository) GetAccessLogs(shareID uint, limit int) ([]model.CareShareAccessLog, error) {
+	var logs []model.CareShareAccessLog
+	if err := r.db.Where("care_share_id = ?", shareID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// GetChatSummaries returns per-day chatbot message counts of a user within [from, to].
+func (r *Repository) GetChatSummaries(userID uint, from, to time.Time) ([]ChatSummary, error) {
+	var summaries []ChatSummary
+	err := r.db.Model(&model.ChatbotLog{}).
+		Select(`DATE_TRUNC('day', created_at) AS day,
+			COUNT(*) FILTER (WHERE sender = 'user') AS user_messages,
+			COUNT(*) FILTER (WHERE sender = 'bot') AS bot_messages,
+			MIN(created_at) AS first_message_at,
+			MAX(created_at) AS last_message_at`).
+		Where("user_id = ? AND created_at BETWEEN ? AND ?", userID, from, to).
+		Group("day").
+		Order("day desc").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
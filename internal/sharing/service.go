@@ -0,0 +1,212 @@
+package sharing
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"time"
+
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+// inviteAlphabet leaves out characters that are easy to confuse when read aloud (0/O, 1/I).
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const inviteCodeLength = 10
+
+var (
+	// ErrShareNotFound is returned when there is no matching share for the caller.
+	ErrShareNotFound = errors.New("care share not found")
+	// ErrInviteInvalid is returned for unknown, expired, revoked or already accepted invitations.
+	ErrInviteInvalid = errors.New("invitation is invalid or expired")
+	// ErrAlreadyShared is returned when the clinician already has an active share with the user.
+	ErrAlreadyShared = errors.New("already sharing with this clinician")
+	// ErrScopeNotGranted is returned when the share does not cover the requested data.
+	ErrScopeNotGranted = errors.New("scope not granted")
+	// ErrInvalidScope is returned for unknown or empty scopes.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// AccessInfo describes a clinician request for audit logging.
+type AccessInfo struct {
+	Path     string
+	ClientIP string
+}
+
+// Service manages consent-based sharing of user data with clinicians.
+type Service struct {
+	repo      *Repository
+	inviteTTL time.Duration
+}
+
+// NewService creates a new sharing Service. Invitations expire after
+// SHARE_INVITE_TTL (default 72h).
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db), inviteTTL: config.Get().Share.InviteTTL}
+}
+
+// CreateInvite issues an invitation code granting the given scopes to whichever clinician accepts it.
+func (s *Service) CreateInvite(userID uint, scopes []string) (*model.CareShare, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	seen := make(map[string]bool)
+	var unique []string
+	for _, sc := range scopes {
+		if !isShareScope(sc) {
+			return nil, ErrInvalidScope
+		}
+		if !seen[sc] {
+			seen[sc] = true
+			unique = append(unique, sc)
+		}
+	}
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	share := &model.CareShare{
+		UserID:          userID,
+		InviteCode:      code,
+		Scopes:          unique,
+		Status:          model.ShareStatusPending,
+		InviteExpiresAt: time.Now().Add(s.inviteTTL),
+	}
+	if err := s.repo.CreateShare(share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// ListForUser returns every share issued by the user.
+func (s *Service) ListForUser(userID uint) ([]ShareView, error) {
+	return s.repo.GetUserShares(userID)
+}
+
+// Revoke ends a pending or active share of the user. Revoking twice is a no-op.
+func (s *Service) Revoke(userID, shareID uint) (*model.CareShare, error) {
+	share, err := s.repo.GetUserShare(userID, shareID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if share.Status == model.ShareStatusRevoked {
+		return share, nil
+	}
+	now := time.Now()
+	share.Status = model.ShareStatusRevoked
+	share.RevokedAt = &now
+	if err := s.repo.SaveShare(share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// AccessLogs returns the access log of a share issued by the user.
+func (s *Service) AccessLogs(userID, shareID uint, limit int) ([]model.CareShareAccessLog, error) {
+	if _, err := s.repo.GetUserShare(userID, shareID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.GetAccessLogs(shareID, limit)
+}
+
+// Accept activates the invitation for the clinician.
+func (s *Service) Accept(clinicianID uint, code string) (*model.CareShare, error) {
+	share, err := s.repo.GetShareByCode(code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if share.Status != model.ShareStatusPending || time.Now().After(share.InviteExpiresAt) || share.UserID == clinicianID {
+		return nil, ErrInviteInvalid
+	}
+	if _, err := s.repo.GetActiveShare(clinicianID, share.UserID); err == nil {
+		return nil, ErrAlreadyShared
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	now := time.Now()
+	activated, err := s.repo.ActivateShare(share.ID, clinicianID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !activated {
+		return nil, ErrInviteInvalid
+	}
+	share.ClinicianID = &clinicianID
+	share.Status = model.ShareStatusActive
+	share.AcceptedAt = &now
+	return share, nil
+}
+
+// ListPatients returns the active shares accepted by the clinician.
+func (s *Service) ListPatients(clinicianID uint) ([]ShareView, error) {
+	return s.repo.GetClinicianShares(clinicianID)
+}
+
+// Authorize checks that the clinician may read the scope of the user's data and
+// records the access. Every successful call leaves an access log entry.
+func (s *Service) Authorize(clinicianID, userID uint, scope string, info AccessInfo) (*model.CareShare, error) {
+	share, err := s.repo.GetActiveShare(clinicianID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !share.HasScope(scope) {
+		return nil, ErrScopeNotGranted
+	}
+	entry := &model.CareShareAccessLog{
+		CareShareID: share.ID,
+		ClinicianID: clinicianID,
+		UserID:      userID,
+		Scope:       scope,
+		Path:        info.Path,
+		ClientIP:    info.ClientIP,
+	}
+	if err := s.repo.CreateAccessLog(entry); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// ChatSummaries returns per-day chatbot activity of a user within [from, to].
+func (s *Service) ChatSummaries(userID uint, from, to time.Time) ([]ChatSummary, error) {
+	return s.repo.GetChatSummaries(userID, from, to)
+}
+
+func isShareScope(scope string) bool {
+	for _, sc := range model.ShareScopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func newInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	max := big.NewInt(int64(len(inviteAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,66 @@
+package token
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+)
+
+// Repository provides database access for refresh tokens.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new token Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUser retrieves the user a token is issued for.
+func (r *Repository) GetUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateToken inserts a new refresh token record.
+func (r *Repository) CreateToken(t *model.RefreshToken) error {
+	return r.db.Create(t).Error
+}
+
+// RevokeToken revokes an unrevoked, unexpired refresh token of the user and
+// reports whether it was still usable. It is the single point where a refresh
+// token gets consumed, so a token can only be rotated once.
+func (r *Repository) RevokeToken(userID uint, tokenID string, at time.Time) (bool, error) {
+	res := r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND token_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, tokenID, at).
+		Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// RevokeUserTokens revokes every unrevoked refresh token of the user and
+// invalidates the access tokens issued so far.
+func (r *Repository) RevokeUserTokens(userID uint, at time.Time) (int64, error) {
+	var revoked int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("tokens_valid_after", at).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", at)
+		revoked = res.RowsAffected
+		return res.Error
+	})
+	return revoked, err
+}
+
+// DeleteExpiredTokens removes refresh token records that expired before the given time.
+func (r *Repository) DeleteExpiredTokens(before time.Time) (int64, error) {
+	res := r.db.Where("expires_at < ?", before).Delete(&model.RefreshToken{})
+	return res.RowsAffected, res.Error
+}
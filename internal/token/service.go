@@ -0,0 +1,112 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ps_backend/internal/jobs"
+	"ps_backend/model"
+	jwt "ps_backend/pkg/middleware"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRefreshToken is returned for malformed, expired, revoked or already used refresh tokens.
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// Service issues, rotates and revokes refresh tokens.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new token Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db)}
+}
+
+// Issue creates a token pair for the user and records the refresh token. The
+// pair is bound to the device unless deviceID is zero.
+func (s *Service) Issue(user *model.User, deviceID uint) (*jwt.TokenPair, error) {
+	pair, err := jwt.IssueTokens(user.ID, user.Username, user.Role, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	record := &model.RefreshToken{
+		UserID:    user.ID,
+		TokenID:   pair.RefreshID,
+		ExpiresAt: pair.RefreshExpiresAt,
+	}
+	if deviceID != 0 {
+		record.DeviceID = &deviceID
+	}
+	if err := s.repo.CreateToken(record); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Rotate consumes a refresh token and issues a new pair for the current state
+// of the user, so role changes take effect on refresh. The new pair stays bound
+// to the same device.
+func (s *Service) Rotate(refreshToken string) (*model.User, *jwt.TokenPair, error) {
+	claims, err := jwt.ParseRefreshToken(refreshToken)
+	if err != nil || claims.ID == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	ok, err := s.repo.RevokeToken(claims.UserID, claims.ID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	user, err := s.repo.GetUser(claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	pair, err := s.Issue(user, claims.DeviceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, pair, nil
+}
+
+// Revoke invalidates a single refresh token, e.g. on sign-out.
+func (s *Service) Revoke(refreshToken string) error {
+	claims, err := jwt.ParseRefreshToken(refreshToken)
+	if err != nil || claims.ID == "" {
+		return ErrInvalidRefreshToken
+	}
+	_, err = s.repo.RevokeToken(claims.UserID, claims.ID, time.Now())
+	return err
+}
+
+// RevokeAll invalidates every refresh token of the user and returns how many were revoked.
+func (s *Service) RevokeAll(userID uint) (int64, error) {
+	return s.repo.RevokeUserTokens(userID, time.Now())
+}
+
+// PurgeExpired removes records of refresh tokens that can no longer be used.
+func (s *Service) PurgeExpired() (int64, error) {
+	return s.repo.DeleteExpiredTokens(time.Now())
+}
+
+// JobPurge is the background job type removing expired refresh token records.
+const JobPurge = "token.purge"
+
+// RegisterJobs registers the daily purge of expired refresh tokens.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobPurge, jobs.Options{MaxAttempts: 1}, func(ctx context.Context, _ struct{}) error {
+		n, err := s.PurgeExpired()
+		if n > 0 {
+			logrus.Infof("Purged %d expired refresh tokens", n)
+		}
+		return err
+	})
+	r.MustSchedule("refresh-token-purge", "50 4 * * *", JobPurge)
+}
@@ -0,0 +1,71 @@
+package usage
+
+import (
+	"time"
+
+	"ps_backend/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository provides database access for LLM usage records.
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new usage Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ReserveUsage inserts entry when allow, given a repository in the same
+// transaction, accepts the usage recorded so far. The user's row is locked, so
+// the reservations of one user run one at a time.
+func (r *Repository) ReserveUsage(entry *model.LLMUsage, allow func(repo *Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&model.User{}, entry.UserID).Error; err != nil {
+			return err
+		}
+		if err := allow(NewRepository(tx)); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// FinishUsage stores the outcome of a reserved LLM call.
+func (r *Repository) FinishUsage(entry *model.LLMUsage) error {
+	return r.db.Model(entry).
+		Select("prompt_tokens", "completion_tokens", "total_tokens", "latency_ms", "success").
+		Updates(entry).Error
+}
+
+// SumTokensSince returns the total tokens and request count of a user since the given time.
+func (r *Repository) SumTokensSince(userID uint, since time.Time) (tokens int64, requests int64, err error) {
+	var row struct {
+		Tokens   int64
+		Requests int64
+	}
+	err = r.db.Model(&model.LLMUsage{}).
+		Select("COALESCE(SUM(total_tokens), 0) AS tokens, COUNT(*) AS requests").
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Scan(&row).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return row.Tokens, row.Requests, nil
+}
+
+// GetRecentUsage returns the latest usage records of a user.
+func (r *Repository) GetRecentUsage(userID uint, limit int) ([]model.LLMUsage, error) {
+	var records []model.LLMUsage
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,160 @@
+package usage
+
+import (
+	"errors"
+	"time"
+
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPlan is used for users without an explicit plan.
+const DefaultPlan = "free"
+
+// ErrQuotaExceeded is returned when a user has used up the daily or monthly quota.
+var ErrQuotaExceeded = errors.New("llm usage quota exceeded")
+
+// PlanQuota holds token limits of a plan. A zero limit means unlimited.
+type PlanQuota struct {
+	DailyTokens   int64 `json:"daily_tokens"`
+	MonthlyTokens int64 `json:"monthly_tokens"`
+}
+
+// QuotaForPlan returns the quota of a plan, configured with
+// LLM_QUOTA_<PLAN>_DAILY and LLM_QUOTA_<PLAN>_MONTHLY. Unknown plans get the
+// quota of the default plan.
+func QuotaForPlan(plan string) PlanQuota {
+	quotas := config.Get().LLMQuota
+	quota, ok := quotas.Plan(plan)
+	if !ok {
+		quota, _ = quotas.Plan(DefaultPlan)
+	}
+	return PlanQuota{DailyTokens: quota.Daily, MonthlyTokens: quota.Monthly}
+}
+
+// Window describes the consumption of a quota window.
+type Window struct {
+	UsedTokens int64     `json:"used_tokens"`
+	Requests   int64     `json:"requests"`
+	Limit      int64     `json:"limit"`
+	ResetsAt   time.Time `json:"resets_at"`
+}
+
+// Exceeded reports whether the window limit has been reached.
+func (w Window) Exceeded() bool {
+	return w.Limit > 0 && w.UsedTokens >= w.Limit
+}
+
+// Summary is the usage overview of a user.
+type Summary struct {
+	Plan    string `json:"plan"`
+	Daily   Window `json:"daily"`
+	Monthly Window `json:"monthly"`
+}
+
+// Service provides LLM usage accounting and quota enforcement.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new usage Service using the given DB connection.
+func NewService(db *gorm.DB) *Service {
+	return &Service{repo: NewRepository(db)}
+}
+
+// Reserve checks that the user has quota left and records an unfinished usage
+// entry holding the estimated tokens of the coming LLM call. Reservations of a
+// user are serialized and count their estimate until Finish replaces it, so
+// concurrent calls cannot all pass on the same remaining quota. When the quota
+// is used up it returns ErrQuotaExceeded together with the summary.
+func (s *Service) Reserve(user *model.User, llmModel string, estimatedTokens int) (*model.LLMUsage, *Summary, error) {
+	if user == nil || user.ID == 0 {
+		return nil, nil, errors.New("user must be provided")
+	}
+	entry := &model.LLMUsage{UserID: user.ID, Model: llmModel, TotalTokens: estimatedTokens}
+	var summary *Summary
+	err := s.repo.ReserveUsage(entry, func(repo *Repository) error {
+		var err error
+		if summary, err = summarize(repo, user); err != nil {
+			return err
+		}
+		if summary.Daily.Exceeded() || summary.Monthly.Exceeded() {
+			return ErrQuotaExceeded
+		}
+		return nil
+	})
+	if errors.Is(err, ErrQuotaExceeded) {
+		return nil, summary, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, summary, nil
+}
+
+// Finish replaces the estimate of a reserved entry with the reported token
+// counts and the outcome of the call.
+func (s *Service) Finish(entry *model.LLMUsage) error {
+	if entry.TotalTokens == 0 {
+		entry.TotalTokens = entry.PromptTokens + entry.CompletionTokens
+	}
+	return s.repo.FinishUsage(entry)
+}
+
+// GetSummary returns the daily and monthly usage of a user against the plan quota.
+func (s *Service) GetSummary(user *model.User) (*Summary, error) {
+	if user == nil || user.ID == 0 {
+		return nil, errors.New("user must be provided")
+	}
+	return summarize(s.repo, user)
+}
+
+func summarize(repo *Repository, user *model.User) (*Summary, error) {
+	plan := user.Plan
+	if plan == "" {
+		plan = DefaultPlan
+	}
+	quota := QuotaForPlan(plan)
+
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	dailyTokens, dailyRequests, err := repo.SumTokensSince(user.ID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+	monthlyTokens, monthlyRequests, err := repo.SumTokensSince(user.ID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Summary{
+		Plan: plan,
+		Daily: Window{
+			UsedTokens: dailyTokens,
+			Requests:   dailyRequests,
+			Limit:      quota.DailyTokens,
+			ResetsAt:   dayStart.AddDate(0, 0, 1),
+		},
+		Monthly: Window{
+			UsedTokens: monthlyTokens,
+			Requests:   monthlyRequests,
+			Limit:      quota.MonthlyTokens,
+			ResetsAt:   monthStart.AddDate(0, 1, 0),
+		},
+	}, nil
+}
+
+// GetRecent returns the latest usage records of a user.
+func (s *Service) GetRecent(userID uint, limit int) ([]model.LLMUsage, error) {
+	if userID == 0 {
+		return nil, errors.New("userID must be provided")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.GetRecentUsage(userID, limit)
+}
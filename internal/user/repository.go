@@ -1,6 +1,8 @@
 package user
 
 import (
+	"time"
+
 	"ps_backend/model"
 
 	"gorm.io/gorm"
@@ -62,7 +64,107 @@ func (r *Repository) Update(user *model.User) error {
 	return r.db.Save(user).Error
 }
 
-// Delete removes a user by ID.
-func (r *Repository) Delete(userID uint) error {
-	return r.db.Delete(&model.User{}, userID).Error
+// UpdateRole sets the role of the user without touching other columns.
+func (r *Repository) UpdateRole(userID uint, role string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+// ScheduleDeletion marks the user for deletion at the given time.
+func (r *Repository) ScheduleDeletion(userID uint, requestedAt, scheduledAt time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"deletion_requested_at": requestedAt,
+		"deletion_scheduled_at": scheduledAt,
+	}).Error
+}
+
+// CancelDeletion clears a pending deletion of the user.
+func (r *Repository) CancelDeletion(userID uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"deletion_requested_at": nil,
+		"deletion_scheduled_at": nil,
+	}).Error
+}
+
+// GetDueForDeletion returns the IDs of users whose deletion grace period ended before the given time.
+func (r *Repository) GetDueForDeletion(before time.Time) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&model.User{}).Where("deletion_scheduled_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// userTables lists the tables holding rows that belong to a user through a user_id column.
+// Rows are deleted in this order, children before parents.
+var userTables = []string{
+	"chatbot_logs",
+	"vital_signs",
+	"user_sub_interests",
+	"user_interests",
+	"user_panic_guides",
+	"breathing_sessions",
+	"emergency_contacts",
+	"panic_episodes",
+	"export_jobs",
+	"refresh_tokens",
+	"password_reset_tokens",
+	"login_histories",
+	"notifications",
+	"notification_preferences",
+	"devices",
+	"check_ins",
+	"check_in_schedules",
+	"questionnaire_responses",
+	"user_insights",
+}
+
+// Purge deletes the user and every row referring to them in one transaction.
+// LLM usage is kept for cost accounting but detached from the user, and the
+// user is removed as the editor of chatbot prompts. It returns the paths of
+// export files that must be removed from disk.
+func (r *Repository) Purge(userID uint) ([]string, error) {
+	var files []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ExportJob{}).Where("user_id = ? AND file_path <> ''", userID).
+			Pluck("file_path", &files).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM sos_alert_deliveries WHERE sos_alert_id IN (SELECT id FROM sos_alerts WHERE user_id = ?)", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM notification_deliveries WHERE notification_id IN (SELECT id FROM notifications WHERE user_id = ?)", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.SOSAlert{}).Error; err != nil {
+			return err
+		}
+		// Shares the user granted go away with their audit trail. Shares where the
+		// user was the clinician are revoked; the patients keep their access logs.
+		if err := tx.Exec("DELETE FROM care_share_access_logs WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.CareShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.CareShare{}).Where("clinician_id = ? AND status <> ?", userID, model.ShareStatusRevoked).
+			Updates(map[string]interface{}{"status": model.ShareStatusRevoked, "revoked_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		for _, table := range userTables {
+			if err := tx.Table(table).Where("user_id = ?", userID).Delete(nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.LLMUsage{}).Where("user_id = ?", userID).Update("user_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.ChatbotPrompt{}).Where("updated_by = ?", userID).Update("updated_by", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, userID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
 }
@@ -1,12 +1,77 @@
 package user
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"ps_backend/internal/jobs"
 	"ps_backend/model"
+	"ps_backend/pkg/config"
+	"ps_backend/pkg/utils"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrInvalidPassword is returned when the current password does not match.
+	ErrInvalidPassword = errors.New("current password is incorrect")
+	// ErrPhoneTaken is returned when the phone number belongs to another user.
+	ErrPhoneTaken = errors.New("phone number already registered")
+	// ErrInvalidTimezone is returned for an unknown IANA time zone.
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	// ErrUnsupportedLocale is returned for a locale the API does not serve.
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+	// ErrInvalidPersona is returned for persona settings outside the catalog.
+	ErrInvalidPersona = errors.New("invalid persona")
+)
+
+// ProfileUpdate holds the profile fields to change; nil fields are left untouched.
+type ProfileUpdate struct {
+	Persona     *PersonaUpdate
+	DisplayName *string
+	Timezone    *string
+	Locale      *string
+}
+
+// PersonaUpdate holds the persona settings to change; nil fields are left untouched.
+type PersonaUpdate struct {
+	Formality *string
+	Warmth    *string
+	Humor     *string
+	Verbosity *string
+	Language  *string
+	Nickname  *string
+}
+
+// MergePersona applies the update to base and validates the result against the persona catalog.
+func MergePersona(base model.Persona, in PersonaUpdate) (model.Persona, error) {
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{
+		{&base.Formality, in.Formality},
+		{&base.Warmth, in.Warmth},
+		{&base.Humor, in.Humor},
+		{&base.Verbosity, in.Verbosity},
+		{&base.Language, in.Language},
+		{&base.Nickname, in.Nickname},
+	} {
+		if f.src != nil {
+			*f.dst = strings.TrimSpace(*f.src)
+		}
+	}
+	base.Language = strings.ToLower(base.Language)
+	if err := base.Validate(utils.SupportedLocales()); err != nil {
+		return base, fmt.Errorf("%w: %v", ErrInvalidPersona, err)
+	}
+	return base, nil
+}
+
 // Service provides user-related business logic.
 type Service struct {
 	repo *Repository
@@ -61,10 +126,153 @@ func (s *Service) Update(user *model.User) error {
 	return s.repo.Update(user)
 }
 
-// Delete removes a user by ID.
+// UpdateProfile applies a partial profile update to the user.
+func (s *Service) UpdateProfile(user *model.User, in ProfileUpdate) error {
+	if in.Timezone != nil {
+		if _, err := time.LoadLocation(*in.Timezone); err != nil || *in.Timezone == "" {
+			return ErrInvalidTimezone
+		}
+		user.Timezone = *in.Timezone
+	}
+	if in.Locale != nil {
+		if *in.Locale != "" && !utils.IsSupportedLocale(*in.Locale) {
+			return ErrUnsupportedLocale
+		}
+		user.Locale = strings.ToLower(*in.Locale)
+	}
+	if in.Persona != nil {
+		persona, err := MergePersona(user.Persona, *in.Persona)
+		if err != nil {
+			return err
+		}
+		user.Persona = persona
+	}
+	if in.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*in.DisplayName)
+	}
+	return s.repo.Update(user)
+}
+
+// ChangePassword replaces the password of the user after checking the current one.
+// The new password must meet the password policy.
+func (s *Service) ChangePassword(user *model.User, current, next string) error {
+	if !utils.CheckPasswordHash(current, user.PasswordHash) {
+		return ErrInvalidPassword
+	}
+	if err := utils.ValidatePassword(next, user.Username); err != nil {
+		return err
+	}
+	hash, err := utils.HashPassword(next)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	return s.repo.Update(user)
+}
+
+// ChangePhone sets a phone number the user confirmed with an OTP.
+func (s *Service) ChangePhone(user *model.User, phone string) error {
+	if phone == user.PhoneNumber {
+		return nil
+	}
+	taken, err := s.repo.ExistsByPhone(phone)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrPhoneTaken
+	}
+	user.PhoneNumber = phone
+	user.Verified = true
+	return s.repo.Update(user)
+}
+
+// ChangeRole sets the role of the user. Only the role column is written, so
+// concurrent changes to the profile are kept.
+func (s *Service) ChangeRole(user *model.User, role string) error {
+	if err := s.repo.UpdateRole(user.ID, role); err != nil {
+		return err
+	}
+	user.Role = role
+	return nil
+}
+
+// Delete permanently removes a user and all of their data.
 func (s *Service) Delete(userID uint) error {
 	if userID == 0 {
 		return errors.New("userID must be provided")
 	}
-	return s.repo.Delete(userID)
+	files, err := s.repo.Purge(userID)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("path", path).Warn("Failed to remove export file of deleted user")
+		}
+	}
+	return nil
+}
+
+// DeletionGracePeriod returns how long a deletion request can be cancelled,
+// configured with ACCOUNT_DELETION_GRACE (default 720h, 30 days).
+func DeletionGracePeriod() time.Duration {
+	return config.Get().Account.DeletionGrace
+}
+
+// RequestDeletion schedules the deletion of a user after the grace period.
+// Requesting again keeps the original schedule.
+func (s *Service) RequestDeletion(user *model.User) error {
+	if user.DeletionScheduledAt != nil {
+		return nil
+	}
+	now := time.Now()
+	scheduled := now.Add(DeletionGracePeriod())
+	if err := s.repo.ScheduleDeletion(user.ID, now, scheduled); err != nil {
+		return err
+	}
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduled
+	return nil
+}
+
+// CancelDeletion cancels a pending deletion of a user.
+func (s *Service) CancelDeletion(user *model.User) error {
+	if err := s.repo.CancelDeletion(user.ID); err != nil {
+		return err
+	}
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	return nil
+}
+
+// PurgeDue deletes every user whose deletion grace period has ended.
+func (s *Service) PurgeDue() (int, error) {
+	ids, err := s.repo.GetDueForDeletion(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, id := range ids {
+		if err := s.Delete(id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// JobPurge is the background job type deleting users past their grace period.
+const JobPurge = "user.purge"
+
+// RegisterJobs registers the hourly purge of deleted users.
+func (s *Service) RegisterJobs(r *jobs.Runner) {
+	jobs.Handle(r, JobPurge, jobs.Options{MaxAttempts: 1, Timeout: 30 * time.Minute}, func(ctx context.Context, _ struct{}) error {
+		n, err := s.PurgeDue()
+		if n > 0 {
+			logrus.Infof("Purged %d deleted users", n)
+		}
+		return err
+	})
+	r.MustSchedule("user-purge", "0 * * * *", JobPurge)
 }
@@ -0,0 +1,24 @@
+package vital
+
+import (
+	"ps_backend/model"
+	"ps_backend/pkg/config"
+)
+
+// PanicThreshold holds the vital sign levels that together indicate a panic attack.
+type PanicThreshold struct {
+	HeartRate   int // beats per minute
+	StressLevel int // 0-100 scale
+}
+
+// DefaultPanicThreshold returns the threshold configured with PANIC_HEART_RATE
+// and PANIC_STRESS_LEVEL (defaults: 130 bpm and 85).
+func DefaultPanicThreshold() PanicThreshold {
+	c := config.Get().Panic
+	return PanicThreshold{HeartRate: c.HeartRate, StressLevel: c.StressLevel}
+}
+
+// Exceeded reports whether the measurement reaches both the heart rate and the stress level.
+func (t PanicThreshold) Exceeded(entry *model.VitalSign) bool {
+	return entry.HeartRate >= t.HeartRate && entry.StressLevel >= t.StressLevel
+}
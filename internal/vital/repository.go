@@ -1,6 +1,9 @@
 package vital
 
 import (
+	"errors"
+	"time"
+
 	"ps_backend/model"
 
 	"gorm.io/gorm"
@@ -30,6 +33,33 @@ func (r *Repository) GetVitalsByUser(userID uint) ([]model.VitalSign, error) {
 	return vitals, nil
 }
 
+// GetVitalsBetween retrieves the VitalSign records of a user measured within [from, to], oldest first.
+func (r *Repository) GetVitalsBetween(userID uint, from, to time.Time) ([]model.VitalSign, error) {
+	var vitals []model.VitalSign
+	if err := r.db.Where("user_id = ? AND measured_at BETWEEN ? AND ?", userID, from, to).
+		Order("measured_at").
+		Find(&vitals).Error; err != nil {
+		return nil, err
+	}
+	return vitals, nil
+}
+
+// GetLatestVital returns the most recent VitalSign of a user measured at or after since.
+// It returns nil without error when no such record exists.
+func (r *Repository) GetLatestVital(userID uint, since time.Time) (*model.VitalSign, error) {
+	var vital model.VitalSign
+	err := r.db.Where("user_id = ? AND measured_at >= ?", userID, since).
+		Order("measured_at desc").
+		First(&vital).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &vital, nil
+}
+
 // DeleteVital deletes a VitalSign record by its ID.
 func (r *Repository) DeleteVital(id uint) error {
 	return r.db.Delete(&model.VitalSign{}, id).Error
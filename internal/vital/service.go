@@ -10,13 +10,15 @@ import (
 
 // Service provides methods to manage vital signs.
 type Service struct {
-	repo *Repository
+	repo      *Repository
+	threshold PanicThreshold
 }
 
 // NewService creates a new Service with the given gorm DB connection.
 func NewService(db *gorm.DB) *Service {
 	return &Service{
-		repo: NewRepository(db),
+		repo:      NewRepository(db),
+		threshold: DefaultPanicThreshold(),
 	}
 }
 
@@ -34,6 +36,11 @@ func (s *Service) CreateVital(entry *model.VitalSign) error {
 	return s.repo.CreateVital(entry)
 }
 
+// DetectPanic reports whether a measurement indicates a panic attack.
+func (s *Service) DetectPanic(entry *model.VitalSign) bool {
+	return entry != nil && s.threshold.Exceeded(entry)
+}
+
 // GetVitalsByUser retrieves all vital sign entries for a given user.
 func (s *Service) GetVitalsByUser(userID uint) ([]model.VitalSign, error) {
 	if userID == 0 {
@@ -42,6 +49,22 @@ func (s *Service) GetVitalsByUser(userID uint) ([]model.VitalSign, error) {
 	return s.repo.GetVitalsByUser(userID)
 }
 
+// GetVitalsBetween retrieves the vital sign entries of a user measured within [from, to].
+func (s *Service) GetVitalsBetween(userID uint, from, to time.Time) ([]model.VitalSign, error) {
+	if userID == 0 {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.repo.GetVitalsBetween(userID, from, to)
+}
+
+// GetLatestVital returns the latest vital sign of a user measured since the given time, or nil.
+func (s *Service) GetLatestVital(userID uint, since time.Time) (*model.VitalSign, error) {
+	if userID == 0 {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.repo.GetLatestVital(userID, since)
+}
+
 // DeleteVital deletes a vital sign entry by its ID.
 func (s *Service) DeleteVital(id uint) error {
 	if id == 0 {
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Breathing session statuses.
+const (
+	SessionStatusRunning   = "running"
+	SessionStatusCompleted = "completed"
+	SessionStatusAborted   = "aborted"
+	// SessionStatusInterrupted marks a session whose pacing stopped without an
+	// outcome, e.g. because the server restarted while it was running.
+	SessionStatusInterrupted = "interrupted"
+)
+
+// BreathingSession represents a server-paced breathing exercise started from a panic guide.
+type BreathingSession struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"not null;index" json:"user_id"`
+	PanicGuideID    uint       `gorm:"not null;index" json:"panic_guide_id"`
+	Pattern         string     `gorm:"size:32;not null" json:"pattern"` // box, 4-7-8, custom
+	Phases          string     `gorm:"size:255;not null" json:"phases"` // e.g. "inhale:4,hold:7,exhale:8"
+	Cycles          int        `gorm:"not null" json:"cycles"`          // planned number of cycles
+	CyclesCompleted int        `gorm:"not null;default:0" json:"cycles_completed"`
+	Status          string     `gorm:"size:16;not null;index" json:"status"`
+	HeartRateBefore *int       `json:"heart_rate_before"`
+	HeartRateAfter  *int       `json:"heart_rate_after"`
+	StartedAt       time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// Data scopes a user can share with a clinician.
+const (
+	ShareScopeVitals         = "vitals"
+	ShareScopeEpisodes       = "episodes"
+	ShareScopeChatSummaries  = "chat_summaries"
+	ShareScopeQuestionnaires = "questionnaires"
+)
+
+// ShareScopes lists every shareable scope.
+var ShareScopes = []string{ShareScopeVitals, ShareScopeEpisodes, ShareScopeChatSummaries, ShareScopeQuestionnaires}
+
+// Care share statuses.
+const (
+	ShareStatusPending = "pending" // invitation issued, not accepted yet
+	ShareStatusActive  = "active"
+	ShareStatusRevoked = "revoked"
+)
+
+// CareShare grants a clinician read-only access to some of a user's data.
+// It starts as an invitation code that a clinician account accepts.
+type CareShare struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"not null;index" json:"user_id"`
+	ClinicianID     *uint      `gorm:"index" json:"clinician_id"`
+	InviteCode      string     `gorm:"size:16;not null;uniqueIndex" json:"invite_code"`
+	Scopes          []string   `gorm:"serializer:json;type:text;not null" json:"scopes"`
+	Status          string     `gorm:"size:16;not null;index" json:"status"`
+	InviteExpiresAt time.Time  `gorm:"not null" json:"invite_expires_at"`
+	AcceptedAt      *time.Time `json:"accepted_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// HasScope reports whether the share grants the scope.
+func (s *CareShare) HasScope(scope string) bool {
+	for _, sc := range s.Scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CareShareAccessLog records every read of shared data by a clinician.
+type CareShareAccessLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CareShareID uint      `gorm:"not null;index" json:"care_share_id"`
+	ClinicianID uint      `gorm:"not null;index" json:"clinician_id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	Scope       string    `gorm:"size:32;not null" json:"scope"`
+	Path        string    `gorm:"size:255" json:"path"`
+	ClientIP    string    `gorm:"size:64" json:"client_ip"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ChatbotPrompt is a system prompt template prepended to chatbot conversations.
+// Only one prompt is active at a time.
+type ChatbotPrompt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:64;not null;unique" json:"name"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	Active    bool      `gorm:"not null;default:false;index" json:"active"`
+	UpdatedBy uint      `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
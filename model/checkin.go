@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// CheckInSchedule is one daily time, in the user's time zone, at which the user
+// is prompted for a check-in.
+type CheckInSchedule struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;uniqueIndex:idx_checkin_schedule_user_time" json:"user_id"`
+	Time         string     `gorm:"size:5;not null;uniqueIndex:idx_checkin_schedule_user_time" json:"time"` // HH:MM
+	Enabled      bool       `gorm:"not null;default:true" json:"enabled"`
+	LastPromptAt *time.Time `json:"last_prompt_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CheckIn is a self-reported mood and anxiety entry. Anxiety uses the two GAD-2
+// items (nervousness and uncontrollable worry), each scored 0-3.
+type CheckIn struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index:idx_checkin_user_responded" json:"user_id"`
+	ScheduleID   *uint      `gorm:"index" json:"schedule_id"` // nil for check-ins not answering a prompt
+	Mood         int        `gorm:"not null" json:"mood"`     // 1 (very bad) - 5 (very good)
+	Nervousness  int        `gorm:"not null" json:"nervousness"`
+	Worry        int        `gorm:"not null" json:"worry"`
+	AnxietyScore int        `gorm:"not null" json:"anxiety_score"` // Nervousness + Worry, 0-6; 3 or more suggests an anxiety disorder
+	SleepQuality *int       `json:"sleep_quality"`                 // last night's sleep, 1 (very poor) - 5 (very good); optional
+	Note         string     `gorm:"type:text" json:"note"`
+	PromptedAt   *time.Time `json:"prompted_at"`
+	RespondedAt  time.Time  `gorm:"not null;index:idx_checkin_user_responded" json:"responded_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Push providers.
+const (
+	PushProviderFCM  = "fcm"
+	PushProviderAPNs = "apns"
+)
+
+// Device types.
+const (
+	DeviceTypePhone  = "phone"
+	DeviceTypeTablet = "tablet"
+	DeviceTypeWatch  = "watch"
+	DeviceTypeWeb    = "web"
+	DeviceTypeOther  = "other"
+)
+
+// Device is an app installation or wearable of a user. Refresh tokens and vital
+// samples are bound to the device they came from.
+type Device struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;uniqueIndex:idx_device_user_installation" json:"user_id"`
+	InstallationID string     `gorm:"size:64;not null;uniqueIndex:idx_device_user_installation" json:"installation_id"` // client-generated, stable per installation
+	Type           string     `gorm:"size:16;not null" json:"type"`
+	Name           string     `gorm:"size:64" json:"name"`
+	Model          string     `gorm:"size:64" json:"model"`
+	OS             string     `gorm:"size:64" json:"os"`
+	PushToken      string     `gorm:"size:512" json:"-"`
+	PushProvider   string     `gorm:"size:16" json:"push_provider"` // fcm or apns
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	LastIP         string     `gorm:"size:64" json:"last_ip"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
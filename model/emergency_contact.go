@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// EmergencyContact is a person notified by SMS when the user is in crisis.
+// Alerts are only sent to contacts whose consent has been confirmed.
+type EmergencyContact struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	UserID             uint       `gorm:"not null;index" json:"user_id"`
+	Name               string     `gorm:"size:100;not null" json:"name"`
+	PhoneNumber        string     `gorm:"size:32;not null" json:"phone_number"`
+	Relationship       string     `gorm:"size:50" json:"relationship"` // 예: 가족, 친구, 보호자
+	ConsentConfirmed   bool       `gorm:"not null;default:false" json:"consent_confirmed"`
+	ConsentConfirmedAt *time.Time `json:"consent_confirmed_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// SOS alert triggers.
+const (
+	SOSTriggerManual         = "manual"
+	SOSTriggerPanicDetection = "panic_detection"
+)
+
+// SOS alert statuses.
+const (
+	SOSStatusSending     = "sending"      // messages are being sent
+	SOSStatusSent        = "sent"         // at least one contact was notified
+	SOSStatusFailed      = "failed"       // no contact could be notified
+	SOSStatusNoContacts  = "no_contacts"  // the user has no consented contact
+	SOSStatusRateLimited = "rate_limited" // suppressed by the rate limit
+)
+
+// SOSAlert is the audit record of an SOS request, whether or not messages were sent.
+type SOSAlert struct {
+	ID         uint               `gorm:"primaryKey" json:"id"`
+	UserID     uint               `gorm:"not null;index" json:"user_id"`
+	Trigger    string             `gorm:"size:32;not null" json:"trigger"`
+	Status     string             `gorm:"size:16;not null;index" json:"status"`
+	Latitude   *float64           `json:"latitude"`
+	Longitude  *float64           `json:"longitude"`
+	Message    string             `gorm:"type:text" json:"message"`
+	Deliveries []SOSAlertDelivery `gorm:"constraint:OnDelete:CASCADE" json:"deliveries,omitempty"`
+	CreatedAt  time.Time          `gorm:"index" json:"created_at"`
+}
+
+// SOSAlertDelivery records the SMS sent to one contact for an SOS alert.
+// The phone number is copied so the trail survives contact edits.
+type SOSAlertDelivery struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	SOSAlertID         uint      `gorm:"not null;index" json:"sos_alert_id"`
+	EmergencyContactID uint      `gorm:"not null;index" json:"emergency_contact_id"`
+	PhoneNumber        string    `gorm:"size:32;not null" json:"phone_number"`
+	Success            bool      `gorm:"not null" json:"success"`
+	Error              string    `gorm:"size:255" json:"error,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+}
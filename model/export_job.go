@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Export formats.
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatFHIR = "fhir"
+	ExportFormatPDF  = "pdf"
+
+	// ExportFormatArchive bundles all personal data of the user (data portability request).
+	ExportFormatArchive = "archive"
+)
+
+// Export job statuses.
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// ExportJob is an asynchronously generated health report of a user for a date range.
+type ExportJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Format      string     `gorm:"size:16;not null" json:"format"`
+	From        time.Time  `gorm:"not null" json:"from"`
+	To          time.Time  `gorm:"not null" json:"to"`
+	Status      string     `gorm:"size:16;not null;index" json:"status"`
+	FilePath    string     `gorm:"size:255" json:"-"`
+	FileName    string     `gorm:"size:128" json:"file_name,omitempty"`
+	ContentType string     `gorm:"size:64" json:"content_type,omitempty"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Error       string     `gorm:"size:255" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"` // the file is deleted afterwards
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
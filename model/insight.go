@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Insight kinds.
+const (
+	InsightTimePattern     = "time_pattern"      // most common weekday/weekend and time-of-day combination
+	InsightLocation        = "location"          // most common place
+	InsightTrigger         = "trigger"           // most common self-reported trigger
+	InsightRisingHeartRate = "rising_heart_rate" // heart rate climbing before episodes
+	InsightPoorSleep       = "poor_sleep"        // episodes on days after poor sleep
+)
+
+// Insight is one pattern found in a user's panic episodes.
+type Insight struct {
+	Kind     string            `json:"kind"`
+	Key      string            `json:"key"`      // e.g. "weekday_morning", "commute", "caffeine"
+	Share    float64           `json:"share"`    // Episodes / Total
+	Episodes int               `json:"episodes"` // episodes matching the pattern
+	Total    int               `json:"total"`    // episodes the share is computed over
+	Messages map[string]string `json:"messages"` // by locale
+}
+
+// EpisodeStats counts a user's panic episodes by context. Weekdays and parts of
+// the day are in the user's time zone.
+type EpisodeStats struct {
+	Episodes        int            `json:"episodes"`
+	ByPartOfDay     map[string]int `json:"by_part_of_day"` // night 0-6h, morning 6-12h, afternoon 12-18h, evening 18-24h
+	ByWeekday       map[string]int `json:"by_weekday"`     // mon ... sun
+	ByLocation      map[string]int `json:"by_location"`
+	ByTrigger       map[string]int `json:"by_trigger"`
+	WithVitals      int            `json:"with_vitals"`       // episodes with vitals in the 30 minutes before
+	RisingHeartRate int            `json:"rising_heart_rate"` // of those, episodes preceded by a heart rate rise
+	WithSleep       int            `json:"with_sleep"`        // episodes on days with a sleep rating
+	AfterPoorSleep  int            `json:"after_poor_sleep"`  // of those, episodes after poor sleep
+}
+
+// UserInsight caches the insights of a user, recomputed by the nightly batch.
+type UserInsight struct {
+	UserID     uint         `gorm:"primaryKey" json:"user_id"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Stats      EpisodeStats `gorm:"serializer:json;type:text" json:"stats"`
+	Insights   []Insight    `gorm:"serializer:json;type:text" json:"insights"`
+	ComputedAt time.Time    `gorm:"index" json:"computed_at"`
+}
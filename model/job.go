@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Background job statuses.
+const (
+	JobStatusQueued    = "queued"  // waiting for RunAt, including retries
+	JobStatusRunning   = "running" // claimed by a worker
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead" // out of attempts or failed permanently; kept until retried or removed
+)
+
+// Job is a unit of background work in the Postgres-backed queue.
+type Job struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Type       string     `gorm:"size:64;not null;index:idx_jobs_claim,priority:2" json:"type"`
+	Status     string     `gorm:"size:16;not null;index:idx_jobs_claim,priority:1" json:"status"`
+	Payload    string     `gorm:"type:text;not null" json:"payload"` // JSON
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	RunAt      time.Time  `gorm:"not null;index:idx_jobs_claim,priority:3" json:"run_at"`
+	LockedBy   string     `gorm:"size:64" json:"locked_by"`
+	LockedAt   *time.Time `json:"locked_at"`
+	LastError  string     `gorm:"type:text" json:"last_error"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// JobSchedule is a recurring job. NextRunAt is shared by every server so each
+// occurrence is enqueued once.
+type JobSchedule struct {
+	Name      string     `gorm:"primaryKey;size:64" json:"name"`
+	Spec      string     `gorm:"size:64;not null" json:"spec"` // cron expression
+	JobType   string     `gorm:"size:64;not null" json:"job_type"`
+	NextRunAt time.Time  `gorm:"not null" json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// LLMUsage records token usage and latency of a single chatbot LLM call.
+type LLMUsage struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	UserID           uint      `gorm:"not null;index" json:"user_id"`
+	Model            string    `gorm:"size:64;not null" json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	LatencyMs        int64     `json:"latency_ms"`
+	Success          bool      `json:"success"`
+	CreatedAt        time.Time `gorm:"index" json:"created_at"`
+}
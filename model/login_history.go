@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Login attempt results.
+const (
+	LoginResultSuccess     = "success"
+	LoginResultBadPassword = "bad_password"
+	LoginResultLocked      = "locked"
+)
+
+// LoginHistory records a sign-in attempt on an existing account.
+type LoginHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_login_history_user_created" json:"user_id"`
+	Result    string    `gorm:"size:16;not null" json:"result"`
+	IP        string    `gorm:"size:64" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	CreatedAt time.Time `gorm:"index:idx_login_history_user_created" json:"created_at"`
+}
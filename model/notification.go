@@ -0,0 +1,112 @@
+package model
+
+import "time"
+
+// Notification categories.
+const (
+	NotificationPanicDetected = "panic_detected"
+	NotificationCheckInDue    = "checkin_due"
+	NotificationCareShare     = "care_share"
+	NotificationSystem        = "system"
+)
+
+// NotificationCategories lists every category a user can turn on or off.
+var NotificationCategories = []string{
+	NotificationPanicDetected,
+	NotificationCheckInDue,
+	NotificationCareShare,
+	NotificationSystem,
+}
+
+// CriticalNotification reports whether the category is delivered during quiet hours.
+func CriticalNotification(category string) bool {
+	return category == NotificationPanicDetected
+}
+
+// Notification statuses.
+const (
+	NotificationStatusPending    = "pending"    // push delivery in progress
+	NotificationStatusSent       = "sent"       // delivered to at least one device
+	NotificationStatusFailed     = "failed"     // no device could be reached
+	NotificationStatusNoDevices  = "no_devices" // the user has no device with a push token
+	NotificationStatusSuppressed = "suppressed" // category turned off or quiet hours; inbox only
+)
+
+// Notification is a message to a user. Every notification is kept in the
+// user's inbox, whether or not it was pushed.
+type Notification struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	UserID     uint                   `gorm:"not null;index:idx_notification_user_created" json:"user_id"`
+	Category   string                 `gorm:"size:32;not null" json:"category"`
+	Title      string                 `gorm:"size:200;not null" json:"title"`
+	Body       string                 `gorm:"type:text" json:"body"`
+	Data       map[string]string      `gorm:"serializer:json" json:"data,omitempty"` // e.g. a deep link for the app
+	Status     string                 `gorm:"size:16;not null;index" json:"status"`
+	ReadAt     *time.Time             `json:"read_at"`
+	SentAt     *time.Time             `json:"sent_at"`
+	Deliveries []NotificationDelivery `gorm:"constraint:OnDelete:CASCADE" json:"deliveries,omitempty"`
+	CreatedAt  time.Time              `gorm:"index:idx_notification_user_created" json:"created_at"`
+}
+
+// NotificationDelivery records the push of a notification to one device.
+type NotificationDelivery struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	NotificationID uint      `gorm:"not null;index" json:"notification_id"`
+	DeviceID       uint      `gorm:"not null;index" json:"device_id"`
+	Provider       string    `gorm:"size:16;not null" json:"provider"`
+	Success        bool      `gorm:"not null" json:"success"`
+	Attempts       int       `gorm:"not null" json:"attempts"`
+	Error          string    `gorm:"size:255" json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NotificationPreference holds which categories a user receives as push
+// notifications and the quiet hours in the user's time zone.
+type NotificationPreference struct {
+	UserID             uint      `gorm:"primaryKey" json:"user_id"`
+	DisabledCategories []string  `gorm:"serializer:json" json:"disabled_categories"`
+	QuietHoursEnabled  bool      `gorm:"not null;default:false" json:"quiet_hours_enabled"`
+	QuietHoursStart    string    `gorm:"size:5;not null;default:22:00" json:"quiet_hours_start"` // HH:MM
+	QuietHoursEnd      string    `gorm:"size:5;not null;default:07:00" json:"quiet_hours_end"`   // HH:MM
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreference returns the preferences of users who never changed them.
+func DefaultNotificationPreference(userID uint) NotificationPreference {
+	return NotificationPreference{
+		UserID:             userID,
+		DisabledCategories: []string{},
+		QuietHoursStart:    "22:00",
+		QuietHoursEnd:      "07:00",
+	}
+}
+
+// CategoryEnabled reports whether the category is pushed.
+func (p NotificationPreference) CategoryEnabled(category string) bool {
+	for _, c := range p.DisabledCategories {
+		if c == category {
+			return false
+		}
+	}
+	return true
+}
+
+// InQuietHours reports whether the local time falls into the quiet hours.
+// A window whose end is before its start spans midnight.
+func (p NotificationPreference) InQuietHours(local time.Time) bool {
+	if !p.QuietHoursEnabled {
+		return false
+	}
+	start, err1 := time.Parse("15:04", p.QuietHoursStart)
+	end, err2 := time.Parse("15:04", p.QuietHoursEnd)
+	if err1 != nil || err2 != nil || start.Equal(end) {
+		return false
+	}
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// OTPCode is a one-time code sent by SMS. A user has at most one code per
+// purpose; a new code replaces the earlier one. Only the SHA-256 hash of the
+// code is stored, together with the number it was sent to.
+type OTPCode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_otp_codes_user_purpose" json:"user_id"`
+	Purpose   string    `gorm:"size:32;not null;uniqueIndex:idx_otp_codes_user_purpose" json:"purpose"`
+	CodeHash  string    `gorm:"size:64;not null" json:"-"`
+	Phone     string    `gorm:"size:32;not null" json:"-"`
+	Attempts  int       `gorm:"not null;default:0" json:"attempts"` // wrong guesses so far
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Panic episode sources.
+const (
+	EpisodeSourceDetected     = "detected"      // recorded from vital signs crossing the panic threshold
+	EpisodeSourceSelfReported = "self_reported" // logged by the user
+)
+
+// EpisodeLocations lists the place labels a user can attach to an episode.
+var EpisodeLocations = []string{"home", "work", "school", "commute", "public_place", "outdoors", "other"}
+
+// EpisodeTriggers lists the self-reported triggers a user can attach to an episode.
+var EpisodeTriggers = []string{
+	"caffeine", "alcohol", "lack_of_sleep", "work_stress", "conflict",
+	"crowds", "driving", "health_worry", "exercise", "other",
+}
+
+// PanicEpisode is a panic attack of a user, either detected from vital signs or self-reported.
+type PanicEpisode struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	Source        string     `gorm:"size:16;not null" json:"source"`
+	StartedAt     time.Time  `gorm:"not null;index" json:"started_at"`
+	EndedAt       *time.Time `json:"ended_at"`
+	Intensity     *int       `json:"intensity"` // 1-10, self-rated
+	PeakHeartRate *int       `json:"peak_heart_rate"`
+	Notes         string     `gorm:"type:text" json:"notes"`
+	Location      string     `gorm:"size:32" json:"location"`                   // one of EpisodeLocations, empty if unknown
+	Triggers      []string   `gorm:"serializer:json;type:text" json:"triggers"` // subset of EpisodeTriggers
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
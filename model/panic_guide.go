@@ -2,19 +2,83 @@ package model
 
 import "time"
 
+// Panic guide step types.
+const (
+	StepTypeBreathing          = "breathing"
+	StepTypeGrounding          = "grounding_54321"
+	StepTypeMuscleRelaxation   = "muscle_relaxation"
+	StepTypeCognitiveReframing = "cognitive_reframing"
+	StepTypeGeneral            = "general"
+)
+
+// Panic guide difficulty levels.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
 // PanicGuide represents a method to cope with panic attacks.
 type PanicGuide struct {
-	ID          uint   `gorm:"primaryKey"`
-	Title       string `gorm:"size:128;not null"`
-	Description string `gorm:"type:text;not null"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID               uint             `gorm:"primaryKey"`
+	Title            string           `gorm:"size:128;not null"`
+	Description      string           `gorm:"type:text;not null"`
+	Locale           string           `gorm:"size:16;not null;default:ko"` // language of Title, Description and Steps
+	Difficulty       string           `gorm:"size:16;not null;default:easy"`
+	EstimatedSeconds int              `gorm:"not null;default:0"` // sum of step durations
+	Steps            []PanicGuideStep `gorm:"foreignKey:PanicGuideID"`
+	Interests        []Interest       `gorm:"many2many:panic_guide_interests"` // used for recommendations
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// PanicGuideStep represents a single ordered step of a panic guide.
+type PanicGuideStep struct {
+	ID              uint   `gorm:"primaryKey"`
+	PanicGuideID    uint   `gorm:"not null;index"`
+	Position        int    `gorm:"not null"`
+	Type            string `gorm:"size:32;not null"`
+	Instruction     string `gorm:"type:text;not null"`
+	DurationSeconds int    `gorm:"not null;default:0"`
+	AudioURL        string `gorm:"size:512"`
+	ImageURL        string `gorm:"size:512"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// PanicGuideTranslation holds a guide's title and description in another locale.
+type PanicGuideTranslation struct {
+	ID           uint   `gorm:"primaryKey"`
+	PanicGuideID uint   `gorm:"not null;uniqueIndex:idx_guide_translation"`
+	Locale       string `gorm:"size:16;not null;uniqueIndex:idx_guide_translation"`
+	Title        string `gorm:"size:128;not null"`
+	Description  string `gorm:"type:text;not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// PanicGuideStepTranslation holds a step's instruction in another locale.
+// Steps are matched by position so translations survive step replacement.
+type PanicGuideStepTranslation struct {
+	ID           uint   `gorm:"primaryKey"`
+	PanicGuideID uint   `gorm:"not null;uniqueIndex:idx_step_translation"`
+	Position     int    `gorm:"not null;uniqueIndex:idx_step_translation"`
+	Locale       string `gorm:"size:16;not null;uniqueIndex:idx_step_translation"`
+	Instruction  string `gorm:"type:text;not null"`
+	AudioURL     string `gorm:"size:512"` // localized narration, falls back to the step's audio
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 // UserPanicGuide represents a user's bookmarked panic guide.
+// A guide can be bookmarked only once per user; bookmarks are grouped into
+// folders and ordered by Position, with pinned bookmarks listed first.
 type UserPanicGuide struct {
 	ID           uint      `gorm:"primaryKey"`
-	UserID       uint      `gorm:"not null;index"`
-	PanicGuideID uint      `gorm:"not null;index"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_user_panic_guide"`
+	PanicGuideID uint      `gorm:"not null;uniqueIndex:idx_user_panic_guide;index"`
+	Folder       string    `gorm:"size:64;not null;default:''"`
+	Position     int       `gorm:"not null;default:0"`
+	Pinned       bool      `gorm:"not null;default:false"`
 	BookmarkedAt time.Time `gorm:"autoCreateTime"`
 }
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// PasswordResetToken is issued after a password reset OTP has been verified and
+// allows setting a new password once. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Persona option values.
+const (
+	FormalityCasual = "casual" // 반말
+	FormalityPolite = "polite" // 해요체
+	FormalityFormal = "formal" // 합니다체
+
+	WarmthReserved = "reserved"
+	WarmthBalanced = "balanced"
+	WarmthWarm     = "warm"
+
+	HumorNone    = "none"
+	HumorLight   = "light"
+	HumorPlayful = "playful"
+
+	VerbosityBrief    = "brief"
+	VerbosityBalanced = "balanced"
+	VerbosityDetailed = "detailed"
+)
+
+// Persona configures how the chatbot talks to the user.
+type Persona struct {
+	Formality string `gorm:"size:16;not null;default:polite" json:"formality"`
+	Warmth    string `gorm:"size:16;not null;default:balanced" json:"warmth"`
+	Humor     string `gorm:"size:16;not null;default:light" json:"humor"`
+	Verbosity string `gorm:"size:16;not null;default:balanced" json:"verbosity"`
+	Language  string `gorm:"size:16" json:"language"` // 비어 있으면 사용자가 쓴 언어로 답변
+	Nickname  string `gorm:"size:32" json:"nickname"` // 챗봇이 사용자를 부르는 이름
+}
+
+// DefaultPersona returns the persona of users who did not configure one.
+func DefaultPersona() Persona {
+	return Persona{
+		Formality: FormalityPolite,
+		Warmth:    WarmthBalanced,
+		Humor:     HumorLight,
+		Verbosity: VerbosityBalanced,
+	}
+}
+
+// PersonaOption is a selectable persona value with its display labels by locale.
+type PersonaOption struct {
+	Value  string            `json:"value"`
+	Labels map[string]string `json:"labels"`
+	prompt string
+}
+
+// PersonaCatalog lists the options of every persona setting.
+type PersonaCatalog struct {
+	Formality []PersonaOption `json:"formality"`
+	Warmth    []PersonaOption `json:"warmth"`
+	Humor     []PersonaOption `json:"humor"`
+	Verbosity []PersonaOption `json:"verbosity"`
+}
+
+func option(value, ko, en, prompt string) PersonaOption {
+	return PersonaOption{Value: value, Labels: map[string]string{"ko": ko, "en": en}, prompt: prompt}
+}
+
+// Personas holds the available persona options.
+var Personas = PersonaCatalog{
+	Formality: []PersonaOption{
+		option(FormalityCasual, "반말", "Casual", "친구처럼 반말로 말하세요."),
+		option(FormalityPolite, "존댓말", "Polite", "부드러운 존댓말(해요체)로 말하세요."),
+		option(FormalityFormal, "격식체", "Formal", "격식 있는 존댓말(합니다체)로 말하세요."),
+	},
+	Warmth: []PersonaOption{
+		option(WarmthReserved, "차분함", "Reserved", "감정 표현을 절제하고 차분하게 말하세요."),
+		option(WarmthBalanced, "보통", "Balanced", "공감하되 담백하게 말하세요."),
+		option(WarmthWarm, "다정함", "Warm", "따뜻하고 다정하게 공감하며 말하세요."),
+	},
+	Humor: []PersonaOption{
+		option(HumorNone, "진지함", "Serious", "농담은 하지 마세요."),
+		option(HumorLight, "가벼운 유머", "Light", "분위기에 맞으면 가벼운 유머를 써도 됩니다."),
+		option(HumorPlayful, "유머러스함", "Playful", "유머를 적극적으로 섞어 말하세요."),
+	},
+	Verbosity: []PersonaOption{
+		option(VerbosityBrief, "짧게", "Brief", "한두 문장으로 짧게 답하세요."),
+		option(VerbosityBalanced, "보통", "Balanced", "적당한 길이로 답하세요."),
+		option(VerbosityDetailed, "자세히", "Detailed", "충분히 자세하게 설명하세요."),
+	},
+}
+
+// LanguageLabels names the languages a persona can prefer.
+var LanguageLabels = map[string]map[string]string{
+	"ko": {"ko": "한국어", "en": "Korean"},
+	"en": {"ko": "영어", "en": "English"},
+}
+
+func findOption(options []PersonaOption, value string) (PersonaOption, bool) {
+	for _, o := range options {
+		if o.Value == value {
+			return o, true
+		}
+	}
+	return PersonaOption{}, false
+}
+
+// Validate checks every setting against the catalog and the given supported languages.
+func (p Persona) Validate(languages []string) error {
+	checks := []struct {
+		name    string
+		options []PersonaOption
+		value   string
+	}{
+		{"formality", Personas.Formality, p.Formality},
+		{"warmth", Personas.Warmth, p.Warmth},
+		{"humor", Personas.Humor, p.Humor},
+		{"verbosity", Personas.Verbosity, p.Verbosity},
+	}
+	for _, c := range checks {
+		if _, ok := findOption(c.options, c.value); !ok {
+			return fmt.Errorf("invalid persona %s: %q", c.name, c.value)
+		}
+	}
+	if p.Language != "" {
+		supported := false
+		for _, l := range languages {
+			supported = supported || l == p.Language
+		}
+		if !supported {
+			return fmt.Errorf("unsupported persona language: %q", p.Language)
+		}
+	}
+	if len([]rune(p.Nickname)) > 32 {
+		return errors.New("persona nickname is too long")
+	}
+	return nil
+}
+
+// Instructions renders the persona as instructions for the chatbot prompt.
+func (p Persona) Instructions() string {
+	var b strings.Builder
+	for _, pick := range []struct {
+		options []PersonaOption
+		value   string
+	}{
+		{Personas.Formality, p.Formality},
+		{Personas.Warmth, p.Warmth},
+		{Personas.Humor, p.Humor},
+		{Personas.Verbosity, p.Verbosity},
+	} {
+		if o, ok := findOption(pick.options, pick.value); ok {
+			b.WriteString(o.prompt)
+			b.WriteString("\n")
+		}
+	}
+	if p.Nickname != "" {
+		fmt.Fprintf(&b, "사용자를 '%s'(이)라고 부르세요.\n", p.Nickname)
+	}
+	if labels, ok := LanguageLabels[p.Language]; ok {
+		fmt.Fprintf(&b, "항상 %s로 답하세요.\n", labels["ko"])
+	}
+	return b.String()
+}
+
+// PersonaFromLegacy maps the former free-text speaking style and tone
+// (e.g. "반말", "유머") onto a persona.
+func PersonaFromLegacy(speakingStyle, tone string) Persona {
+	p := DefaultPersona()
+	style := strings.ToLower(speakingStyle)
+	switch {
+	case containsAny(style, "반말", "casual"):
+		p.Formality = FormalityCasual
+	case containsAny(style, "격식", "합니다", "formal"):
+		p.Formality = FormalityFormal
+	}
+	t := strings.ToLower(tone)
+	switch {
+	case containsAny(t, "유머", "장난", "humor", "funny", "playful"):
+		p.Humor = HumorPlayful
+	case containsAny(t, "진지", "딱딱", "serious"):
+		p.Humor = HumorNone
+		p.Warmth = WarmthReserved
+	}
+	if containsAny(t, "다정", "친근", "따뜻", "warm", "friendly") {
+		p.Warmth = WarmthWarm
+	}
+	return p
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
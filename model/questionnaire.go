@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// QuestionnaireResponse is a completed clinical questionnaire and its score,
+// computed with the instrument version the user answered.
+type QuestionnaireResponse struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index:idx_questionnaire_user_instrument" json:"user_id"`
+	Instrument string    `gorm:"size:16;not null;index:idx_questionnaire_user_instrument" json:"instrument"` // e.g. phq9, gad7, pdss
+	Version    int       `gorm:"not null" json:"version"`
+	Answers    []int     `gorm:"serializer:json;type:text;not null" json:"answers"` // one value per item, in item order
+	TotalScore int       `gorm:"not null" json:"total_score"`
+	Severity   string    `gorm:"size:32;not null" json:"severity"`
+	SafetyFlag bool      `gorm:"not null;default:false" json:"safety_flag"` // a safety item, e.g. PHQ-9 item 9, was answered positively
+	CreatedAt  time.Time `gorm:"index:idx_questionnaire_user_instrument" json:"created_at"`
+}
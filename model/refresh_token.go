@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// RefreshToken tracks an issued refresh token by its JWT ID so it can be revoked.
+// Refresh tokens without a matching, unrevoked row are rejected.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	DeviceID  *uint      `gorm:"index" json:"device_id"`
+	TokenID   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package model
+
+// User roles.
+const (
+	RoleUser          = "user"
+	RoleClinician     = "clinician"
+	RoleContentEditor = "content-editor"
+	RoleAdmin         = "admin"
+)
+
+// Permissions granted to roles.
+const (
+	PermManageGuides    = "guides:manage"
+	PermManageInterests = "interests:manage"
+	PermManagePrompts   = "prompts:manage"
+	PermManageUsers     = "users:manage"
+	PermManageJobs      = "jobs:manage"
+	PermViewSharedData  = "shared-data:view"
+)
+
+// RolePermissions maps each role to the permissions it grants.
+var RolePermissions = map[string][]string{
+	RoleUser:          {},
+	RoleClinician:     {PermViewSharedData},
+	RoleContentEditor: {PermManageGuides, PermManageInterests},
+	RoleAdmin:         {PermManageGuides, PermManageInterests, PermManagePrompts, PermManageUsers, PermManageJobs},
+}
+
+// IsValidRole reports whether the role is known.
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether the role grants the permission.
+func HasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
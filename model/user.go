@@ -3,14 +3,27 @@ package model
 import "time"
 
 type User struct {
-	ID            uint   `gorm:"primaryKey"`
-	Username      string `gorm:"unique;not null"`
-	PasswordHash  string `gorm:"not null"`
-	PhoneNumber   string `gorm:"unique;not null"`
-	Verified      bool   `gorm:"default:false"`
-	SpeakingStyle string // 예: 반말, 존댓말
-	Tone          string // 예: 유머, 딱딱함 등
-	CreatedAt     time.Time
+	ID           uint   `gorm:"primaryKey"`
+	Username     string `gorm:"unique;not null"`
+	PasswordHash string `gorm:"not null"`
+	PhoneNumber  string `gorm:"unique;not null"`
+	Verified     bool   `gorm:"default:false"`
+	DisplayName  string `gorm:"size:64"`
+	Timezone     string `gorm:"size:64;not null;default:Asia/Seoul"` // IANA 시간대
+	Locale       string `gorm:"size:16"`                             // 비어 있으면 DEFAULT_LOCALE
+	Plan         string `gorm:"size:32;not null;default:free"`       // 예: free, premium
+	Role         string `gorm:"size:32;not null;default:user"`       // user, clinician, content-editor, admin
+	CreatedAt    time.Time
+
+	Persona Persona `gorm:"embedded;embeddedPrefix:persona_"` // 챗봇 말투 설정
+
+	FailedLoginCount int        `gorm:"not null;default:0"` // 연속 로그인 실패 횟수, 성공하면 0
+	LockedUntil      *time.Time // 실패가 누적되면 이 시각까지 로그인 거부
+
+	TokensValidAfter *time.Time // 이 시각 이전에 발급된 액세스 토큰은 거부 (비밀번호 변경, 탈퇴 요청)
+
+	DeletionRequestedAt *time.Time
+	DeletionScheduledAt *time.Time `gorm:"index"` // 유예 기간이 끝나면 모든 데이터 삭제
 }
 
 type ChatbotLog struct {
@@ -6,9 +6,10 @@ import "time"
 type VitalSign struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	UserID      uint      `gorm:"not null;index" json:"user_id"`
-	HeartRate   int       `json:"heart_rate"`   // Beats per minute
-	BreathRate  int       `json:"breath_rate"`  // Breaths per minute
-	StressLevel int       `json:"stress_level"` // 0-100 scale
+	DeviceID    *uint     `gorm:"index" json:"device_id"` // source device; nil for samples recorded before devices existed
+	HeartRate   int       `json:"heart_rate"`             // Beats per minute
+	BreathRate  int       `json:"breath_rate"`            // Breaths per minute
+	StressLevel int       `json:"stress_level"`           // 0-100 scale
 	MeasuredAt  time.Time `gorm:"not null" json:"measured_at"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -0,0 +1,430 @@
+// Package config loads the process configuration from defaults, an optional
+// YAML file and the environment, in that order of precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gopkg.in/yaml.v3"
+)
+
+// Environments.
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// minSecretBytes is the shortest signing secret accepted outside development;
+// HS256 keys should be at least as long as the hash.
+const minSecretBytes = 32
+
+// Config is the typed configuration of the server and the CLI commands.
+//
+// Every field has a YAML key and an environment variable. A field marked secret
+// can also be read from a file named by <VARIABLE>_FILE, and is hidden by Redacted.
+type Config struct {
+	Env      string         `yaml:"env" env:"APP_ENV"`
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	SMS      APIConfig      `yaml:"sms" env:"SMS"`
+	Gemini   APIConfig      `yaml:"gemini" env:"GEMINI"`
+	Export   ExportConfig   `yaml:"export"`
+
+	Locale   LocaleConfig   `yaml:"locale"`
+	Password PasswordConfig `yaml:"password" env:"PASSWORD"`
+	Login    LoginConfig    `yaml:"login" env:"LOGIN"`
+	OTP      OTPConfig      `yaml:"otp" env:"OTP"`
+	Account  AccountConfig  `yaml:"account" env:"ACCOUNT"`
+	SOS      SOSConfig      `yaml:"sos" env:"SOS"`
+	Panic    PanicConfig    `yaml:"panic" env:"PANIC"`
+	Share    ShareConfig    `yaml:"share" env:"SHARE"`
+	Insights InsightsConfig `yaml:"insights" env:"INSIGHTS"`
+	LLMQuota LLMQuotaConfig `yaml:"llm_quota" env:"LLM_QUOTA"`
+	Push     PushConfig     `yaml:"push" env:"PUSH"`
+	FCM      FCMConfig      `yaml:"fcm" env:"FCM"`
+	APNs     APNsConfig     `yaml:"apns" env:"APNS"`
+	Jobs     JobsConfig     `yaml:"jobs" env:"JOBS"`
+	Readyz   ReadyzConfig   `yaml:"readyz" env:"READYZ"`
+}
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	Port           int      `yaml:"port" env:"PORT"`
+	CORSOrigins    []string `yaml:"cors_origins" env:"CORS_ORIGINS"`                 // comma-separated in the environment; CORS is off when empty
+	MetricsToken   string   `yaml:"metrics_token" env:"METRICS_TOKEN" secret:"true"` // bearer token required by /metrics; open when empty
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`           // IPs or CIDRs of proxies whose X-Forwarded-For is trusted; none when empty
+}
+
+// DatabaseConfig configures the PostgreSQL connection.
+type DatabaseConfig struct {
+	DSN string `yaml:"dsn" env:"DATABASE_DSN" secret:"dsn"`
+}
+
+// AuthConfig holds the JWT signing secrets.
+type AuthConfig struct {
+	AccessSecret  string `yaml:"access_secret" env:"ACCESS_SECRET" secret:"true"`
+	RefreshSecret string `yaml:"refresh_secret" env:"REFRESH_SECRET" secret:"true"`
+}
+
+// APIConfig is an external HTTP API called with a bearer key. The variables are
+// prefixed with the name of the API, e.g. SMS_API_URL.
+type APIConfig struct {
+	URL string `yaml:"api_url" env:"API_URL"`
+	Key string `yaml:"api_key" env:"API_KEY" secret:"true"`
+}
+
+// ExportConfig configures the export files and their signed download links.
+type ExportConfig struct {
+	SigningSecret string        `yaml:"signing_secret" env:"EXPORT_SIGNING_SECRET" secret:"true"` // required outside development
+	Dir           string        `yaml:"dir" env:"EXPORT_DIR"`
+	LinkTTL       time.Duration `yaml:"link_ttl" env:"EXPORT_LINK_TTL"`
+	Retention     time.Duration `yaml:"retention" env:"EXPORT_RETENTION"` // files are deleted this long after they were generated
+}
+
+// DefaultGeminiURL is the base URL of the Gemini API used when gemini.api_url
+// is not set.
+const DefaultGeminiURL = "https://generativelanguage.googleapis.com"
+
+// GeminiURL returns the base URL of the Gemini API, without a trailing slash.
+// The chatbot sends its requests there and the readiness check probes it.
+func (c *Config) GeminiURL() string {
+	if c == nil || c.Gemini.URL == "" {
+		return DefaultGeminiURL
+	}
+	return strings.TrimRight(c.Gemini.URL, "/")
+}
+
+// Defaults returns the configuration used for keys that are set nowhere.
+func Defaults() *Config {
+	cfg := &Config{
+		Env:    EnvDevelopment,
+		Server: ServerConfig{Port: 8100},
+	}
+	setFeatureDefaults(cfg)
+	return cfg
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Config
+	loadErr  error
+)
+
+// Load reads the configuration once per process: the defaults, then the YAML
+// file named by CONFIG_FILE if set, then the environment. It reports unreadable
+// sources and values of the wrong type; see Validate for the other checks.
+func Load() (*Config, error) {
+	loadOnce.Do(func() {
+		loaded, loadErr = load(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+	})
+	return loaded, loadErr
+}
+
+// Get returns the loaded configuration for packages that need a setting at
+// runtime. Problems are reported by the command calling Load at startup.
+func Get() *Config {
+	cfg, _ := Load()
+	return cfg
+}
+
+func load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Defaults()
+	var errs []error
+	if path != "" {
+		if err := readFile(cfg, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, f := range fields(reflect.ValueOf(cfg).Elem(), "", "") {
+		raw, fromFile, err := lookup(f, lookupEnv)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if raw == nil {
+			continue
+		}
+		if err := set(f.value, *raw); err != nil {
+			source := f.env
+			if fromFile {
+				source = f.env + "_FILE"
+			}
+			errs = append(errs, fmt.Errorf("%s (%s): %v", f.path, source, err))
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+func readFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer file.Close()
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookup returns the environment value of the field, read from <VARIABLE>_FILE
+// for secrets, or nil when the environment does not set it.
+func lookup(f field, lookupEnv func(string) (string, bool)) (*string, bool, error) {
+	value, inEnv := lookupEnv(f.env)
+	if f.secret == "" {
+		if !inEnv {
+			return nil, false, nil
+		}
+		return &value, false, nil
+	}
+	filePath, inFile := lookupEnv(f.env + "_FILE")
+	switch {
+	case inEnv && inFile:
+		return nil, false, fmt.Errorf("%s and %s_FILE are both set", f.env, f.env)
+	case inFile:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, true, fmt.Errorf("%s_FILE: %w", f.env, err)
+		}
+		secret := strings.TrimSpace(string(data))
+		return &secret, true, nil
+	case inEnv:
+		return &value, false, nil
+	}
+	return nil, false, nil
+}
+
+// field is a settable leaf of Config.
+type field struct {
+	path   string // YAML path, e.g. auth.access_secret
+	env    string
+	secret string // "true", "dsn" or empty
+	value  reflect.Value
+}
+
+func fields(v reflect.Value, yamlPrefix, envPrefix string) []field {
+	var out []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		env := sf.Tag.Get("env")
+		if sf.Type.Kind() == reflect.Struct {
+			prefix := envPrefix
+			if env != "" {
+				prefix += env + "_"
+			}
+			out = append(out, fields(v.Field(i), yamlPrefix+name+".", prefix)...)
+			continue
+		}
+		out = append(out, field{
+			path:   yamlPrefix + name,
+			env:    envPrefix + env,
+			secret: sf.Tag.Get("secret"),
+			value:  v.Field(i),
+		})
+	}
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func set(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s, 15m or 72h", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Validate checks the settings every command needs: the environment name, the
+// port, the database DSN, the format of the optional settings and the ranges
+// of the feature settings.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(path, env, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s (%s): %s", path, env, fmt.Sprintf(format, args...)))
+	}
+
+	switch c.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		fail("env", "APP_ENV", "must be %s, %s or %s, got %q", EnvDevelopment, EnvStaging, EnvProduction, c.Env)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("server.port", "PORT", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if origin != "*" && !isHTTPURL(origin) {
+			fail("server.cors_origins", "CORS_ORIGINS", "%q must be * or an http(s) origin such as https://app.example.com", origin)
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if !isIPOrCIDR(proxy) {
+			fail("server.trusted_proxies", "TRUSTED_PROXIES", "%q must be an IP address or CIDR such as 10.0.0.0/8", proxy)
+		}
+	}
+	if c.Database.DSN == "" {
+		fail("database.dsn", "DATABASE_DSN", "must be set")
+	} else if _, err := pgconn.ParseConfig(c.Database.DSN); err != nil {
+		fail("database.dsn", "DATABASE_DSN", "is not a valid PostgreSQL connection string")
+	}
+	for _, api := range []struct {
+		name string
+		cfg  APIConfig
+	}{{"sms", c.SMS}, {"gemini", c.Gemini}} {
+		env := strings.ToUpper(api.name)
+		if api.cfg.URL != "" && !isHTTPURL(api.cfg.URL) {
+			fail(api.name+".api_url", env+"_API_URL", "must be an http(s) URL")
+		}
+		switch {
+		case api.cfg.URL != "" && api.cfg.Key == "":
+			fail(api.name, env+"_API_URL, "+env+"_API_KEY", "must be set together")
+		case api.cfg.URL == "" && api.cfg.Key != "" && api.name != "gemini":
+			// Only the Gemini API has a default URL.
+			fail(api.name, env+"_API_URL, "+env+"_API_KEY", "must be set together")
+		}
+	}
+	c.validateFeatures(fail)
+	return errors.Join(errs...)
+}
+
+// ValidateServer runs Validate and also checks what the API server needs: the
+// JWT secrets and, in staging and production, strong secrets, an export signing
+// secret and configured SMS and chatbot APIs.
+func (c *Config) ValidateServer() error {
+	errs := []error{c.Validate()}
+	fail := func(path, env, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s (%s): %s", path, env, fmt.Sprintf(format, args...)))
+	}
+
+	secrets := []struct{ path, env, value string }{
+		{"auth.access_secret", "ACCESS_SECRET", c.Auth.AccessSecret},
+		{"auth.refresh_secret", "REFRESH_SECRET", c.Auth.RefreshSecret},
+		{"export.signing_secret", "EXPORT_SIGNING_SECRET", c.Export.SigningSecret},
+	}
+	for i, s := range secrets {
+		switch {
+		case s.value == "" && i < 2:
+			fail(s.path, s.env, "must be set")
+		case s.value == "" && c.Env != EnvDevelopment:
+			fail(s.path, s.env, "must be set in %s; export download links are signed with it", c.Env)
+		case s.value != "" && c.Env != EnvDevelopment && len(s.value) < minSecretBytes:
+			fail(s.path, s.env, "must be at least %d bytes in %s", minSecretBytes, c.Env)
+		}
+	}
+	if c.Auth.AccessSecret != "" && c.Auth.AccessSecret == c.Auth.RefreshSecret {
+		fail("auth.refresh_secret", "REFRESH_SECRET", "must differ from the access secret")
+	}
+	if c.Env != EnvDevelopment {
+		if c.SMS.URL == "" {
+			fail("sms.api_url", "SMS_API_URL", "must be set in %s; sign-up and password resets send codes by SMS", c.Env)
+		}
+		if c.Gemini.Key == "" {
+			fail("gemini.api_key", "GEMINI_API_KEY", "must be set in %s; the chatbot calls the Gemini API with it", c.Env)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isIPOrCIDR(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+	return net.ParseIP(s) != nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Redacted returns the configuration as YAML with secrets replaced, for
+// debugging. The password of the database DSN is hidden, the rest is kept.
+func (c *Config) Redacted() string {
+	dump := *c
+	for _, f := range fields(reflect.ValueOf(&dump).Elem(), "", "") {
+		value := f.value.String()
+		switch {
+		case f.secret == "" || value == "":
+		case f.secret == "dsn":
+			f.value.SetString(redactDSN(value))
+		default:
+			f.value.SetString(redactedValue)
+		}
+	}
+	out, err := yaml.Marshal(&dump)
+	if err != nil {
+		return fmt.Sprintf("# cannot render configuration: %v\n", err)
+	}
+	return string(out)
+}
+
+const redactedValue = "<redacted>"
+
+// dsnPassword matches the password of a key=value connection string, quoted or not.
+var dsnPassword = regexp.MustCompile(`(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactDSN hides the password of a URL or key=value connection string.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			return strings.Replace(u.String(), "xxxxx", redactedValue, 1)
+		}
+		return dsn
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redactedValue)
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LocaleConfig lists the locales served by the API.
+type LocaleConfig struct {
+	Default   string   `yaml:"default" env:"DEFAULT_LOCALE"`      // used when negotiation finds no match
+	Supported []string `yaml:"supported" env:"SUPPORTED_LOCALES"` // comma-separated in the environment
+}
+
+// PasswordConfig is the password policy and the password reset.
+type PasswordConfig struct {
+	MinLength     int           `yaml:"min_length" env:"MIN_LENGTH"`
+	RequireLetter bool          `yaml:"require_letter" env:"REQUIRE_LETTER"`
+	RequireUpper  bool          `yaml:"require_upper" env:"REQUIRE_UPPER"`
+	RequireDigit  bool          `yaml:"require_digit" env:"REQUIRE_DIGIT"`
+	RequireSymbol bool          `yaml:"require_symbol" env:"REQUIRE_SYMBOL"`
+	ResetTokenTTL time.Duration `yaml:"reset_token_ttl" env:"RESET_TOKEN_TTL"` // validity of a reset token after the OTP was verified
+}
+
+// RateLimitConfig allows Limit requests per key within a sliding Window. The
+// variables are prefixed with the name of the limit, e.g. LOGIN_IP_RATE_LIMIT.
+type RateLimitConfig struct {
+	Limit  int           `yaml:"limit" env:"LIMIT"` // 0 disables the limit
+	Window time.Duration `yaml:"window" env:"WINDOW"`
+}
+
+// LoginConfig configures the sign-in limits and the account lockout.
+type LoginConfig struct {
+	IPRate           RateLimitConfig `yaml:"ip_rate" env:"IP_RATE"`
+	UserRate         RateLimitConfig `yaml:"user_rate" env:"USER_RATE"`
+	LockoutThreshold int             `yaml:"lockout_threshold" env:"LOCKOUT_THRESHOLD"` // failures before the first lock
+	LockoutBase      time.Duration   `yaml:"lockout_base" env:"LOCKOUT_BASE"`           // first lock; doubled for every further failure
+	LockoutMax       time.Duration   `yaml:"lockout_max" env:"LOCKOUT_MAX"`
+}
+
+// OTPConfig configures the limits of the phone verification endpoints.
+type OTPConfig struct {
+	IPRate     RateLimitConfig `yaml:"ip_rate" env:"IP_RATE"`
+	TargetRate RateLimitConfig `yaml:"target_rate" env:"TARGET_RATE"` // per phone number or user
+}
+
+// AccountConfig configures account deletion.
+type AccountConfig struct {
+	DeletionGrace time.Duration `yaml:"deletion_grace" env:"DELETION_GRACE"` // how long a deletion request can be cancelled
+}
+
+// SOSConfig limits how often SOS messages are sent for a user.
+type SOSConfig struct {
+	Rate         RateLimitConfig `yaml:"rate" env:"RATE"`
+	AutoCooldown time.Duration   `yaml:"auto_cooldown" env:"AUTO_COOLDOWN"` // minimum gap before an automatic alert follows any other alert
+}
+
+// PanicConfig holds the vital sign levels that together indicate a panic attack.
+type PanicConfig struct {
+	HeartRate   int `yaml:"heart_rate" env:"HEART_RATE"`     // beats per minute
+	StressLevel int `yaml:"stress_level" env:"STRESS_LEVEL"` // 0-100 scale
+}
+
+// ShareConfig configures care sharing.
+type ShareConfig struct {
+	InviteTTL time.Duration `yaml:"invite_ttl" env:"INVITE_TTL"`
+}
+
+// InsightsConfig controls the analysis window and the nightly batch.
+type InsightsConfig struct {
+	WindowDays  int    `yaml:"window_days" env:"WINDOW_DAYS"`   // episodes started within this many days are analyzed
+	MinEpisodes int    `yaml:"min_episodes" env:"MIN_EPISODES"` // fewer episodes produce statistics but no insights
+	BatchAt     string `yaml:"batch_at" env:"BATCH_AT"`         // HH:MM server time of the nightly batch
+}
+
+// QuotaConfig holds the token limits of a plan. A zero limit means unlimited.
+type QuotaConfig struct {
+	Daily   int64 `yaml:"daily" env:"DAILY"`
+	Monthly int64 `yaml:"monthly" env:"MONTHLY"`
+}
+
+// LLMQuotaConfig holds the chatbot token quota of each plan.
+type LLMQuotaConfig struct {
+	Free    QuotaConfig `yaml:"free" env:"FREE"`
+	Premium QuotaConfig `yaml:"premium" env:"PREMIUM"`
+}
+
+// Plan returns the quota of the named plan.
+func (c LLMQuotaConfig) Plan(name string) (QuotaConfig, bool) {
+	switch name {
+	case "free":
+		return c.Free, true
+	case "premium":
+		return c.Premium, true
+	}
+	return QuotaConfig{}, false
+}
+
+// PushConfig configures the delivery of push notifications.
+type PushConfig struct {
+	Fake         bool          `yaml:"fake" env:"FAKE"` // only log pushes, for local development
+	MaxAttempts  int           `yaml:"max_attempts" env:"MAX_ATTEMPTS"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" env:"RETRY_BACKOFF"` // doubled after every attempt
+}
+
+// FCMConfig configures Firebase Cloud Messaging. FCM is off when CredentialsFile is empty.
+type FCMConfig struct {
+	CredentialsFile string `yaml:"credentials_file" env:"CREDENTIALS_FILE"` // service account JSON
+	ProjectID       string `yaml:"project_id" env:"PROJECT_ID"`             // defaults to the project of the service account
+}
+
+// APNsConfig configures the Apple Push Notification service. APNs is off when KeyFile is empty.
+type APNsConfig struct {
+	KeyFile    string `yaml:"key_file" env:"KEY_FILE"`
+	KeyID      string `yaml:"key_id" env:"KEY_ID"`
+	TeamID     string `yaml:"team_id" env:"TEAM_ID"`
+	Topic      string `yaml:"topic" env:"TOPIC"` // the app bundle ID
+	Production bool   `yaml:"production" env:"PRODUCTION"`
+}
+
+// JobsConfig configures the background job runner.
+type JobsConfig struct {
+	Workers       int           `yaml:"workers" env:"WORKERS"`
+	PollInterval  time.Duration `yaml:"poll_interval" env:"POLL_INTERVAL"`
+	LockTimeout   time.Duration `yaml:"lock_timeout" env:"LOCK_TIMEOUT"`     // running jobs whose lock was not renewed for this long are released
+	Retention     time.Duration `yaml:"retention" env:"RETENTION"`           // succeeded jobs are kept this long
+	DeadRetention time.Duration `yaml:"dead_retention" env:"DEAD_RETENTION"` // dead jobs are kept this long for inspection and retries
+}
+
+// ReadyzConfig controls the readiness checks.
+type ReadyzConfig struct {
+	Timeout     time.Duration `yaml:"timeout" env:"TIMEOUT"`             // deadline of the database checks and of one LLM probe
+	LLMCacheTTL time.Duration `yaml:"llm_cache_ttl" env:"LLM_CACHE_TTL"` // how long an LLM probe result is reused
+	RequireLLM  bool          `yaml:"require_llm" env:"REQUIRE_LLM"`     // an unreachable LLM fails readiness instead of degrading it
+}
+
+// setFeatureDefaults fills the defaults of the feature settings.
+func setFeatureDefaults(c *Config) {
+	c.Locale = LocaleConfig{Default: "ko", Supported: []string{"ko", "en"}}
+	c.Password = PasswordConfig{MinLength: 8, RequireLetter: true, RequireDigit: true, ResetTokenTTL: 15 * time.Minute}
+	c.Login = LoginConfig{
+		IPRate:           RateLimitConfig{Limit: 20, Window: 15 * time.Minute},
+		UserRate:         RateLimitConfig{Limit: 10, Window: 15 * time.Minute},
+		LockoutThreshold: 5,
+		LockoutBase:      time.Minute,
+		LockoutMax:       time.Hour,
+	}
+	c.OTP = OTPConfig{
+		IPRate:     RateLimitConfig{Limit: 10, Window: 15 * time.Minute},
+		TargetRate: RateLimitConfig{Limit: 5, Window: 15 * time.Minute},
+	}
+	c.Account = AccountConfig{DeletionGrace: 30 * 24 * time.Hour}
+	c.SOS = SOSConfig{Rate: RateLimitConfig{Limit: 3, Window: time.Hour}, AutoCooldown: 30 * time.Minute}
+	c.Panic = PanicConfig{HeartRate: 130, StressLevel: 85}
+	c.Share = ShareConfig{InviteTTL: 72 * time.Hour}
+	c.Export.Dir = filepath.Join(os.TempDir(), "panicshield-exports")
+	c.Export.LinkTTL = time.Hour
+	c.Export.Retention = 72 * time.Hour
+	c.Insights = InsightsConfig{WindowDays: 90, MinEpisodes: 5, BatchAt: "03:00"}
+	c.LLMQuota = LLMQuotaConfig{
+		Free:    QuotaConfig{Daily: 20000, Monthly: 300000},
+		Premium: QuotaConfig{Daily: 200000, Monthly: 3000000},
+	}
+	c.Push = PushConfig{MaxAttempts: 3, RetryBackoff: 2 * time.Second}
+	c.Jobs = JobsConfig{
+		Workers:       4,
+		PollInterval:  time.Second,
+		LockTimeout:   15 * time.Minute,
+		Retention:     7 * 24 * time.Hour,
+		DeadRetention: 30 * 24 * time.Hour,
+	}
+	c.Readyz = ReadyzConfig{Timeout: 2 * time.Second, LLMCacheTTL: 30 * time.Second}
+}
+
+// validateFeatures checks the ranges of the feature settings. fail is called
+// with the YAML path and variable of the offending field.
+func (c *Config) validateFeatures(fail func(path, env, format string, args ...interface{})) {
+	field := func(ptr interface{}, format string, args ...interface{}) {
+		path, env := c.describe(ptr)
+		fail(path, env, format, args...)
+	}
+	positive := func(ptrs ...*int) {
+		for _, p := range ptrs {
+			if *p <= 0 {
+				field(p, "must be positive, got %d", *p)
+			}
+		}
+	}
+	positiveDuration := func(ptrs ...*time.Duration) {
+		for _, p := range ptrs {
+			if *p <= 0 {
+				field(p, "must be a positive duration such as 30s or 15m, got %s", *p)
+			}
+		}
+	}
+	notNegativeDuration := func(ptrs ...*time.Duration) {
+		for _, p := range ptrs {
+			if *p < 0 {
+				field(p, "must not be negative, got %s", *p)
+			}
+		}
+	}
+	rate := func(limits ...*RateLimitConfig) {
+		for _, l := range limits {
+			if l.Limit < 0 {
+				field(&l.Limit, "must not be negative, got %d", l.Limit)
+			}
+			positiveDuration(&l.Window)
+		}
+	}
+
+	if len(c.Locale.Supported) == 0 {
+		field(&c.Locale.Supported, "must list at least one locale")
+	}
+	if !slices.Contains(c.Locale.Supported, strings.ToLower(c.Locale.Default)) {
+		field(&c.Locale.Default, "%q is not one of the supported locales %v", c.Locale.Default, c.Locale.Supported)
+	}
+
+	positive(&c.Password.MinLength, &c.Login.LockoutThreshold, &c.SOS.Rate.Limit,
+		&c.Panic.HeartRate, &c.Panic.StressLevel, &c.Insights.WindowDays, &c.Insights.MinEpisodes,
+		&c.Push.MaxAttempts, &c.Jobs.Workers)
+	positiveDuration(&c.Password.ResetTokenTTL, &c.Login.LockoutBase, &c.Login.LockoutMax,
+		&c.Share.InviteTTL, &c.Export.LinkTTL, &c.Export.Retention,
+		&c.Jobs.PollInterval, &c.Jobs.LockTimeout, &c.Jobs.Retention, &c.Jobs.DeadRetention, &c.Readyz.Timeout)
+	notNegativeDuration(&c.Account.DeletionGrace, &c.SOS.AutoCooldown, &c.Push.RetryBackoff, &c.Readyz.LLMCacheTTL)
+	rate(&c.Login.IPRate, &c.Login.UserRate, &c.OTP.IPRate, &c.OTP.TargetRate)
+	positiveDuration(&c.SOS.Rate.Window)
+
+	if c.Password.MinLength > 72 {
+		field(&c.Password.MinLength, "must be at most 72, the longest password bcrypt can hash")
+	}
+	if c.Login.LockoutMax < c.Login.LockoutBase {
+		field(&c.Login.LockoutMax, "must not be shorter than login.lockout_base (%s)", c.Login.LockoutBase)
+	}
+	if c.Panic.StressLevel > 100 {
+		field(&c.Panic.StressLevel, "must be at most 100, got %d", c.Panic.StressLevel)
+	}
+	if c.Export.Dir == "" {
+		field(&c.Export.Dir, "must be set")
+	}
+	if _, err := time.Parse("15:04", c.Insights.BatchAt); err != nil {
+		field(&c.Insights.BatchAt, "%q must be a time such as 03:00", c.Insights.BatchAt)
+	}
+	for _, q := range []*QuotaConfig{&c.LLMQuota.Free, &c.LLMQuota.Premium} {
+		if q.Daily < 0 {
+			field(&q.Daily, "must not be negative, got %d", q.Daily)
+		}
+		if q.Monthly < 0 {
+			field(&q.Monthly, "must not be negative, got %d", q.Monthly)
+		}
+	}
+	if c.APNs.KeyFile != "" {
+		for _, p := range []*string{&c.APNs.KeyID, &c.APNs.TeamID, &c.APNs.Topic} {
+			if *p == "" {
+				field(p, "must be set when apns.key_file is set")
+			}
+		}
+	}
+}
+
+// describe returns the YAML path and the variable of the field ptr points to.
+func (c *Config) describe(ptr interface{}) (path, env string) {
+	addr := reflect.ValueOf(ptr).Pointer()
+	for _, f := range fields(reflect.ValueOf(c).Elem(), "", "") {
+		if f.value.Addr().Pointer() == addr {
+			return f.path, f.env
+		}
+	}
+	return "", ""
+}
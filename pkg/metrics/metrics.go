@@ -0,0 +1,277 @@
+// Package metrics keeps counters, histograms and gauges and serves them in the
+// Prometheus text exposition format.
+//
+// Metrics are declared once as package-level variables with the New functions,
+// which register them with Handler and panic on a duplicate name, as a
+// duplicate is a programming error.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are histogram buckets in seconds suited to request latencies.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// registry is a set of metrics written out together.
+type registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric writes its HELP and TYPE lines followed by its samples.
+type metric interface {
+	write(w *bufio.Writer, name string)
+}
+
+// defaultRegistry holds the metrics registered by the New functions.
+var defaultRegistry = &registry{metrics: make(map[string]metric)}
+
+func (r *registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.metrics[name] = m
+}
+
+// write writes every metric in the text exposition format, ordered by name.
+func (r *registry) write(w *bufio.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]metric, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	for i, m := range metrics {
+		m.write(w, names[i])
+	}
+	return w.Flush()
+}
+
+// Handler serves the registered metrics for a Prometheus scrape.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		defaultRegistry.write(bufio.NewWriter(w))
+	})
+}
+
+// vec holds the series of a labelled metric keyed by their label values.
+type vec[T any] struct {
+	help   string
+	labels []string
+	mu     sync.Mutex
+	series map[string]*series[T]
+	init   func() *T
+}
+
+type series[T any] struct {
+	values []string
+	data   T
+}
+
+func newVec[T any](help string, labels []string, init func() *T) *vec[T] {
+	return &vec[T]{help: help, labels: labels, series: make(map[string]*series[T]), init: init}
+}
+
+// with returns the series of the label values, creating it on first use. The
+// caller must hold v.mu.
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(values), v.labels))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series[T]{values: append([]string(nil), values...), data: *v.init()}
+		v.series[key] = s
+	}
+	return &s.data
+}
+
+// sorted returns the series ordered by label values. The caller must hold v.mu.
+func (v *vec[T]) sorted() []*series[T] {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]*series[T], len(keys))
+	for i, key := range keys {
+		out[i] = v.series[key]
+	}
+	return out
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	*vec[float64]
+}
+
+// NewCounterVec registers a counter. By convention its name ends in _total.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(help, labels, func() *float64 { return new(float64) })}
+	defaultRegistry.register(name, c)
+	return c
+}
+
+// Inc adds one to the series of the label values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds a non-negative amount to the series of the label values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	*c.with(values) += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer, name string) {
+	writeHeader(w, name, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.sorted() {
+		writeSample(w, name, c.labels, s.values, "", "", s.data)
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	*vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram with the given upper bucket bounds in
+// increasing order; the +Inf bucket is implied.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: buckets of %s are not sorted", name))
+	}
+	h := &HistogramVec{buckets: buckets}
+	h.vec = newVec(help, labels, func() *histogram { return &histogram{counts: make([]uint64, len(buckets))} })
+	defaultRegistry.register(name, h)
+	return h
+}
+
+// Observe records a value in the series of the label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	s := h.with(values)
+	if i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+	h.mu.Unlock()
+}
+
+func (h *HistogramVec) write(w *bufio.Writer, name string) {
+	writeHeader(w, name, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.sorted() {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.data.counts[i]
+			writeSample(w, name+"_bucket", h.labels, s.values, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, name+"_bucket", h.labels, s.values, "le", "+Inf", float64(s.data.count))
+		writeSample(w, name+"_sum", h.labels, s.values, "", "", s.data.sum)
+		writeSample(w, name+"_count", h.labels, s.values, "", "", float64(s.data.count))
+	}
+}
+
+// funcMetric is a single unlabelled value read at scrape time.
+type funcMetric struct {
+	help string
+	kind string
+	fn   func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every scrape,
+// for values owned elsewhere such as a connection count.
+func NewGaugeFunc(name, help string, fn func() float64) {
+	defaultRegistry.register(name, &funcMetric{help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc registers a counter whose value is read from fn on every
+// scrape; fn must never return a smaller value than before.
+func NewCounterFunc(name, help string, fn func() float64) {
+	defaultRegistry.register(name, &funcMetric{help: help, kind: "counter", fn: fn})
+}
+
+func (f *funcMetric) write(w *bufio.Writer, name string) {
+	writeHeader(w, name, f.help, f.kind)
+	writeSample(w, name, nil, nil, "", "", f.fn())
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, kind)
+}
+
+// writeSample writes one sample line; extraLabel, such as le, follows the labels if set.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, labelEscaper.Replace(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
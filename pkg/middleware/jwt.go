@@ -1,102 +1,197 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"ps_backend/db"
+	"ps_backend/model"
+	"ps_backend/pkg/config"
 )
 
 // JWTClaims defines custom claims structure
 type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
+	DeviceID uint   `json:"device_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT access token and refresh token pair.
-func GenerateToken(userID uint, username string) (accessToken string, refreshToken string, err error) {
-	secret := []byte(os.Getenv("ACCESS_SECRET"))
-	refreshSecret := []byte(os.Getenv("REFRESH_SECRET"))
+// TokenPair is a freshly issued access and refresh token. RefreshID is the
+// unique ID (jti) of the refresh token, used to track and revoke it.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshID        string
+	RefreshExpiresAt time.Time
+	DeviceID         uint
+}
+
+// IssueTokens generates a JWT access token and a uniquely identified refresh token.
+// Both are bound to the device unless deviceID is zero.
+func IssueTokens(userID uint, username, role string, deviceID uint) (*TokenPair, error) {
+	auth := config.Get().Auth
+	secret := []byte(auth.AccessSecret)
+	refreshSecret := []byte(auth.RefreshSecret)
 	if len(secret) == 0 || len(refreshSecret) == 0 {
-		return "", "", ErrMissingSecrets
+		return nil, ErrMissingSecrets
 	}
+	now := time.Now()
 
 	// Access token
 	atClaims := JWTClaims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
+		DeviceID: deviceID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   "access_token",
 		},
 	}
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-	accessToken, err = at.SignedString(secret)
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims).SignedString(secret)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	// Refresh token
-	rtClaims := jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		Subject:   "refresh_token",
-		ID:        username,
-	}
-	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims)
-	refreshToken, err = rt.SignedString(refreshSecret)
-	return
+	refreshID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpires := now.Add(7 * 24 * time.Hour)
+	rtClaims := JWTClaims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		DeviceID: deviceID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshID,
+			ExpiresAt: jwt.NewNumericDate(refreshExpires),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   "refresh_token",
+		},
+	}
+	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims).SignedString(refreshSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshID:        refreshID,
+		RefreshExpiresAt: refreshExpires,
+		DeviceID:         deviceID,
+	}, nil
 }
 
-// GenerateTokenFromRefresh validates a refresh token and issues new access and refresh tokens.
-func GenerateTokenFromRefresh(refreshToken string) (string, string, error) {
-	refreshSecret := []byte(os.Getenv("REFRESH_SECRET"))
+// GenerateToken generates a JWT access token and refresh token pair.
+// The refresh token is not tracked; sign-in flows should go through the token
+// service so it can be revoked.
+func GenerateToken(userID uint, username, role string) (accessToken string, refreshToken string, err error) {
+	pair, err := IssueTokens(userID, username, role, 0)
+	if err != nil {
+		return "", "", err
+	}
+	return pair.AccessToken, pair.RefreshToken, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ParseRefreshToken validates a refresh token and returns its claims.
+func ParseRefreshToken(refreshToken string) (*JWTClaims, error) {
+	refreshSecret := []byte(config.Get().Auth.RefreshSecret)
 	if len(refreshSecret) == 0 {
-		return "", "", ErrMissingSecrets
+		return nil, ErrMissingSecrets
 	}
 
-	// Parse refresh token
-	token, err := jwt.ParseWithClaims(refreshToken, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(refreshToken, &JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return refreshSecret, nil
 	})
-	if err != nil || !token.Valid {
-		return "", "", err
+	if err != nil {
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
-	if !ok || claims.Subject != "refresh_token" {
-		return "", "", fmt.Errorf("invalid refresh token")
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid || claims.Subject != "refresh_token" || claims.UserID == 0 {
+		return nil, fmt.Errorf("invalid refresh token")
 	}
+	return claims, nil
+}
 
-	// Extract userID from IssuedAt timestamp encoded as Unix seconds in Token ID if that's intended.
-	// Here, assuming the username is stored in ID, and userID must be reloaded via service in real code.
-	username := claims.ID
+// ErrMissingSecrets is returned when the JWT secrets are not configured
+var ErrMissingSecrets = gin.Error{
+	Err:  jwt.ErrTokenMalformed,
+	Type: gin.ErrorTypePrivate,
+}
 
-	// Generate new tokens
-	return GenerateToken(parseUint(username), username)
+// parseAccessToken validates an "Authorization: Bearer" header value and returns its claims.
+func parseAccessToken(authHeader string) (*JWTClaims, error) {
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	secret := []byte(config.Get().Auth.AccessSecret)
+	if len(secret) == 0 {
+		return nil, ErrMissingSecrets
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return claims, nil
 }
 
-// parseUint is a helper to convert string to uint
-func parseUint(s string) uint {
-	v, err := strconv.ParseUint(s, 10, 64)
+// tokenRevoked reports whether the access token was issued before the user's
+// sessions were revoked, e.g. by a password change, or the user no longer exists.
+// Issue times have second precision, so a token issued within the second
+// before the revocation is still accepted. For a valid token it replaces the
+// role claim with the stored role, so a role change applies to issued tokens
+// at once.
+func tokenRevoked(claims *JWTClaims) (bool, error) {
+	var user model.User
+	err := db.GetDB().Select("id", "role", "tokens_valid_after").Where("id = ?", claims.UserID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
 	if err != nil {
-		return 0
+		return false, err
+	}
+	if user.TokensValidAfter != nil &&
+		(claims.IssuedAt == nil || claims.IssuedAt.Time.Before(user.TokensValidAfter.Truncate(time.Second))) {
+		return true, nil
 	}
-	return uint(v)
+	claims.Role = user.Role
+	return false, nil
 }
 
-// ErrMissingSecrets is returned when env secrets are not set
-var ErrMissingSecrets = gin.Error{
-	Err:  jwt.ErrTokenMalformed,
-	Type: gin.ErrorTypePrivate,
+// setClaims stores the authenticated user info in the request context.
+func setClaims(c *gin.Context, claims *JWTClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("device_id", claims.DeviceID)
 }
 
 // JWTAuthMiddleware validates the JWT access token.
@@ -107,29 +202,71 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			return
 		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		secret := []byte(os.Getenv("ACCESS_SECRET"))
-		if len(secret) == 0 {
-			logrus.Error("ACCESS_SECRET env var not set")
+		if config.Get().Auth.AccessSecret == "" {
+			logrus.Error("ACCESS_SECRET is not configured")
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server error"})
 			return
 		}
-		token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := parseAccessToken(authHeader)
+		if err != nil {
 			logrus.WithError(err).Warn("Invalid JWT token")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			return
 		}
-		claims, ok := token.Claims.(*JWTClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		revoked, err := tokenRevoked(claims)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to check token revocation")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			return
 		}
 		// set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		setClaims(c, claims)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuthMiddleware sets the user info when a valid access token is
+// present but lets anonymous requests through.
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if claims, err := parseAccessToken(authHeader); err == nil {
+				if revoked, err := tokenRevoked(claims); err == nil && !revoked {
+					setClaims(c, claims)
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole allows the request only when the authenticated user has one of the roles.
+// It must run after JWTAuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
+
+// RequirePermission allows the request only when the authenticated user's role grants the permission.
+// It must run after JWTAuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.HasPermission(c.GetString("role"), permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			return
+		}
 		c.Next()
 	}
 }
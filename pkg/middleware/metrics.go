@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"ps_backend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var requestDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+	"Latency of HTTP requests by route pattern, method and status code.",
+	metrics.DefBuckets, "method", "route", "status")
+
+// Metrics records the latency of every request. Requests are labelled with the
+// route pattern, e.g. /api/users/me/episodes/:id, so IDs do not create new series;
+// requests matching no route share the route label "unmatched".
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requestDuration.Observe(time.Since(started).Seconds(), c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ps_backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitByIP rejects requests with 429 once the client IP exceeds the limiter.
+func RateLimitByIP(limiter *utils.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, retryAfter := limiter.Allow(c.ClientIP()); !ok {
+			AbortTooManyRequests(c, retryAfter)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortTooManyRequests writes a 429 response with a Retry-After header.
+func AbortTooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many attempts, please try again later",
+		"retry_after": seconds,
+	})
+}
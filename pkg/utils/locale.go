@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"ps_backend/pkg/config"
+)
+
+// DefaultLocale returns the locale used when negotiation finds no match (DEFAULT_LOCALE, default "ko").
+func DefaultLocale() string {
+	return strings.ToLower(config.Get().Locale.Default)
+}
+
+// SupportedLocales returns the locales served by the API (SUPPORTED_LOCALES, comma-separated).
+func SupportedLocales() []string {
+	var locales []string
+	for _, l := range config.Get().Locale.Supported {
+		locales = append(locales, strings.ToLower(l))
+	}
+	return locales
+}
+
+// IsSupportedLocale reports whether the locale is served by the API.
+func IsSupportedLocale(locale string) bool {
+	for _, l := range SupportedLocales() {
+		if l == strings.ToLower(locale) {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateLocale picks the best supported locale for an Accept-Language header
+// such as "en-US,en;q=0.9,ko;q=0.8", falling back to DefaultLocale.
+func NegotiateLocale(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	supported := SupportedLocales()
+	for _, c := range candidates {
+		if c.tag == "*" {
+			break
+		}
+		primary := strings.SplitN(c.tag, "-", 2)[0]
+		for _, l := range supported {
+			if l == c.tag || l == primary {
+				return l
+			}
+		}
+	}
+	return DefaultLocale()
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"ps_backend/pkg/config"
+)
+
+// ErrWeakPassword is returned when a password does not meet the password policy.
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// PasswordPolicy describes the strength requirements for new passwords.
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	RequireLetter bool `json:"require_letter"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+}
+
+// maxPasswordBytes is the longest password bcrypt can hash.
+const maxPasswordBytes = 72
+
+// LoadPasswordPolicy reads the policy from PASSWORD_MIN_LENGTH (default 8),
+// PASSWORD_REQUIRE_LETTER (default true), PASSWORD_REQUIRE_UPPER,
+// PASSWORD_REQUIRE_DIGIT (default true) and PASSWORD_REQUIRE_SYMBOL.
+func LoadPasswordPolicy() PasswordPolicy {
+	c := config.Get().Password
+	return PasswordPolicy{
+		MinLength:     c.MinLength,
+		RequireLetter: c.RequireLetter,
+		RequireUpper:  c.RequireUpper,
+		RequireDigit:  c.RequireDigit,
+		RequireSymbol: c.RequireSymbol,
+	}
+}
+
+// Check validates the password against the policy. The password must also
+// differ from the username. Errors wrap ErrWeakPassword.
+func (p PasswordPolicy) Check(password, username string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: must be at most %d bytes", ErrWeakPassword, maxPasswordBytes)
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return fmt.Errorf("%w: must differ from the username", ErrWeakPassword)
+	}
+	var letter, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+			upper = upper || unicode.IsUpper(r)
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireLetter && !letter:
+		return fmt.Errorf("%w: must contain a letter", ErrWeakPassword)
+	case p.RequireUpper && !upper:
+		return fmt.Errorf("%w: must contain an uppercase letter", ErrWeakPassword)
+	case p.RequireDigit && !digit:
+		return fmt.Errorf("%w: must contain a digit", ErrWeakPassword)
+	case p.RequireSymbol && !symbol:
+		return fmt.Errorf("%w: must contain a symbol", ErrWeakPassword)
+	}
+	return nil
+}
+
+// ValidatePassword checks the password against the configured policy.
+func ValidatePassword(password, username string) error {
+	return LoadPasswordPolicy().Check(password, username)
+}
+
+// generatedPasswordLength is the length of generated passwords unless the policy asks for more.
+const generatedPasswordLength = 20
+
+var passwordAlphabets = []string{
+	"abcdefghijkmnopqrstuvwxyz",
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"23456789",
+	"!#%+-=?@_",
+}
+
+// Generate returns a random password that satisfies the policy. It contains
+// at least one character of each class and avoids look-alike characters.
+func (p PasswordPolicy) Generate() (string, error) {
+	length := generatedPasswordLength
+	if p.MinLength > length {
+		length = p.MinLength
+	}
+	if length > maxPasswordBytes {
+		length = maxPasswordBytes
+	}
+	out := make([]byte, 0, length)
+	for _, alphabet := range passwordAlphabets {
+		c, err := randomChar(alphabet)
+		if err != nil {
+			return "", err
+		}
+		out = append(out, c)
+	}
+	all := strings.Join(passwordAlphabets, "")
+	for len(out) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		out = append(out, c)
+	}
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		out[i], out[j.Int64()] = out[j.Int64()], out[i]
+	}
+	return string(out), nil
+}
+
+func randomChar(alphabet string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+	if err != nil {
+		return 0, err
+	}
+	return alphabet[n.Int64()], nil
+}
+
+// GeneratePassword returns a random password satisfying the configured policy.
+func GeneratePassword() (string, error) {
+	return LoadPasswordPolicy().Generate()
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"ps_backend/pkg/config"
+)
+
+// RateLimiter allows at most limit events per key within a sliding window.
+// State is kept in memory, so limits apply per server instance.
+type RateLimiter struct {
+	limit     int
+	window    time.Duration
+	mu        sync.Mutex
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+// NewRateLimiter creates a limiter allowing limit events per key within window.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, hits: make(map[string][]time.Time), lastSweep: time.Now()}
+}
+
+// NewRateLimiterFromConfig creates a limiter from a rate limit setting. A limit
+// of 0 disables it.
+func NewRateLimiterFromConfig(cfg config.RateLimitConfig) *RateLimiter {
+	return NewRateLimiter(cfg.Limit, cfg.Window)
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+// When it is not, it also returns how long until the next event is allowed.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.limit == 0 {
+		return true, 0
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.window {
+		for k, hits := range l.hits {
+			if len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	hits := l.hits[key]
+	start := 0
+	for start < len(hits) && now.Sub(hits[start]) >= l.window {
+		start++
+	}
+	hits = hits[start:]
+	if len(hits) >= l.limit {
+		l.hits[key] = hits
+		return false, l.window - now.Sub(hits[0])
+	}
+	l.hits[key] = append(hits, now)
+	return true, 0
+}
+
+// Reset forgets the events recorded for key.
+func (l *RateLimiter) Reset(key string) {
+	l.mu.Lock()
+	delete(l.hits, key)
+	l.mu.Unlock()
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -40,6 +41,16 @@ func ParseUint(s string) (uint, error) {
 	return uint(val), nil
 }
 
+// MaskPhone hides the middle of a phone number for logging, keeping the first
+// three and last four digits, e.g. 010****5678. Short numbers are masked entirely.
+func MaskPhone(phone string) string {
+	r := []rune(phone)
+	if len(r) < 8 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:3]) + strings.Repeat("*", len(r)-7) + string(r[len(r)-4:])
+}
+
 // GetEnv reads an environment variable or returns a default value if unset.
 func GetEnv(key, defaultVal string) string {
 	if v := os.Getenv(key); v != "" {
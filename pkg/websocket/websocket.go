@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
@@ -17,10 +18,10 @@ type Client struct {
 	Send chan []byte
 }
 
-// Hub maintains the set of active clients and broadcasts messages.
+// Hub maintains the set of active clients. Messages are only ever pushed by
+// the server to one client ID with SendToClient.
 type Hub struct {
 	clients    map[*Client]bool
-	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.Mutex
@@ -30,7 +31,6 @@ type Hub struct {
 func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 	}
@@ -53,21 +53,37 @@ func (h *Hub) Run() {
 				logrus.Infof("WebSocket client unregistered: %s", client.ID)
 			}
 			h.mu.Unlock()
-		case message := <-h.broadcast:
-			h.mu.Lock()
-			for client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.clients, client)
-				}
-			}
-			h.mu.Unlock()
 		}
 	}
 }
 
+// ClientCount returns the number of open connections.
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// SendToClient queues a message for every connection of the given client ID
+// and returns the number of connections it was delivered to.
+func (h *Hub) SendToClient(clientID string, message []byte) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sent := 0
+	for client := range h.clients {
+		if client.ID != clientID {
+			continue
+		}
+		select {
+		case client.Send <- message:
+			sent++
+		default:
+			logrus.Warnf("WebSocket send buffer full for client %s", client.ID)
+		}
+	}
+	return sent
+}
+
 // Upgrader config for Gorilla WebSocket
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
@@ -84,8 +100,13 @@ func ServeWS(hub *Hub) gin.HandlerFunc {
 			c.AbortWithStatus(http.StatusBadRequest)
 			return
 		}
+		// Prefer the authenticated user over the query parameter.
+		clientID := c.Query("user_id")
+		if userID, ok := c.Get("user_id"); ok {
+			clientID = fmt.Sprint(userID)
+		}
 		client := &Client{
-			ID:   c.Query("user_id"),
+			ID:   clientID,
 			Conn: conn,
 			Send: make(chan []byte, 256),
 		}
@@ -97,7 +118,8 @@ func ServeWS(hub *Hub) gin.HandlerFunc {
 	}
 }
 
-// readPump reads messages from the WebSocket connection.
+// readPump keeps the connection alive and notices when it closes. Clients have
+// nothing to send, so their frames are discarded rather than relayed.
 func (c *Client) readPump(hub *Hub) {
 	defer func() {
 		hub.unregister <- c
@@ -110,11 +132,9 @@ func (c *Client) readPump(hub *Hub) {
 		return nil
 	})
 	for {
-		_, message, err := c.Conn.ReadMessage()
-		if err != nil {
+		if _, _, err := c.Conn.ReadMessage(); err != nil {
 			break
 		}
-		hub.broadcast <- message
 	}
 }
 